@@ -18,6 +18,20 @@ var (
 	EpoolLRUSampleSize = getEnvAsInt("REDIS_EPOOL_LRU_SAMPLE_SIZE", 5)
 )
 
+// RESP protocol limits
+var (
+	ProtoMaxBulkLen      = getEnvAsInt("REDIS_PROTO_MAX_BULK_LEN", 512*1024*1024)
+	ProtoMaxMultiBulkLen = getEnvAsInt("REDIS_PROTO_MAX_MULTIBULK_LEN", 1024*1024)
+)
+
+// Data structure encodings
+var (
+	// Sets made of integers only stay in the compact intset encoding up to this many members
+	SetMaxIntsetEntries = getEnvAsInt("REDIS_SET_MAX_INTSET_ENTRIES", 512)
+	// HyperLogLogs stay in the sparse representation while it takes up to this many bytes
+	HllSparseMaxBytes = getEnvAsInt("REDIS_HLL_SPARSE_MAX_BYTES", 3000)
+)
+
 // HTTP Gateway configuration
 var (
 	HTTPPort         = getEnv("HTTP_PORT", ":8080")
@@ -3,6 +3,8 @@ package constant
 import "time"
 
 var RespNil = []byte("$-1\r\n")
+var RespNilArray = []byte("*-1\r\n")
+var RespEmptyArray = []byte("*0\r\n")
 var RespOk = []byte("+OK\r\n")
 var RespZero = []byte(":0\r\n")
 var RespOne = []byte(":1\r\n")
@@ -12,10 +14,32 @@ var ActiveExpireFrequency = 100 * time.Millisecond
 var ActiveExpireSampleSize = 20
 var ActiveExpireThreshold = 0.1
 var DefaultBPlusTreeDegree = 4
+var ListMaxNodeSize = 128 // Max number of elements in one quicklist node
 
 const BfDefaultInitCapacity = 100
 const BfDefaultErrRate = 0.01
+const BfDefaultExpansion = 2
+const BfMaxCapacity = 1 << 30 // Max capacity of BF.RESERVE
+
+const CfDefaultInitCapacity = 1024
+const CfDefaultBucketSize = 2
+const CfDefaultMaxIterations = 20
+const CfDefaultExpansion = 1
+
+const TopkDefaultWidth = 8
+const TopkDefaultDepth = 7
+const TopkDefaultDecay = 0.9
+const TopkMaxK = 100000        // Max number of items tracked by a Top-K
+const TopkMaxBuckets = 1 << 24 // Max width * depth of a Top-K, each bucket takes 16 bytes
+
+const TDigestDefaultCompression = 100
+const TDigestMaxCompression = 10000 // Buffers 5 * compression observations
 
 const ServerStatusIdle int32 = 0
 const ServerStatusShutdown int32 = 1
 const ServerStatusRunning int32 = 2
+
+// Client connection I/O
+const IOBufferSize = 16 * 1024       // Bytes read from a client socket per read call
+const ProtoInlineMaxSize = 64 * 1024 // Max size of an inline command or of a multibulk header line
+const ReplyChannelSize = 4096        // Replies buffered between the workers and an I/O handler
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+)
+
+// Blocking commands (flagged FlagBlocking) take a timeout as their last argument. Their handler
+// serves the command right away when one of its keys is ready, and returns nil otherwise.
+// A Worker then parks the client until a write makes one of the keys ready, the timeout expires
+// or the client disconnects. Outside of a Worker the command does not wait and replies as if
+// it timed out, like a blocking command inside a Redis transaction.
+
+// parseBlockTimeout parses the timeout of a blocking command, in seconds. 0 means no timeout.
+func parseBlockTimeout(arg string) (time.Duration, error) {
+	timeout, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(timeout) || math.IsInf(timeout, 0) || timeout > math.MaxInt64/float64(time.Second) {
+		return 0, errors.New("ERR timeout is not a float or out of range")
+	}
+	if timeout < 0 {
+		return 0, errors.New("ERR timeout is negative")
+	}
+	return time.Duration(timeout * float64(time.Second)), nil
+}
+
+// timeoutReply returns the reply of a blocking command that timed out.
+func timeoutReply(spec *CommandSpec) []byte {
+	switch spec.Name {
+	case "BLMOVE", "BRPOPLPUSH":
+		return constant.RespNil
+	}
+	return constant.RespNilArray
+}
+
+// blockingKeys returns the keys a blocking command waits on. BLMOVE and BRPOPLPUSH only wait on
+// their source, a push to the destination does not make them ready.
+func blockingKeys(spec *CommandSpec, args []string) []string {
+	switch spec.Name {
+	case "BLMOVE", "BRPOPLPUSH":
+		return args[:1]
+	}
+	return spec.GetKeys(args)
+}
+
+// blockedClient is a blocking command waiting for one of its keys.
+type blockedClient struct {
+	task  *Task
+	spec  *CommandSpec
+	keys  []string
+	timer *time.Timer // nil without timeout
+	done  bool        // Served, timed out or disconnected
+}
+
+// blockingState is the registry of the clients blocked on a Worker's shard.
+type blockingState struct {
+	byKey    map[string][]*blockedClient // Clients waiting on each key, in the order they blocked
+	byClient map[uint64][]*blockedClient
+}
+
+func newBlockingState() *blockingState {
+	return &blockingState{
+		byKey:    make(map[string][]*blockedClient),
+		byClient: make(map[uint64][]*blockedClient),
+	}
+}
+
+func (b *blockingState) add(bc *blockedClient) {
+	for _, key := range bc.keys {
+		b.byKey[key] = append(b.byKey[key], bc)
+	}
+	b.byClient[bc.task.ClientID] = append(b.byClient[bc.task.ClientID], bc)
+}
+
+// remove unregisters bc from every key it waits on and stops its timer.
+func (b *blockingState) remove(bc *blockedClient) {
+	bc.done = true
+	if bc.timer != nil {
+		bc.timer.Stop()
+	}
+	for _, key := range bc.keys {
+		b.byKey[key] = removeBlocked(b.byKey[key], bc)
+		if len(b.byKey[key]) == 0 {
+			delete(b.byKey, key)
+		}
+	}
+	clientID := bc.task.ClientID
+	b.byClient[clientID] = removeBlocked(b.byClient[clientID], bc)
+	if len(b.byClient[clientID]) == 0 {
+		delete(b.byClient, clientID)
+	}
+}
+
+func removeBlocked(list []*blockedClient, bc *blockedClient) []*blockedClient {
+	for i, x := range list {
+		if x == bc {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// block parks the task until one of its keys is ready.
+func (w *Worker) block(task *Task, spec *CommandSpec) {
+	bc := &blockedClient{
+		task: task,
+		spec: spec,
+		keys: blockingKeys(spec, task.Command.Args),
+	}
+	// The handler already validated the timeout before deciding to block
+	timeout, _ := parseBlockTimeout(task.Command.Args[len(task.Command.Args)-1])
+	if timeout > 0 {
+		ctx := w.ctx
+		bc.timer = time.AfterFunc(timeout, func() {
+			select {
+			case w.timeoutCh <- bc:
+			case <-ctx.Done():
+			}
+		})
+	}
+	w.blocking.add(bc)
+}
+
+// unblock replies to a blocked client that timed out or disconnected.
+func (w *Worker) unblock(bc *blockedClient) {
+	if bc.done {
+		return
+	}
+	w.blocking.remove(bc)
+	bc.task.Reply(timeoutReply(bc.spec))
+}
+
+// serveKeys retries the clients blocked on keys that might just have become ready, in the order
+// they blocked. A served command may write other keys, BLMOVE pushes to its destination for
+// instance, so their clients are retried as well.
+func (w *Worker) serveKeys(keys []string) {
+	if len(w.blocking.byKey) == 0 {
+		return
+	}
+	for len(keys) > 0 {
+		key := keys[0]
+		keys = keys[1:]
+		for i := 0; i < len(w.blocking.byKey[key]); {
+			bc := w.blocking.byKey[key][i]
+			res := w.storage.tryExecute(bc.spec, bc.task.Command)
+			if res == nil {
+				// Not ready for this client, a later one may still be served
+				i++
+				continue
+			}
+			w.blocking.remove(bc)
+			bc.task.Reply(res)
+			keys = append(keys, bc.spec.GetKeys(bc.task.Command.Args)...)
+		}
+	}
+}
+
+// serveAllBlocked retries every blocked client, after the shard was changed from outside the worker.
+func (w *Worker) serveAllBlocked() {
+	keys := make([]string, 0, len(w.blocking.byKey))
+	for key := range w.blocking.byKey {
+		keys = append(keys, key)
+	}
+	w.serveKeys(keys)
+}
+
+// ClientGone unblocks the commands a disconnected client is blocked on. It is queued behind the
+// client's earlier tasks, so a blocking command still in the queue is unblocked as well.
+func (w *Worker) ClientGone(clientID uint64) {
+	w.TaskCh <- &Task{ClientID: clientID, clientGone: true}
+}
@@ -0,0 +1,109 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// sendToWorker queues a command without waiting for its reply.
+func sendToWorker(w *core.Worker, clientID uint64, cmd string, args ...string) chan *core.Reply {
+	replyCh := make(chan *core.Reply, 1)
+	w.TaskCh <- &core.Task{Command: &core.Command{Cmd: cmd, Args: args}, ClientID: clientID, ReplyCh: replyCh}
+	return replyCh
+}
+
+func waitReply(t *testing.T, replyCh chan *core.Reply) string {
+	t.Helper()
+	select {
+	case reply := <-replyCh:
+		return string(reply.Data)
+	case <-time.After(time.Second):
+		t.Fatal("no reply")
+		return ""
+	}
+}
+
+func assertNoReply(t *testing.T, replyCh chan *core.Reply) {
+	t.Helper()
+	select {
+	case reply := <-replyCh:
+		t.Fatalf("unexpected reply %q", reply.Data)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestBlockingPopServesClientsInOrder(t *testing.T) {
+	w := core.NewWorker(0, 16)
+	w.Start(context.Background())
+	defer w.Stop()
+
+	first := sendToWorker(w, 1, "BLPOP", "q1", "q2", "0")
+	second := sendToWorker(w, 2, "BRPOP", "q2", "0")
+	assertNoReply(t, first)
+	assertNoReply(t, second)
+
+	assert.EqualValues(t, ":2\r\n", execOnWorker(w, "RPUSH", "q2", "a", "b"))
+	assert.EqualValues(t, "*2\r\n$2\r\nq2\r\n$1\r\na\r\n", waitReply(t, first))
+	assert.EqualValues(t, "*2\r\n$2\r\nq2\r\n$1\r\nb\r\n", waitReply(t, second))
+	assert.EqualValues(t, ":0\r\n", execOnWorker(w, "EXISTS", "q2"))
+
+	// Served right away when a list is not empty
+	execOnWorker(w, "RPUSH", "q1", "x")
+	assert.EqualValues(t, "*2\r\n$2\r\nq1\r\n$1\r\nx\r\n", execOnWorker(w, "BLPOP", "q1", "0"))
+}
+
+func TestBlockingTimeoutAndDisconnect(t *testing.T) {
+	w := core.NewWorker(0, 16)
+	w.Start(context.Background())
+	defer w.Stop()
+
+	assert.EqualValues(t, "*-1\r\n", execOnWorker(w, "BLPOP", "q", "0.05"))
+	assert.EqualValues(t, "$-1\r\n", execOnWorker(w, "BLMOVE", "q", "dst", "LEFT", "LEFT", "0.01"))
+	assert.EqualValues(t, "-ERR timeout is negative\r\n", execOnWorker(w, "BLPOP", "q", "-1"))
+	assert.EqualValues(t, "-ERR timeout is not a float or out of range\r\n", execOnWorker(w, "BLPOP", "q", "x"))
+
+	gone := sendToWorker(w, 7, "BLPOP", "q", "0")
+	w.ClientGone(7)
+	assert.EqualValues(t, "*-1\r\n", waitReply(t, gone))
+	// The disconnected client does not take the element
+	execOnWorker(w, "RPUSH", "q", "a")
+	assert.EqualValues(t, ":1\r\n", execOnWorker(w, "LLEN", "q"))
+}
+
+func TestBlockingMoveAndZPop(t *testing.T) {
+	w := core.NewWorker(0, 16)
+	w.Start(context.Background())
+	defer w.Stop()
+
+	// The element moved by BLMOVE wakes up the client waiting on the destination
+	moved := sendToWorker(w, 1, "BLMOVE", "src", "dst", "RIGHT", "LEFT", "0")
+	popped := sendToWorker(w, 2, "BLPOP", "dst", "0")
+	execOnWorker(w, "RPUSH", "src", "a")
+	assert.EqualValues(t, "$1\r\na\r\n", waitReply(t, moved))
+	assert.EqualValues(t, "*2\r\n$3\r\ndst\r\n$1\r\na\r\n", waitReply(t, popped))
+
+	// BLMOVE does not wait on its destination, so it does not hold back the clients blocked there
+	waiting := sendToWorker(w, 3, "BLMOVE", "empty", "q", "LEFT", "LEFT", "0")
+	blpop := sendToWorker(w, 4, "BLPOP", "q", "0")
+	execOnWorker(w, "RPUSH", "q", "x")
+	assert.EqualValues(t, "*2\r\n$1\r\nq\r\n$1\r\nx\r\n", waitReply(t, blpop))
+	assertNoReply(t, waiting)
+
+	zpop := sendToWorker(w, 5, "BZPOPMAX", "zs", "0")
+	execOnWorker(w, "ZADD", "zs", "1.5", "a", "2", "b")
+	assert.EqualValues(t, "*3\r\n$2\r\nzs\r\n$1\r\nb\r\n$1\r\n2\r\n", waitReply(t, zpop))
+	assert.EqualValues(t, "*3\r\n$2\r\nzs\r\n$1\r\na\r\n$3\r\n1.5\r\n", execOnWorker(w, "BZPOPMIN", "zs", "0"))
+
+	execOnWorker(w, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execOnWorker(w, "BLPOP", "str", "0"))
+}
+
+func TestBlockingCommandOutsideWorker(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "*-1\r\n", execute(s, "BLPOP", "q", "0"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "BRPOPLPUSH", "q", "dst", "0"))
+}
@@ -5,12 +5,25 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 
 	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
 	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/probabilistic"
 )
 
-func cmdCMSINITBYDIM(args []string) []byte {
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "CMS.INITBYDIM", Handler: (*Storage).cmdCMSINITBYDIM, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cms", Syntax: "key width depth", Summary: "Initialize a Count-min Sketch by dimensions"},
+		&CommandSpec{Name: "CMS.INITBYPROB", Handler: (*Storage).cmdCMSINITBYPROB, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cms", Syntax: "key error probability", Summary: "Initialize a Count-min Sketch by error rate and probability"},
+		&CommandSpec{Name: "CMS.INCRBY", Handler: (*Storage).cmdCMSINCRBY, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cms", Syntax: "key item increment [item increment ...]", Summary: "Increase the count of items in a Count-min Sketch"},
+		&CommandSpec{Name: "CMS.QUERY", Handler: (*Storage).cmdCMSQUERY, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cms", Syntax: "key item [item ...]", Summary: "Return the count of items in a Count-min Sketch"},
+		&CommandSpec{Name: "CMS.MERGE", Handler: (*Storage).cmdCMSMERGE, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM, FlagMovableKeys}, FirstKey: 1, LastKey: 1, Step: 1, KeysFunc: zsetOpStoreKeys, Group: "cms", Syntax: "destination numkeys source [source ...] [WEIGHTS weight [weight ...]]", Summary: "Merge Count-min Sketches into a destination sketch"},
+		&CommandSpec{Name: "CMS.INFO", Handler: (*Storage).cmdCMSINFO, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cms", Syntax: "key", Summary: "Return the width, depth and total count of a Count-min Sketch"},
+	)
+}
+
+func (s *Storage) cmdCMSINITBYDIM(args []string) []byte {
 	if len(args) != 3 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'CMS.INITBYDIM' command"), false)
 	}
@@ -27,16 +40,15 @@ func cmdCMSINITBYDIM(args []string) []byte {
 		return Encode(fmt.Errorf("height must be a integer number %s", args[1]), false)
 	}
 
-	_, exist := cmsStore[key]
-	if exist {
+	if s.exists(key) {
 		return Encode(errors.New("CMS: key already exists"), false)
 	}
 
-	cmsStore[key] = probabilistic.NewCMS(uint64(width), uint64(height))
+	s.addObj(key, hash_table.ObjTypeCMS, hash_table.ObjEncodingRaw, probabilistic.NewCMS(uint64(width), uint64(height)))
 	return constant.RespOk
 }
 
-func cmdCMSINITBYPROB(args []string) []byte {
+func (s *Storage) cmdCMSINITBYPROB(args []string) []byte {
 	if len(args) != 3 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'CMS.INITBYPROB' command"), false)
 	}
@@ -56,23 +68,25 @@ func cmdCMSINITBYPROB(args []string) []byte {
 	if probability >= 1 || probability <= 0 {
 		return Encode(errors.New("CMS: invalid prob value"), false)
 	}
-	_, exist := cmsStore[key]
-	if exist {
+	if s.exists(key) {
 		return Encode(errors.New("CMS: key already exists"), false)
 	}
 
 	w, h := probabilistic.CalcCMSDim(errRate, probability)
-	cmsStore[key] = probabilistic.NewCMS(w, h)
+	s.addObj(key, hash_table.ObjTypeCMS, hash_table.ObjEncodingRaw, probabilistic.NewCMS(w, h))
 	return constant.RespOk
 }
 
-func cmdCMSINCRBY(args []string) []byte {
+func (s *Storage) cmdCMSINCRBY(args []string) []byte {
 	if len(args) < 3 || len(args)%2 == 0 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'CMS.INCBY' command"), false)
 	}
 	key := args[0]
-	cms, exist := cmsStore[key]
-	if !exist {
+	cms, err := s.lookupCMS(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if cms == nil {
 		return Encode(errors.New("CMS: key does not exist"), false)
 	}
 
@@ -93,13 +107,16 @@ func cmdCMSINCRBY(args []string) []byte {
 	return Encode(res, false)
 }
 
-func cmdCMSQUERY(args []string) []byte {
+func (s *Storage) cmdCMSQUERY(args []string) []byte {
 	if len(args) < 2 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'CMS.QUERY' command"), false)
 	}
 	key := args[0]
-	cms, exist := cmsStore[key]
-	if !exist {
+	cms, err := s.lookupCMS(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if cms == nil {
 		return Encode(errors.New("CMS: key does not exist"), false)
 	}
 
@@ -111,3 +128,69 @@ func cmdCMSQUERY(args []string) []byte {
 	}
 	return Encode(res, false)
 }
+
+func (s *Storage) cmdCMSMERGE(args []string) []byte {
+	keys := numKeysArgs(args, 1)
+	if keys == nil {
+		return Encode(errors.New("CMS: invalid numkeys"), false)
+	}
+
+	weights := make([]uint64, len(keys))
+	for i := range weights {
+		weights[i] = 1
+	}
+	rest := args[2+len(keys):]
+	if len(rest) > 0 {
+		if !strings.EqualFold(rest[0], "WEIGHTS") || len(rest)-1 != len(keys) {
+			return Encode(errSyntax, false)
+		}
+		for i, w := range rest[1:] {
+			weight, err := strconv.ParseUint(w, 10, 64)
+			if err != nil {
+				return Encode(errors.New("CMS: invalid weight value"), false)
+			}
+			weights[i] = weight
+		}
+	}
+
+	dest, err := s.lookupCMS(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if dest == nil {
+		return Encode(errors.New("CMS: key does not exist"), false)
+	}
+	sketches := make([]probabilistic.FrequencyEstimator, len(keys))
+	for i, key := range keys {
+		cms, err := s.lookupCMS(key)
+		if err != nil {
+			return Encode(err, false)
+		}
+		if cms == nil {
+			return Encode(errors.New("CMS: key does not exist"), false)
+		}
+		sketches[i] = cms
+	}
+
+	if err := dest.Merge(sketches, weights); err != nil {
+		return Encode(errors.New("CMS: "+err.Error()), false)
+	}
+	return constant.RespOk
+}
+
+func (s *Storage) cmdCMSINFO(args []string) []byte {
+	cms, err := s.lookupCMS(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if cms == nil {
+		return Encode(errors.New("CMS: key does not exist"), false)
+	}
+
+	info := cms.Info()
+	return Encode([]interface{}{
+		"width", int(info.Width),
+		"depth", int(info.Depth),
+		"count", int(info.Count),
+	}, false)
+}
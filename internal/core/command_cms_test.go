@@ -0,0 +1,42 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCMSMergeAndInfo(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "CMS.INITBYDIM", "eu", "100", "5")
+	execute(s, "CMS.INITBYDIM", "us", "100", "5")
+	execute(s, "CMS.INITBYDIM", "all", "100", "5")
+	execute(s, "CMS.INCRBY", "eu", "home", "3", "cart", "1")
+	execute(s, "CMS.INCRBY", "us", "home", "2")
+
+	assert.EqualValues(t, "*6\r\n$5\r\nwidth\r\n:100\r\n$5\r\ndepth\r\n:5\r\n$5\r\ncount\r\n:4\r\n", execute(s, "CMS.INFO", "eu"))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "CMS.MERGE", "all", "2", "eu", "us"))
+	assert.EqualValues(t, "*2\r\n$1\r\n5\r\n$1\r\n1\r\n", execute(s, "CMS.QUERY", "all", "home", "cart"))
+	assert.EqualValues(t, "+OK\r\n", execute(s, "CMS.MERGE", "all", "2", "eu", "us", "WEIGHTS", "1", "10"))
+	assert.EqualValues(t, "*1\r\n$2\r\n23\r\n", execute(s, "CMS.QUERY", "all", "home"))
+	assert.EqualValues(t, "*6\r\n$5\r\nwidth\r\n:100\r\n$5\r\ndepth\r\n:5\r\n$5\r\ncount\r\n:24\r\n", execute(s, "CMS.INFO", "all"))
+
+	// The destination can be one of the sources
+	assert.EqualValues(t, "+OK\r\n", execute(s, "CMS.MERGE", "eu", "2", "eu", "us"))
+	assert.EqualValues(t, "*1\r\n$1\r\n5\r\n", execute(s, "CMS.QUERY", "eu", "home"))
+
+	execute(s, "CMS.INITBYDIM", "small", "10", "5")
+	assert.EqualValues(t, "-CMS: width/depth is not equal\r\n", execute(s, "CMS.MERGE", "all", "2", "eu", "small"))
+	assert.EqualValues(t, "-CMS: key does not exist\r\n", execute(s, "CMS.MERGE", "all", "1", "missing"))
+	assert.EqualValues(t, "-CMS: key does not exist\r\n", execute(s, "CMS.MERGE", "missing", "1", "eu"))
+	assert.EqualValues(t, "-CMS: invalid numkeys\r\n", execute(s, "CMS.MERGE", "all", "3", "eu", "us"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "CMS.MERGE", "all", "2", "eu", "us", "WEIGHTS", "1"))
+	assert.EqualValues(t, "-CMS: invalid weight value\r\n", execute(s, "CMS.MERGE", "all", "1", "eu", "WEIGHTS", "-1"))
+	assert.EqualValues(t, "-CMS: key does not exist\r\n", execute(s, "CMS.INFO", "missing"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "CMS.MERGE", "all", "1", "str"))
+	assert.EqualValues(t, wrongType, execute(s, "CMS.INFO", "str"))
+}
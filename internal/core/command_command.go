@@ -0,0 +1,91 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "COMMAND", Handler: (*Storage).cmdCOMMAND, Arity: -1, Group: "server", Syntax: "[COUNT | INFO [command ...] | DOCS [command ...]]", Summary: "Get details about the server commands"},
+	)
+}
+
+// commandInfo returns the COMMAND INFO entry of a command:
+// name, arity, flags, first key, last key, step, ACL categories and tips.
+func commandInfo(spec *CommandSpec) []interface{} {
+	flags := make([]string, len(spec.Flags))
+	copy(flags, spec.Flags)
+	return []interface{}{
+		strings.ToLower(spec.Name),
+		spec.Arity,
+		flags,
+		spec.FirstKey,
+		spec.LastKey,
+		spec.Step,
+		[]string{"@" + spec.Group},
+		append([]string{}, spec.Tips...),
+	}
+}
+
+// commandDocs returns the COMMAND DOCS entry of a command as a flattened map.
+func commandDocs(spec *CommandSpec) []interface{} {
+	return []interface{}{
+		"summary", spec.Summary,
+		"group", spec.Group,
+		"syntax", strings.TrimSpace(strings.ToLower(spec.Name) + " " + spec.Syntax),
+	}
+}
+
+// commandSpecs resolves the requested command names, or every command if none is given.
+// Unknown names map to a nil spec.
+func commandSpecs(names []string) []*CommandSpec {
+	if len(names) == 0 {
+		names = sortedCommandNames()
+	}
+	specs := make([]*CommandSpec, len(names))
+	for i, name := range names {
+		specs[i] = LookupCommand(name)
+	}
+	return specs
+}
+
+func (s *Storage) cmdCOMMAND(args []string) []byte {
+	if len(args) == 0 {
+		var res []interface{}
+		for _, spec := range commandSpecs(nil) {
+			res = append(res, commandInfo(spec))
+		}
+		return Encode(res, false)
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "COUNT":
+		if len(args) != 1 {
+			return Encode(errors.New("ERR wrong number of arguments for 'command|count' command"), false)
+		}
+		return Encode(len(commandTable), false)
+	case "INFO":
+		res := []interface{}{}
+		for _, spec := range commandSpecs(args[1:]) {
+			if spec == nil {
+				res = append(res, nil)
+				continue
+			}
+			res = append(res, commandInfo(spec))
+		}
+		return Encode(res, false)
+	case "DOCS":
+		res := []interface{}{}
+		for _, spec := range commandSpecs(args[1:]) {
+			if spec == nil {
+				continue
+			}
+			res = append(res, strings.ToLower(spec.Name), commandDocs(spec))
+		}
+		return Encode(res, false)
+	default:
+		return Encode(fmt.Errorf("ERR unknown subcommand '%s'. Try COMMAND HELP.", args[0]), false)
+	}
+}
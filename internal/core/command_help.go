@@ -1,18 +1,24 @@
 package core
 
-func cmdHELP() []byte {
-	helpCommands := []string{
-		"--------------------------------",
-		"PING [message] - Ping the server",
-		"GET key - Get the value of a key",
-		"SET key value - Set the value of a key",
-		"EXISTS key - Check if a key exists",
-		"TTL key - Get the time to live for a key",
-		"SADD key member [member ...] - Add members to a set",
-		"SREM key member [member ...] - Remove members from a set",
-		"HELP - Show this help message",
-		"CLEAR - Clear the terminal screen",
-		"--------------------------------",
+import "strings"
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "HELP", Handler: (*Storage).cmdHELP, Arity: 1, Group: "server", Summary: "Show this help message"},
+	)
+}
+
+// cmdHELP lists every registered command, generated from the command table.
+func (s *Storage) cmdHELP(args []string) []byte {
+	helpCommands := []string{"--------------------------------"}
+	for _, name := range sortedCommandNames() {
+		spec := commandTable[name]
+		usage := name
+		if spec.Syntax != "" {
+			usage += " " + spec.Syntax
+		}
+		helpCommands = append(helpCommands, strings.TrimSpace(usage)+" - "+spec.Summary)
 	}
+	helpCommands = append(helpCommands, "--------------------------------")
 	return Encode(helpCommands, false)
 }
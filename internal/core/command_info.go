@@ -1,12 +1,29 @@
 package core
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 )
 
-func cmdINFO(args []string) []byte {
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "INFO", Handler: (*Storage).cmdINFO, Arity: -1, Flags: []string{FlagReadOnly}, Tips: []string{TipRequestAllShards, TipResponseSpecial}, Group: "server", Syntax: "[section]", Summary: "Get information and statistics about the server"},
+	)
+}
+
+// KeyspaceInfo formats the keyspace section of INFO. With several shards the server sums the sections
+// of every shard, avgTTL averages the TTLs of the keys with an expiry, in milliseconds.
+func KeyspaceInfo(keys, expires int, avgTTL uint64) string {
+	return fmt.Sprintf("# Keyspace\r\ndb0:keys=%d,expires=%d,avg_ttl=%d\r\n", keys, expires, avgTTL)
+}
+
+// ParseKeyspaceInfo parses the keyspace section formatted by KeyspaceInfo, ok is false for another section.
+func ParseKeyspaceInfo(info string) (keys, expires int, avgTTL uint64, ok bool) {
+	_, err := fmt.Sscanf(info, "# Keyspace\r\ndb0:keys=%d,expires=%d,avg_ttl=%d\r\n", &keys, &expires, &avgTTL)
+	return keys, expires, avgTTL, err == nil
+}
+
+func (s *Storage) cmdINFO(args []string) []byte {
 	if len(args) == 0 {
 		return Encode("All sections. I will implement later. You can try `INFO keyspace` command\n", false)
 	}
@@ -15,11 +32,7 @@ func cmdINFO(args []string) []byte {
 	}
 	switch args[0] {
 	case "keyspace":
-		var info []byte
-		buf := bytes.NewBuffer(info)
-		buf.WriteString("# Keyspace\r\n")
-		fmt.Fprintf(buf, "db0:keys=%d,expires=%d,avg_ttl=%d\r\n", len(dictStore.GetDictStore()), dictStore.ExpiringKeysCount(), dictStore.TLL_Avg())
-		return Encode(buf.String(), false)
+		return Encode(KeyspaceInfo(s.dictStore.Len(), s.dictStore.ExpiringKeysCount(), s.dictStore.TLL_Avg()), false)
 	default:
 		return Encode(errors.New("(error) ERR unknown INFO section"), false)
 	}
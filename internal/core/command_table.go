@@ -0,0 +1,131 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CommandHandler executes a command against one storage and returns the RESP encoded reply.
+// Args do not include the command name.
+type CommandHandler func(s *Storage, args []string) []byte
+
+// Command flags, reported by COMMAND INFO the same way Redis does
+const (
+	FlagWrite       = "write"       // Command may modify the keyspace
+	FlagReadOnly    = "readonly"    // Command never modifies the keyspace
+	FlagDenyOOM     = "denyoom"     // Command may use additional memory
+	FlagFast        = "fast"        // Command runs in O(1) or O(log N)
+	FlagAdmin       = "admin"       // Server administration command
+	FlagBlocking    = "blocking"    // Command may wait for one of its keys to become ready, see Worker
+	FlagMovableKeys = "movablekeys" // Key positions depend on the arguments, see CommandSpec.KeysFunc
+)
+
+// Command tips, reported by COMMAND INFO. The request and response policies tell the sharded
+// server how to route a command whose keys are not all owned by one worker, and how to merge the replies.
+const (
+	TipRequestAllShards            = "request_policy:all_shards"     // Run on every shard
+	TipRequestMultiShard           = "request_policy:multi_shard"    // Split the keys by shard and run one sub-command per shard
+	TipRequestSpecial              = "request_policy:special"        // Routed by custom server logic
+	TipResponseAggSum              = "response_policy:agg_sum"       // Sum the integer replies
+	TipResponseAllSucceeded        = "response_policy:all_succeeded" // Reply OK unless a shard returned an error
+	TipResponseSpecial             = "response_policy:special"       // Merged by custom server logic
+	TipNondeterministicOutput      = "nondeterministic_output"
+	TipNondeterministicOutputOrder = "nondeterministic_output_order"
+)
+
+// CommandSpec declares everything the server needs to know about a command.
+// Key positions follow the Redis convention: they are indexes into the full argv,
+// where argv[0] is the command name, and a negative LastKey counts from the end.
+type CommandSpec struct {
+	Name     string
+	Handler  CommandHandler
+	Arity    int // Number of arguments including the command name, -N means at least N
+	Flags    []string
+	FirstKey int // 0 if the command takes no key
+	LastKey  int
+	Step     int
+	KeysFunc func(args []string) []string // Extracts the keys instead of FirstKey, LastKey and Step when set
+	Tips     []string
+	Group    string
+	Syntax   string // Arguments as shown by HELP and COMMAND DOCS
+	Summary  string
+}
+
+var commandTable = make(map[string]*CommandSpec)
+
+func registerCommands(specs ...*CommandSpec) {
+	for _, spec := range specs {
+		commandTable[spec.Name] = spec
+	}
+}
+
+// LookupCommand returns the spec of a command name (case-insensitive), or nil if it is unknown.
+func LookupCommand(name string) *CommandSpec {
+	return commandTable[strings.ToUpper(name)]
+}
+
+// sortedCommandNames returns every registered command name in alphabetical order.
+func sortedCommandNames() []string {
+	names := make([]string, 0, len(commandTable))
+	for name := range commandTable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *CommandSpec) HasFlag(flag string) bool {
+	return slices.Contains(c.Flags, flag)
+}
+
+func (c *CommandSpec) HasTip(tip string) bool {
+	return slices.Contains(c.Tips, tip)
+}
+
+// CheckArity reports whether args (without the command name) satisfy the command arity.
+func (c *CommandSpec) CheckArity(args []string) bool {
+	argc := len(args) + 1
+	if c.Arity >= 0 {
+		return argc == c.Arity
+	}
+	return argc >= -c.Arity
+}
+
+// GetKeys extracts the key arguments from args (without the command name) using the declared key positions,
+// or KeysFunc for commands with movable keys.
+func (c *CommandSpec) GetKeys(args []string) []string {
+	if c.KeysFunc != nil {
+		return c.KeysFunc(args)
+	}
+	if c.FirstKey <= 0 {
+		return nil
+	}
+	argc := len(args) + 1
+	last := c.LastKey
+	if last < 0 {
+		last = argc + last
+	}
+	step := c.Step
+	if step <= 0 {
+		step = 1
+	}
+	var keys []string
+	for i := c.FirstKey; i <= last && i < argc; i += step {
+		keys = append(keys, args[i-1])
+	}
+	return keys
+}
+
+func errWrongNumberOfArgs(name string) []byte {
+	return Encode(fmt.Errorf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)), false)
+}
+
+func errUnknownCommand(cmd *Command) []byte {
+	var sb strings.Builder
+	for _, arg := range cmd.Args {
+		fmt.Fprintf(&sb, "'%s' ", arg)
+	}
+	return Encode(fmt.Errorf("ERR unknown command '%s', with args beginning with: %s", cmd.Cmd, sb.String()), false)
+}
@@ -0,0 +1,48 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func execute(s *core.Storage, cmd string, args ...string) string {
+	return string(s.Execute(&core.Command{Cmd: cmd, Args: args}))
+}
+
+func TestCommandSpecGetKeys(t *testing.T) {
+	spec := core.LookupCommand("set")
+	assert.NotNil(t, spec)
+	assert.EqualValues(t, []string{"k"}, spec.GetKeys([]string{"k", "v"}))
+
+	spec = core.LookupCommand("PING")
+	assert.Nil(t, spec.GetKeys([]string{"hello"}))
+
+	spec = &core.CommandSpec{Name: "MSET", Arity: -3, FirstKey: 1, LastKey: -1, Step: 2}
+	assert.EqualValues(t, []string{"a", "b"}, spec.GetKeys([]string{"a", "1", "b", "2"}))
+}
+
+func TestExecuteChecksArity(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "-ERR wrong number of arguments for 'get' command\r\n", execute(s, "GET"))
+	assert.EqualValues(t, "-ERR wrong number of arguments for 'zadd' command\r\n", execute(s, "ZADD", "k", "1"))
+	assert.EqualValues(t, "-ERR unknown command 'NOPE', with args beginning with: 'a' \r\n", execute(s, "NOPE", "a"))
+}
+
+func TestCommandCommand(t *testing.T) {
+	s := core.NewStorage()
+	assert.True(t, strings.HasPrefix(execute(s, "COMMAND", "COUNT"), ":"))
+	assert.EqualValues(t,
+		"*2\r\n*8\r\n$3\r\nget\r\n:2\r\n*2\r\n$8\r\nreadonly\r\n$4\r\nfast\r\n:1\r\n:1\r\n:1\r\n*1\r\n$7\r\n@string\r\n*0\r\n$-1\r\n",
+		execute(s, "COMMAND", "INFO", "get", "nope"))
+	assert.Contains(t, execute(s, "COMMAND", "DOCS", "set"), "Set the value of a key")
+	assert.True(t, strings.HasPrefix(execute(s, "COMMAND", "FOO"), "-ERR unknown subcommand"))
+}
+
+func TestHelpIsGeneratedFromCommandTable(t *testing.T) {
+	help := execute(core.NewStorage(), "HELP")
+	assert.Contains(t, help, "ZADD key score member [score member ...] - Add members to a sorted set")
+	assert.NotContains(t, help, "CLEAR")
+}
@@ -0,0 +1,589 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/config"
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "SETBIT", Handler: (*Storage).cmdSETBIT, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bitmap", Syntax: "key offset value", Summary: "Set or clear the bit at an offset of a string, growing it as needed"},
+		&CommandSpec{Name: "GETBIT", Handler: (*Storage).cmdGETBIT, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bitmap", Syntax: "key offset", Summary: "Return the bit at an offset of a string"},
+		&CommandSpec{Name: "BITCOUNT", Handler: (*Storage).cmdBITCOUNT, Arity: -2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bitmap", Syntax: "key [start end [BYTE | BIT]]", Summary: "Count the bits set to 1 in a string"},
+		&CommandSpec{Name: "BITPOS", Handler: (*Storage).cmdBITPOS, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bitmap", Syntax: "key bit [start [end [BYTE | BIT]]]", Summary: "Return the position of the first bit set to 1 or 0 in a string"},
+		&CommandSpec{Name: "BITOP", Handler: (*Storage).cmdBITOP, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 2, LastKey: -1, Step: 1, Group: "bitmap", Syntax: "AND | OR | XOR | NOT destkey key [key ...]", Summary: "Store the bitwise operation of strings in a key"},
+		&CommandSpec{Name: "BITFIELD", Handler: (*Storage).cmdBITFIELD, Arity: -2, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bitmap", Syntax: "key [GET encoding offset | [OVERFLOW WRAP | SAT | FAIL] SET encoding offset value | [OVERFLOW WRAP | SAT | FAIL] INCRBY encoding offset increment ...]", Summary: "Get, set and increment integers of arbitrary width in a string"},
+		&CommandSpec{Name: "BITFIELD_RO", Handler: (*Storage).cmdBITFIELDRO, Arity: -2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bitmap", Syntax: "key [GET encoding offset ...]", Summary: "Get integers of arbitrary width in a string"},
+	)
+}
+
+var errBitOffset = errors.New("ERR bit offset is not an integer or out of range")
+
+// bitmap is the value of a string object as the bitmap commands read it: a string, or the byte slice
+// of a string updated in place. Readers take either without copying it.
+type bitmap interface {
+	~string | ~[]byte
+}
+
+// mutableBytes returns the value of a string object as a byte slice for the bitmap commands to update
+// in place. The object keeps the slice, so only the first update copies the string.
+func mutableBytes(obj *hash_table.Obj) []byte {
+	if p, ok := obj.Value.([]byte); ok {
+		return p
+	}
+	p := []byte(stringValue(obj))
+	obj.Value, obj.Encoding = p, hash_table.ObjEncodingRaw
+	return p
+}
+
+// bitmapBytes returns the value of a string object as a byte slice to read, a copy unless the
+// bitmap commands already hold it as one.
+func bitmapBytes(obj *hash_table.Obj) []byte {
+	if p, ok := obj.Value.([]byte); ok {
+		return p
+	}
+	return []byte(stringValue(obj))
+}
+
+// getBit returns the bit at offset, 0 past the end of value. Like in Redis, bits are numbered from the
+// most significant bit of the first byte.
+func getBit[T bitmap](value T, offset int64) int {
+	if offset>>3 >= int64(len(value)) {
+		return 0
+	}
+	return int(value[offset>>3]>>(7-offset&7)) & 1
+}
+
+func setBit(p []byte, offset int64, bit int) {
+	mask := byte(1) << (7 - offset&7)
+	if bit == 1 {
+		p[offset>>3] |= mask
+	} else {
+		p[offset>>3] &^= mask
+	}
+}
+
+// growBytes pads p with zero bytes up to n bytes
+func growBytes(p []byte, n int64) []byte {
+	if n > int64(len(p)) {
+		p = append(p, make([]byte, n-int64(len(p)))...)
+	}
+	return p
+}
+
+// parseBitOffset parses the offset of SETBIT, GETBIT and BITFIELD. With hash, an offset prefixed by #
+// counts in units of width bits, as BITFIELD allows. The string it addresses must fit proto-max-bulk-len.
+func parseBitOffset(arg string, hash bool, width int) (int64, error) {
+	multiplier := int64(1)
+	if hash && strings.HasPrefix(arg, "#") {
+		arg, multiplier = arg[1:], int64(width)
+	}
+	offset, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || offset < 0 || offset > math.MaxInt64/multiplier {
+		return 0, errBitOffset
+	}
+	offset *= multiplier
+	limit := int64(config.ProtoMaxBulkLen)
+	if offset>>3 >= limit || (offset+int64(max(width, 1))-1)>>3 >= limit {
+		return 0, errBitOffset
+	}
+	return offset, nil
+}
+
+func (s *Storage) cmdSETBIT(args []string) []byte {
+	offset, err := parseBitOffset(args[1], false, 0)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if args[2] != "0" && args[2] != "1" {
+		return Encode(errors.New("ERR bit is not an integer or out of range"), false)
+	}
+	obj, err := s.lookup(args[0], hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if obj == nil {
+		obj = s.addObj(args[0], hash_table.ObjTypeString, hash_table.ObjEncodingRaw, []byte(nil))
+	}
+
+	p := growBytes(mutableBytes(obj), offset>>3+1)
+	old := getBit(p, offset)
+	setBit(p, offset, int(args[2][0]-'0'))
+	obj.Value = p
+	return Encode(old, false)
+}
+
+func (s *Storage) cmdGETBIT(args []string) []byte {
+	offset, err := parseBitOffset(args[1], false, 0)
+	if err != nil {
+		return Encode(err, false)
+	}
+	obj, err := s.lookup(args[0], hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if obj == nil {
+		return constant.RespZero
+	}
+	if p, ok := obj.Value.([]byte); ok {
+		return Encode(getBit(p, offset), false)
+	}
+	return Encode(getBit(stringValue(obj), offset), false)
+}
+
+// bitRange is the start [end [BYTE | BIT]] range of BITCOUNT and BITPOS
+type bitRange struct {
+	start, end int64
+	endGiven   bool
+	bitUnit    bool
+}
+
+func parseBitRange(args []string) (*bitRange, error) {
+	r := &bitRange{end: -1}
+	if len(args) == 0 {
+		return r, nil
+	}
+	if len(args) > 3 {
+		return nil, errSyntax
+	}
+	var err error
+	if r.start, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+		return nil, errNotInteger
+	}
+	if len(args) > 1 {
+		if r.end, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return nil, errNotInteger
+		}
+		r.endGiven = true
+	}
+	if len(args) == 3 {
+		switch strings.ToUpper(args[2]) {
+		case "BYTE":
+		case "BIT":
+			r.bitUnit = true
+		default:
+			return nil, errSyntax
+		}
+	}
+	return r, nil
+}
+
+// bits returns the first and last bit of the range in a string of n bytes, first > last if it is empty.
+// Negative indexes count from the end of the string.
+func (r *bitRange) bits(n int64) (int64, int64) {
+	if r.bitUnit {
+		n *= 8
+	}
+	start, end := r.start, r.end
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if end < 0 {
+		end = max(n+end, 0)
+	}
+	end = min(end, n-1)
+	if start > end {
+		return 0, -1
+	}
+	if r.bitUnit {
+		return start, end
+	}
+	return start * 8, end*8 + 7
+}
+
+func (s *Storage) cmdBITCOUNT(args []string) []byte {
+	// Unlike BITPOS, a start without an end is an error
+	if len(args) == 2 {
+		return Encode(errSyntax, false)
+	}
+	r, err := parseBitRange(args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	obj, err := s.lookup(args[0], hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if obj == nil {
+		return constant.RespZero
+	}
+	if p, ok := obj.Value.([]byte); ok {
+		return Encode(countBits(p, r), false)
+	}
+	return Encode(countBits(stringValue(obj), r), false)
+}
+
+// countBits counts the bits set to 1 in the range r of value
+func countBits[T bitmap](value T, r *bitRange) int {
+	first, last := r.bits(int64(len(value)))
+	count := 0
+	for i := first; i <= last; {
+		if i&7 == 0 && i+7 <= last {
+			count += bits.OnesCount8(value[i>>3])
+			i += 8
+			continue
+		}
+		count += getBit(value, i)
+		i++
+	}
+	return count
+}
+
+func (s *Storage) cmdBITPOS(args []string) []byte {
+	if args[1] != "0" && args[1] != "1" {
+		return Encode(errors.New("ERR The bit argument must be 1 or 0."), false)
+	}
+	bit := int(args[1][0] - '0')
+	r, err := parseBitRange(args[2:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	obj, err := s.lookup(args[0], hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	// A missing key is an empty string, which is padded with zeros on the right
+	if obj == nil {
+		return Encode(-bit, false)
+	}
+	if p, ok := obj.Value.([]byte); ok {
+		return Encode(findBit(p, bit, r), false)
+	}
+	return Encode(findBit(stringValue(obj), bit, r), false)
+}
+
+// findBit returns the position of the first bit set to bit in the range r of value, or -1
+func findBit[T bitmap](value T, bit int, r *bitRange) int64 {
+	first, last := r.bits(int64(len(value)))
+	if first > last {
+		return -1
+	}
+	// Bytes of the other bit only are skipped at once
+	skip := byte(0xff)
+	if bit == 1 {
+		skip = 0
+	}
+	for i := first; i <= last; {
+		if i&7 == 0 && i+7 <= last && value[i>>3] == skip {
+			i += 8
+			continue
+		}
+		if getBit(value, i) == bit {
+			return i
+		}
+		i++
+	}
+	// Without an end, the clear bit right after the string is found
+	if bit == 0 && !r.endGiven {
+		return last + 1
+	}
+	return -1
+}
+
+func (s *Storage) cmdBITOP(args []string) []byte {
+	op, dest, keys := strings.ToUpper(args[0]), args[1], args[2:]
+	switch op {
+	case "AND", "OR", "XOR":
+	case "NOT":
+		if len(keys) != 1 {
+			return Encode(errors.New("ERR BITOP NOT must be called with a single source key."), false)
+		}
+	default:
+		return Encode(errSyntax, false)
+	}
+
+	// Missing keys are empty strings, and shorter strings are padded with zeros
+	values := make([]string, len(keys))
+	n := 0
+	for i, key := range keys {
+		obj, err := s.lookup(key, hash_table.ObjTypeString)
+		if err != nil {
+			return Encode(err, false)
+		}
+		if obj != nil {
+			values[i] = stringValue(obj)
+			n = max(n, len(values[i]))
+		}
+	}
+
+	res := make([]byte, n)
+	copy(res, values[0])
+	for j := range res {
+		if op == "NOT" {
+			res[j] = ^res[j]
+			continue
+		}
+		for _, value := range values[1:] {
+			var b byte
+			if j < len(value) {
+				b = value[j]
+			}
+			switch op {
+			case "AND":
+				res[j] &= b
+			case "OR":
+				res[j] |= b
+			case "XOR":
+				res[j] ^= b
+			}
+		}
+	}
+
+	if n == 0 {
+		s.deleteKey(dest)
+		return constant.RespZero
+	}
+	s.setString(dest, string(res), 0, false)
+	return Encode(n, false)
+}
+
+// Overflow policies of BITFIELD SET and INCRBY
+const (
+	bitfieldWrap = iota
+	bitfieldSat
+	bitfieldFail
+)
+
+type bitfieldOp struct {
+	name     string // GET, SET or INCRBY
+	signed   bool
+	width    int
+	offset   int64
+	value    int64 // the value of SET, the increment of INCRBY
+	overflow int
+}
+
+// parseBitfieldType parses an encoding such as i16 or u8, u64 does not fit the int64 replies
+func parseBitfieldType(arg string) (bool, int, error) {
+	errType := errors.New("ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+	if len(arg) < 2 || (arg[0] != 'i' && arg[0] != 'u') {
+		return false, 0, errType
+	}
+	signed := arg[0] == 'i'
+	width, err := strconv.Atoi(arg[1:])
+	if err != nil || width < 1 || (signed && width > 64) || (!signed && width > 63) {
+		return false, 0, errType
+	}
+	return signed, width, nil
+}
+
+func parseBitfieldOps(args []string) ([]bitfieldOp, error) {
+	var ops []bitfieldOp
+	overflow := bitfieldWrap
+	for i := 0; i < len(args); i++ {
+		name := strings.ToUpper(args[i])
+		switch name {
+		case "OVERFLOW":
+			if i+1 >= len(args) {
+				return nil, errSyntax
+			}
+			switch strings.ToUpper(args[i+1]) {
+			case "WRAP":
+				overflow = bitfieldWrap
+			case "SAT":
+				overflow = bitfieldSat
+			case "FAIL":
+				overflow = bitfieldFail
+			default:
+				return nil, errors.New("ERR Invalid OVERFLOW type specified")
+			}
+			i++
+			continue
+		case "GET":
+			if i+2 >= len(args) {
+				return nil, errSyntax
+			}
+		case "SET", "INCRBY":
+			if i+3 >= len(args) {
+				return nil, errSyntax
+			}
+		default:
+			return nil, errSyntax
+		}
+
+		op := bitfieldOp{name: name, overflow: overflow}
+		var err error
+		if op.signed, op.width, err = parseBitfieldType(args[i+1]); err != nil {
+			return nil, err
+		}
+		if op.offset, err = parseBitOffset(args[i+2], true, op.width); err != nil {
+			return nil, err
+		}
+		if name != "GET" {
+			if op.value, err = strconv.ParseInt(args[i+3], 10, 64); err != nil {
+				return nil, errNotInteger
+			}
+			i++
+		}
+		ops = append(ops, op)
+		i += 2
+	}
+	return ops, nil
+}
+
+func getBitfield(p []byte, offset int64, width int) uint64 {
+	var v uint64
+	for j := int64(0); j < int64(width); j++ {
+		i := offset + j
+		v <<= 1
+		if i>>3 < int64(len(p)) {
+			v |= uint64(p[i>>3]>>(7-i&7)) & 1
+		}
+	}
+	return v
+}
+
+func setBitfield(p []byte, offset int64, width int, v uint64) {
+	for j := 0; j < width; j++ {
+		setBit(p, offset+int64(j), int(v>>(width-1-j))&1)
+	}
+}
+
+// signExtend turns the width lowest bits of v into a signed integer
+func signExtend(v uint64, width int) int64 {
+	shift := 64 - width
+	return int64(v<<shift) >> shift
+}
+
+// unsignedOverflow adds incr to value, an unsigned integer of width bits. It returns the result, which
+// overflow wraps around or saturates, and whether it overflowed.
+func unsignedOverflow(value uint64, incr int64, width int, overflow int) (uint64, bool) {
+	maxValue := uint64(1)<<width - 1
+	switch {
+	case value > maxValue || (incr > 0 && uint64(incr) > maxValue-value):
+		if overflow == bitfieldSat {
+			return maxValue, true
+		}
+	case incr < 0 && uint64(-incr) > value:
+		if overflow == bitfieldSat {
+			return 0, true
+		}
+	default:
+		return value + uint64(incr), false
+	}
+	return (value + uint64(incr)) & maxValue, true
+}
+
+// signedOverflow is unsignedOverflow for a signed integer of width bits
+func signedOverflow(value, incr int64, width int, overflow int) (int64, bool) {
+	maxValue := int64(uint64(1)<<(width-1) - 1)
+	minValue := -maxValue - 1
+	sum := int64(uint64(value) + uint64(incr))
+	switch {
+	case value > maxValue || (incr > 0 && (sum < value || sum > maxValue)):
+		if overflow == bitfieldSat {
+			return maxValue, true
+		}
+	case value < minValue || (incr < 0 && (sum > value || sum < minValue)):
+		if overflow == bitfieldSat {
+			return minValue, true
+		}
+	default:
+		return sum, false
+	}
+	return signExtend(uint64(sum), width), true
+}
+
+// bitfield runs the operations of BITFIELD, the key is only created or grown when there is a SET or INCRBY.
+// BITFIELD_RO, which only has GET, is readOnly and leaves the value as it is stored.
+func (s *Storage) bitfield(key string, ops []bitfieldOp, readOnly bool) []byte {
+	obj, err := s.lookup(key, hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	var p []byte
+	if obj != nil {
+		if readOnly {
+			p = bitmapBytes(obj)
+		} else {
+			p = mutableBytes(obj)
+		}
+	}
+
+	written := false
+	res := make([]interface{}, 0, len(ops))
+	for _, op := range ops {
+		old := getBitfield(p, op.offset, op.width)
+		if op.name == "GET" {
+			if op.signed {
+				res = append(res, signExtend(old, op.width))
+			} else {
+				res = append(res, int64(old))
+			}
+			continue
+		}
+
+		// SET stores its value as is, or as it overflows the type
+		var reply int64
+		var updated uint64
+		var overflowed bool
+		if op.signed {
+			base := signExtend(old, op.width)
+			if op.name == "SET" {
+				base = 0
+			}
+			var v int64
+			v, overflowed = signedOverflow(base, op.value, op.width, op.overflow)
+			updated, reply = uint64(v), v
+			if op.name == "SET" {
+				reply = signExtend(old, op.width)
+			}
+		} else {
+			base, incr := old, op.value
+			if op.name == "SET" {
+				base, incr = uint64(op.value), 0
+			}
+			updated, overflowed = unsignedOverflow(base, incr, op.width, op.overflow)
+			reply = int64(updated)
+			if op.name == "SET" {
+				reply = int64(old)
+			}
+		}
+		if overflowed && op.overflow == bitfieldFail {
+			res = append(res, nil)
+			continue
+		}
+
+		p = growBytes(p, (op.offset+int64(op.width)+7)>>3)
+		setBitfield(p, op.offset, op.width, updated)
+		written = true
+		res = append(res, reply)
+	}
+
+	if written {
+		if obj == nil {
+			s.addObj(key, hash_table.ObjTypeString, hash_table.ObjEncodingRaw, p)
+		} else {
+			obj.Value = p
+		}
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdBITFIELD(args []string) []byte {
+	ops, err := parseBitfieldOps(args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return s.bitfield(args[0], ops, false)
+}
+
+func (s *Storage) cmdBITFIELDRO(args []string) []byte {
+	ops, err := parseBitfieldOps(args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	for _, op := range ops {
+		if op.name != "GET" {
+			return Encode(errors.New("ERR BITFIELD_RO only supports the GET subcommand"), false)
+		}
+	}
+	return s.bitfield(args[0], ops, true)
+}
@@ -0,0 +1,172 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetBitGetBit(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":0\r\n", execute(s, "GETBIT", "bm", "7"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "SETBIT", "bm", "7", "1"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "SETBIT", "bm", "7", "1"))
+	assert.EqualValues(t, "$1\r\n\x01\r\n", execute(s, "GET", "bm"))
+
+	// The string grows with zero bytes to hold the offset
+	assert.EqualValues(t, ":0\r\n", execute(s, "SETBIT", "bm", "17", "1"))
+	assert.EqualValues(t, "$3\r\n\x01\x00\x40\r\n", execute(s, "GET", "bm"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "GETBIT", "bm", "17"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "GETBIT", "bm", "100"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "SETBIT", "bm", "17", "0"))
+	assert.EqualValues(t, "$3\r\n\x01\x00\x00\r\n", execute(s, "GET", "bm"))
+
+	// Bits of an existing string, '`' is 0110 0000
+	execute(s, "SET", "str", "`")
+	execute(s, "EXPIRE", "str", "100")
+	assert.EqualValues(t, ":1\r\n", execute(s, "GETBIT", "str", "1"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "SETBIT", "str", "7", "1"))
+	assert.EqualValues(t, "$1\r\na\r\n", execute(s, "GET", "str"))
+	assert.Contains(t, []string{":100\r\n", ":99\r\n"}, string(execute(s, "TTL", "str")))
+
+	assert.EqualValues(t, "-ERR bit is not an integer or out of range\r\n", execute(s, "SETBIT", "bm", "1", "2"))
+	assert.EqualValues(t, "-ERR bit offset is not an integer or out of range\r\n", execute(s, "SETBIT", "bm", "-1", "1"))
+	assert.EqualValues(t, "-ERR bit offset is not an integer or out of range\r\n", execute(s, "SETBIT", "bm", "4294967296", "1"))
+	assert.EqualValues(t, "-ERR bit offset is not an integer or out of range\r\n", execute(s, "GETBIT", "bm", "x"))
+
+	// The string commands still read and write a bitmap updated in place, '0' is 0011 0000
+	execute(s, "SET", "n", "10")
+	assert.EqualValues(t, ":0\r\n", execute(s, "SETBIT", "n", "15", "1"))
+	assert.EqualValues(t, "$3\r\nraw\r\n", execute(s, "OBJECT", "ENCODING", "n"))
+	assert.EqualValues(t, ":12\r\n", execute(s, "INCR", "n"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "SETBIT", "n", "23", "1"))
+	assert.EqualValues(t, ":4\r\n", execute(s, "APPEND", "n", "!"))
+	assert.EqualValues(t, "$4\r\n12\x01!\r\n", execute(s, "GET", "n"))
+	assert.EqualValues(t, ":4\r\n", execute(s, "STRLEN", "n"))
+
+	execute(s, "LPUSH", "list", "a")
+	assert.EqualValues(t, wrongType, execute(s, "SETBIT", "list", "1", "1"))
+	assert.EqualValues(t, wrongType, execute(s, "GETBIT", "list", "1"))
+}
+
+func TestBitCount(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":0\r\n", execute(s, "BITCOUNT", "bm"))
+
+	execute(s, "SET", "bm", "foobar")
+	assert.EqualValues(t, ":26\r\n", execute(s, "BITCOUNT", "bm"))
+	assert.EqualValues(t, ":4\r\n", execute(s, "BITCOUNT", "bm", "0", "0"))
+	assert.EqualValues(t, ":6\r\n", execute(s, "BITCOUNT", "bm", "1", "1"))
+	assert.EqualValues(t, ":6\r\n", execute(s, "BITCOUNT", "bm", "1", "1", "BYTE"))
+	assert.EqualValues(t, ":17\r\n", execute(s, "BITCOUNT", "bm", "5", "30", "BIT"))
+	assert.EqualValues(t, ":26\r\n", execute(s, "BITCOUNT", "bm", "0", "-1"))
+	assert.EqualValues(t, ":4\r\n", execute(s, "BITCOUNT", "bm", "-1", "-1"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "BITCOUNT", "bm", "3", "1"))
+	assert.EqualValues(t, ":26\r\n", execute(s, "BITCOUNT", "bm", "-100", "100"))
+
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "BITCOUNT", "bm", "0"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "BITCOUNT", "bm", "0", "1", "WORD"))
+	assert.EqualValues(t, "-ERR value is not an integer or out of range\r\n", execute(s, "BITCOUNT", "bm", "a", "1"))
+}
+
+func TestBitPos(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":0\r\n", execute(s, "BITPOS", "bm", "0"))
+	assert.EqualValues(t, ":-1\r\n", execute(s, "BITPOS", "bm", "1"))
+
+	execute(s, "SET", "bm", "\xff\xf0\x00")
+	assert.EqualValues(t, ":12\r\n", execute(s, "BITPOS", "bm", "0"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "BITPOS", "bm", "1"))
+	assert.EqualValues(t, ":8\r\n", execute(s, "BITPOS", "bm", "1", "1"))
+	assert.EqualValues(t, ":16\r\n", execute(s, "BITPOS", "bm", "0", "2", "-1"))
+	assert.EqualValues(t, ":-1\r\n", execute(s, "BITPOS", "bm", "1", "2", "-1"))
+	assert.EqualValues(t, ":10\r\n", execute(s, "BITPOS", "bm", "1", "10", "20", "BIT"))
+	assert.EqualValues(t, ":12\r\n", execute(s, "BITPOS", "bm", "0", "7", "15", "BIT"))
+
+	// Without an end, the string is padded with zeros on the right
+	execute(s, "SET", "ones", "\xff\xff")
+	assert.EqualValues(t, ":16\r\n", execute(s, "BITPOS", "ones", "0"))
+	assert.EqualValues(t, ":16\r\n", execute(s, "BITPOS", "ones", "0", "1"))
+	assert.EqualValues(t, ":-1\r\n", execute(s, "BITPOS", "ones", "0", "0", "-1"))
+
+	assert.EqualValues(t, "-ERR The bit argument must be 1 or 0.\r\n", execute(s, "BITPOS", "bm", "2"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "BITPOS", "bm", "1", "0", "1", "BIT", "x"))
+}
+
+func TestBitOp(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "SET", "a", "foobar")
+	execute(s, "SET", "b", "abc")
+
+	assert.EqualValues(t, ":6\r\n", execute(s, "BITOP", "AND", "dest", "a", "b"))
+	assert.EqualValues(t, "$6\r\n`bc\x00\x00\x00\r\n", execute(s, "GET", "dest"))
+	assert.EqualValues(t, ":6\r\n", execute(s, "BITOP", "or", "dest", "a", "b"))
+	assert.EqualValues(t, "$6\r\ngoobar\r\n", execute(s, "GET", "dest"))
+	assert.EqualValues(t, ":6\r\n", execute(s, "BITOP", "XOR", "dest", "a", "b", "missing"))
+	assert.EqualValues(t, "$6\r\n\x07\x0d\x0cbar\r\n", execute(s, "GET", "dest"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "BITOP", "NOT", "dest", "b"))
+	assert.EqualValues(t, "$3\r\n\x9e\x9d\x9c\r\n", execute(s, "GET", "dest"))
+
+	// The destination is replaced whatever its type, and removed when the result is empty
+	execute(s, "LPUSH", "list", "x")
+	assert.EqualValues(t, ":3\r\n", execute(s, "BITOP", "AND", "list", "b"))
+	assert.EqualValues(t, "+string\r\n", execute(s, "TYPE", "list"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "BITOP", "OR", "dest", "missing"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "dest"))
+
+	assert.EqualValues(t, "-ERR BITOP NOT must be called with a single source key.\r\n", execute(s, "BITOP", "NOT", "dest", "a", "b"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "BITOP", "NAND", "dest", "a", "b"))
+	execute(s, "LPUSH", "list2", "x")
+	assert.EqualValues(t, wrongType, execute(s, "BITOP", "AND", "dest", "a", "list2"))
+}
+
+func TestBitField(t *testing.T) {
+	s := core.NewStorage()
+	// Reads do not create the key
+	assert.EqualValues(t, "*1\r\n:0\r\n", execute(s, "BITFIELD", "bf", "GET", "u8", "0"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "bf"))
+
+	assert.EqualValues(t, "*2\r\n:0\r\n:255\r\n", execute(s, "BITFIELD", "bf", "SET", "u8", "0", "255", "GET", "u8", "0"))
+	assert.EqualValues(t, "*2\r\n:-1\r\n:15\r\n", execute(s, "BITFIELD", "bf", "GET", "i8", "0", "GET", "u4", "4"))
+	assert.EqualValues(t, "*1\r\n:-1\r\n", execute(s, "BITFIELD", "bf", "SET", "i8", "0", "100"))
+	assert.EqualValues(t, "$1\r\nd\r\n", execute(s, "GET", "bf"))
+
+	// # offsets count in units of the type width
+	assert.EqualValues(t, "*2\r\n:0\r\n:0\r\n", execute(s, "BITFIELD", "bf", "SET", "u8", "#1", "98", "SET", "u8", "#2", "99"))
+	assert.EqualValues(t, "$3\r\ndbc\r\n", execute(s, "GET", "bf"))
+	assert.EqualValues(t, "*1\r\n:25187\r\n", execute(s, "BITFIELD_RO", "bf", "GET", "u16", "8"))
+	assert.EqualValues(t, "*3\r\n:101\r\n:110\r\n:110\r\n", execute(s, "BITFIELD", "bf", "INCRBY", "i8", "0", "1", "INCRBY", "i8", "0", "9", "SET", "i8", "0", "-128"))
+
+	// Fields can span bytes past the end of the string
+	assert.EqualValues(t, "*1\r\n:5\r\n", execute(s, "BITFIELD", "bf", "INCRBY", "u63", "60", "5"))
+	assert.EqualValues(t, ":16\r\n", execute(s, "STRLEN", "bf"))
+	assert.EqualValues(t, "*2\r\n:0\r\n:-9223372036854775808\r\n", execute(s, "BITFIELD", "bf", "SET", "i64", "#2", "9223372036854775807", "INCRBY", "i64", "#2", "1"))
+
+	assert.EqualValues(t, "-ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.\r\n", execute(s, "BITFIELD", "bf", "GET", "u64", "0"))
+	assert.EqualValues(t, "-ERR Invalid OVERFLOW type specified\r\n", execute(s, "BITFIELD", "bf", "OVERFLOW", "NONE", "GET", "u8", "0"))
+	assert.EqualValues(t, "-ERR bit offset is not an integer or out of range\r\n", execute(s, "BITFIELD", "bf", "GET", "u8", "-1"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "BITFIELD", "bf", "SET", "u8", "0"))
+	assert.EqualValues(t, "-ERR value is not an integer or out of range\r\n", execute(s, "BITFIELD", "bf", "INCRBY", "u8", "0", "x"))
+	assert.EqualValues(t, "-ERR BITFIELD_RO only supports the GET subcommand\r\n", execute(s, "BITFIELD_RO", "bf", "SET", "u8", "0", "1"))
+
+	execute(s, "LPUSH", "list", "a")
+	assert.EqualValues(t, wrongType, execute(s, "BITFIELD", "list", "GET", "u8", "0"))
+}
+
+func TestBitFieldOverflow(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "*3\r\n:1\r\n:2\r\n:3\r\n", execute(s, "BITFIELD", "bf", "INCRBY", "u2", "0", "1", "INCRBY", "u2", "0", "1", "INCRBY", "u2", "0", "1"))
+
+	// WRAP is the default
+	assert.EqualValues(t, "*2\r\n:0\r\n:3\r\n", execute(s, "BITFIELD", "bf", "INCRBY", "u2", "0", "1", "INCRBY", "u2", "0", "-1"))
+	assert.EqualValues(t, "*2\r\n:3\r\n:3\r\n", execute(s, "BITFIELD", "bf", "OVERFLOW", "SAT", "INCRBY", "u2", "0", "10", "SET", "u2", "0", "100"))
+	assert.EqualValues(t, "*1\r\n:0\r\n", execute(s, "BITFIELD", "bf", "OVERFLOW", "SAT", "INCRBY", "u2", "0", "-10"))
+	assert.EqualValues(t, "*2\r\n$-1\r\n:0\r\n", execute(s, "BITFIELD", "bf", "OVERFLOW", "FAIL", "INCRBY", "u2", "0", "-1", "GET", "u2", "0"))
+
+	assert.EqualValues(t, "*4\r\n:0\r\n:-128\r\n:127\r\n$-1\r\n",
+		execute(s, "BITFIELD", "bf", "SET", "i8", "8", "127", "INCRBY", "i8", "8", "1", "OVERFLOW", "SAT", "INCRBY", "i8", "8", "1000", "OVERFLOW", "FAIL", "SET", "i8", "8", "128"))
+	assert.EqualValues(t, "*2\r\n:-128\r\n:-128\r\n", execute(s, "BITFIELD", "bf", "OVERFLOW", "SAT", "INCRBY", "i8", "8", "-1000", "GET", "i8", "8"))
+	// A SET out of the range of the type wraps around
+	assert.EqualValues(t, "*2\r\n:-128\r\n:-56\r\n", execute(s, "BITFIELD", "bf", "SET", "i8", "8", "200", "GET", "i8", "8"))
+}
@@ -0,0 +1,221 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/probabilistic"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "BF.RESERVE", Handler: (*Storage).cmdBFRESERVE, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bf", Syntax: "key error_rate capacity [EXPANSION expansion] [NONSCALING]", Summary: "Create a Bloom filter with the given error rate and capacity"},
+		&CommandSpec{Name: "BF.ADD", Handler: (*Storage).cmdBFADD, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bf", Syntax: "key item", Summary: "Add an item to a Bloom filter, creating the filter if needed"},
+		&CommandSpec{Name: "BF.MADD", Handler: (*Storage).cmdBFMADD, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bf", Syntax: "key item [item ...]", Summary: "Add items to a Bloom filter, creating the filter if needed"},
+		&CommandSpec{Name: "BF.EXISTS", Handler: (*Storage).cmdBFEXISTS, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bf", Syntax: "key item", Summary: "Check whether an item may have been added to a Bloom filter"},
+		&CommandSpec{Name: "BF.MEXISTS", Handler: (*Storage).cmdBFMEXISTS, Arity: -3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bf", Syntax: "key item [item ...]", Summary: "Check whether items may have been added to a Bloom filter"},
+		&CommandSpec{Name: "BF.CARD", Handler: (*Storage).cmdBFCARD, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bf", Syntax: "key", Summary: "Return the number of items added to a Bloom filter"},
+		&CommandSpec{Name: "BF.INFO", Handler: (*Storage).cmdBFINFO, Arity: -2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "bf", Syntax: "key [CAPACITY | SIZE | FILTERS | ITEMS | EXPANSION | HASHES]", Summary: "Return information about a Bloom filter"},
+	)
+}
+
+var (
+	errBloomExists   = errors.New("ERR item exists")
+	errBloomNotFound = errors.New("ERR not found")
+)
+
+// bloomForWrite returns the Bloom filter at key, creating a scalable one with the default capacity,
+// error rate and expansion if needed.
+func (s *Storage) bloomForWrite(key string) (probabilistic.MembershipTester, error) {
+	bf, err := s.lookupBloom(key)
+	if err != nil || bf != nil {
+		return bf, err
+	}
+	bf = probabilistic.NewScalableBloomFilter(constant.BfDefaultInitCapacity, constant.BfDefaultErrRate, constant.BfDefaultExpansion)
+	s.addObj(key, hash_table.ObjTypeBloom, hash_table.ObjEncodingRaw, bf)
+	return bf, nil
+}
+
+func boolReply(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (s *Storage) cmdBFRESERVE(args []string) []byte {
+	errRate, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return Encode(errors.New("ERR bad error rate"), false)
+	}
+	if errRate <= 0 || errRate >= 1 {
+		return Encode(errors.New("ERR (0 < error rate range < 1)"), false)
+	}
+	capacity, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return Encode(errors.New("ERR bad capacity"), false)
+	}
+	if capacity <= 0 {
+		return Encode(errors.New("ERR (capacity should be larger than 0)"), false)
+	}
+	if capacity > constant.BfMaxCapacity || !probabilistic.BloomFilterFits(uint64(capacity), errRate) {
+		return Encode(errors.New("ERR "+probabilistic.ErrFilterTooLarge.Error()), false)
+	}
+
+	expansion, expansionSet, scaling := constant.BfDefaultExpansion, false, true
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NONSCALING":
+			scaling = false
+		case "EXPANSION":
+			if i+1 >= len(args) {
+				return Encode(errSyntax, false)
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil {
+				return Encode(errors.New("ERR bad expansion"), false)
+			}
+			if n < 1 {
+				return Encode(errors.New("ERR expansion should be greater or equal to 1"), false)
+			}
+			expansion, expansionSet = n, true
+		default:
+			return Encode(errSyntax, false)
+		}
+	}
+	if !scaling && expansionSet {
+		return Encode(errors.New("ERR Nonscaling filters cannot expand"), false)
+	}
+	if s.exists(args[0]) {
+		return Encode(errBloomExists, false)
+	}
+
+	var bf probabilistic.MembershipTester
+	if scaling {
+		bf = probabilistic.NewScalableBloomFilter(uint64(capacity), errRate, expansion)
+	} else {
+		bf = probabilistic.NewBloomFilter(uint64(capacity), errRate)
+	}
+	s.addObj(args[0], hash_table.ObjTypeBloom, hash_table.ObjEncodingRaw, bf)
+	return constant.RespOk
+}
+
+func (s *Storage) cmdBFADD(args []string) []byte {
+	bf, err := s.bloomForWrite(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return Encode(bloomAdd(bf, args[1]), false)
+}
+
+func (s *Storage) cmdBFMADD(args []string) []byte {
+	bf, err := s.bloomForWrite(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, 0, len(args)-1)
+	for _, item := range args[1:] {
+		res = append(res, bloomAdd(bf, item))
+	}
+	return Encode(res, false)
+}
+
+// bloomAdd replies 1 if item is new, 0 if it may have been added before, or the error of a filter that
+// is full or cannot grow any more
+func bloomAdd(bf probabilistic.MembershipTester, item string) interface{} {
+	added, err := bf.Add(item)
+	if err != nil {
+		return errors.New("ERR " + err.Error())
+	}
+	return boolReply(added)
+}
+
+// bloomExists checks items against the filter at key, a missing filter holds nothing.
+func (s *Storage) bloomExists(key string, items []string) ([]interface{}, error) {
+	bf, err := s.lookupBloom(key)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		res = append(res, boolReply(bf != nil && bf.Exist(item)))
+	}
+	return res, nil
+}
+
+func (s *Storage) cmdBFEXISTS(args []string) []byte {
+	res, err := s.bloomExists(args[0], args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return Encode(res[0], false)
+}
+
+func (s *Storage) cmdBFMEXISTS(args []string) []byte {
+	res, err := s.bloomExists(args[0], args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdBFCARD(args []string) []byte {
+	bf, err := s.lookupBloom(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if bf == nil {
+		return Encode(0, false)
+	}
+	return Encode(int(bf.Info().Items), false)
+}
+
+func (s *Storage) cmdBFINFO(args []string) []byte {
+	if len(args) > 2 {
+		return Encode(errSyntax, false)
+	}
+	bf, err := s.lookupBloom(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if bf == nil {
+		return Encode(errBloomNotFound, false)
+	}
+
+	info := bf.Info()
+	// The expansion rate of a filter that does not scale is nil, like RedisBloom's NONSCALING filters
+	var expansion interface{}
+	if info.Expansion > 0 {
+		expansion = info.Expansion
+	}
+	fields := []struct {
+		name  string
+		arg   string
+		value interface{}
+	}{
+		{"Capacity", "CAPACITY", int(info.Capacity)},
+		{"Size", "SIZE", int(info.Size)},
+		{"Number of filters", "FILTERS", info.Filters},
+		{"Number of items inserted", "ITEMS", int(info.Items)},
+		{"Expansion rate", "EXPANSION", expansion},
+		{"Number of hash functions", "HASHES", info.Hashes},
+	}
+
+	if len(args) == 2 {
+		for _, f := range fields {
+			if strings.EqualFold(args[1], f.arg) {
+				return Encode([]interface{}{f.value}, false)
+			}
+		}
+		return Encode(errors.New("ERR Invalid information value"), false)
+	}
+	res := make([]interface{}, 0, 2*len(fields))
+	for _, f := range fields {
+		res = append(res, f.name, f.value)
+	}
+	return Encode(res, false)
+}
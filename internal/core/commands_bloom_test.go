@@ -0,0 +1,96 @@
+package core_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomCommands(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":0\r\n", execute(s, "BF.EXISTS", "bf", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "BF.CARD", "bf"))
+	assert.EqualValues(t, "-ERR not found\r\n", execute(s, "BF.INFO", "bf"))
+
+	assert.EqualValues(t, ":1\r\n", execute(s, "BF.ADD", "bf", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "BF.ADD", "bf", "a"))
+	assert.EqualValues(t, "*3\r\n:1\r\n:0\r\n:1\r\n", execute(s, "BF.MADD", "bf", "b", "a", "c"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "BF.EXISTS", "bf", "b"))
+	assert.EqualValues(t, "*2\r\n:1\r\n:0\r\n", execute(s, "BF.MEXISTS", "bf", "c", "x"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "BF.CARD", "bf"))
+	assert.EqualValues(t, "+MBbloom--\r\n", execute(s, "TYPE", "bf"))
+
+	// BF.ADD creates a scalable filter with the default capacity, error rate and expansion
+	assert.EqualValues(t, "*12\r\n"+
+		"$8\r\nCapacity\r\n:100\r\n"+
+		"$4\r\nSize\r\n:120\r\n"+
+		"$17\r\nNumber of filters\r\n:1\r\n"+
+		"$24\r\nNumber of items inserted\r\n:3\r\n"+
+		"$14\r\nExpansion rate\r\n:2\r\n"+
+		"$24\r\nNumber of hash functions\r\n:7\r\n", execute(s, "BF.INFO", "bf"))
+	assert.EqualValues(t, "*1\r\n:100\r\n", execute(s, "BF.INFO", "bf", "capacity"))
+	assert.EqualValues(t, "*1\r\n:3\r\n", execute(s, "BF.INFO", "bf", "ITEMS"))
+	assert.EqualValues(t, "-ERR Invalid information value\r\n", execute(s, "BF.INFO", "bf", "colour"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "BF.ADD", "str", "a"))
+	assert.EqualValues(t, wrongType, execute(s, "BF.EXISTS", "str", "a"))
+}
+
+func TestBloomReserve(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "+OK\r\n", execute(s, "BF.RESERVE", "bf", "0.001", "1000"))
+	assert.EqualValues(t, "-ERR item exists\r\n", execute(s, "BF.RESERVE", "bf", "0.001", "1000"))
+	assert.EqualValues(t, "*1\r\n:1000\r\n", execute(s, "BF.INFO", "bf", "CAPACITY"))
+	assert.EqualValues(t, "*1\r\n:10\r\n", execute(s, "BF.INFO", "bf", "HASHES"))
+
+	assert.EqualValues(t, "-ERR bad error rate\r\n", execute(s, "BF.RESERVE", "x", "abc", "10"))
+	assert.EqualValues(t, "-ERR (0 < error rate range < 1)\r\n", execute(s, "BF.RESERVE", "x", "1", "10"))
+	assert.EqualValues(t, "-ERR bad capacity\r\n", execute(s, "BF.RESERVE", "x", "0.01", "ten"))
+	assert.EqualValues(t, "-ERR (capacity should be larger than 0)\r\n", execute(s, "BF.RESERVE", "x", "0.01", "0"))
+	assert.EqualValues(t, "-ERR filter is too large\r\n", execute(s, "BF.RESERVE", "x", "0.01", "9223372036854775807"))
+	// Within the capacity limit but too many bits at this error rate
+	assert.EqualValues(t, "-ERR filter is too large\r\n", execute(s, "BF.RESERVE", "x", "0.0000001", "200000000"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "BF.RESERVE", "x", "0.01", "10", "EXTRA"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "BF.RESERVE", "x", "0.01", "10", "EXPANSION"))
+	assert.EqualValues(t, "-ERR bad expansion\r\n", execute(s, "BF.RESERVE", "x", "0.01", "10", "EXPANSION", "two"))
+	assert.EqualValues(t, "-ERR expansion should be greater or equal to 1\r\n", execute(s, "BF.RESERVE", "x", "0.01", "10", "EXPANSION", "0"))
+	assert.EqualValues(t, "-ERR Nonscaling filters cannot expand\r\n", execute(s, "BF.RESERVE", "x", "0.01", "10", "EXPANSION", "2", "NONSCALING"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "x"))
+
+	// Many items stay within the error rate
+	for i := 0; i < 1000; i++ {
+		execute(s, "BF.ADD", "bf", "item"+strconv.Itoa(i))
+	}
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if execute(s, "BF.EXISTS", "bf", "other"+strconv.Itoa(i)) == ":1\r\n" {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 10)
+}
+
+func TestBloomScaling(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "+OK\r\n", execute(s, "BF.RESERVE", "bf", "0.01", "10", "EXPANSION", "3"))
+	for i := 0; i < 30; i++ {
+		execute(s, "BF.ADD", "bf", "item"+strconv.Itoa(i))
+	}
+	// 10 + 30 holds 30 items
+	assert.EqualValues(t, "*1\r\n:2\r\n", execute(s, "BF.INFO", "bf", "FILTERS"))
+	assert.EqualValues(t, "*1\r\n:40\r\n", execute(s, "BF.INFO", "bf", "CAPACITY"))
+	assert.EqualValues(t, "*1\r\n:3\r\n", execute(s, "BF.INFO", "bf", "EXPANSION"))
+	for i := 0; i < 30; i++ {
+		assert.EqualValues(t, ":1\r\n", execute(s, "BF.EXISTS", "bf", "item"+strconv.Itoa(i)))
+	}
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "BF.RESERVE", "fixed", "0.01", "2", "NONSCALING"))
+	assert.EqualValues(t, "*3\r\n:1\r\n:1\r\n-ERR non scaling filter is full\r\n", execute(s, "BF.MADD", "fixed", "a", "b", "c"))
+	assert.EqualValues(t, "-ERR non scaling filter is full\r\n", execute(s, "BF.ADD", "fixed", "c"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "BF.ADD", "fixed", "a"))
+	assert.EqualValues(t, "*1\r\n:1\r\n", execute(s, "BF.INFO", "fixed", "FILTERS"))
+	assert.EqualValues(t, "*1\r\n$-1\r\n", execute(s, "BF.INFO", "fixed", "EXPANSION"))
+}
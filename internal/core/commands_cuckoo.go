@@ -0,0 +1,182 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/probabilistic"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "CF.RESERVE", Handler: (*Storage).cmdCFRESERVE, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cf", Syntax: "key capacity [BUCKETSIZE bucketsize] [MAXITERATIONS maxiterations] [EXPANSION expansion]", Summary: "Create a Cuckoo filter with the given capacity"},
+		&CommandSpec{Name: "CF.ADD", Handler: (*Storage).cmdCFADD, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cf", Syntax: "key item", Summary: "Add an item to a Cuckoo filter, creating the filter if needed"},
+		&CommandSpec{Name: "CF.ADDNX", Handler: (*Storage).cmdCFADDNX, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cf", Syntax: "key item", Summary: "Add an item to a Cuckoo filter only if it does not exist, creating the filter if needed"},
+		&CommandSpec{Name: "CF.EXISTS", Handler: (*Storage).cmdCFEXISTS, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cf", Syntax: "key item", Summary: "Check whether an item may have been added to a Cuckoo filter"},
+		&CommandSpec{Name: "CF.MEXISTS", Handler: (*Storage).cmdCFMEXISTS, Arity: -3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cf", Syntax: "key item [item ...]", Summary: "Check whether items may have been added to a Cuckoo filter"},
+		&CommandSpec{Name: "CF.DEL", Handler: (*Storage).cmdCFDEL, Arity: 3, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cf", Syntax: "key item", Summary: "Delete one copy of an item from a Cuckoo filter"},
+		&CommandSpec{Name: "CF.COUNT", Handler: (*Storage).cmdCFCOUNT, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cf", Syntax: "key item", Summary: "Return the number of copies of an item in a Cuckoo filter"},
+		&CommandSpec{Name: "CF.INFO", Handler: (*Storage).cmdCFINFO, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "cf", Syntax: "key", Summary: "Return information about a Cuckoo filter"},
+	)
+}
+
+var (
+	errCuckooNotFound = errors.New("ERR not found")
+	errCuckooFull     = errors.New("ERR " + probabilistic.ErrCuckooFull.Error())
+)
+
+// cuckooForWrite returns the Cuckoo filter at key, creating one with the default parameters if needed.
+func (s *Storage) cuckooForWrite(key string) (probabilistic.DeletableMembershipTester, error) {
+	cf, err := s.lookupCuckoo(key)
+	if err != nil || cf != nil {
+		return cf, err
+	}
+	cf = probabilistic.NewCuckooFilter(constant.CfDefaultInitCapacity, constant.CfDefaultBucketSize, constant.CfDefaultMaxIterations, constant.CfDefaultExpansion)
+	s.addObj(key, hash_table.ObjTypeCuckoo, hash_table.ObjEncodingRaw, cf)
+	return cf, nil
+}
+
+// parseCuckooOption parses the value of a CF.RESERVE option, between min and max
+func parseCuckooOption(value string, min, max int, errInvalid error) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, errInvalid
+	}
+	return n, nil
+}
+
+func (s *Storage) cmdCFRESERVE(args []string) []byte {
+	capacity, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || capacity <= 0 {
+		return Encode(errors.New("ERR Bad capacity"), false)
+	}
+
+	bucketSize, maxIterations, expansion := constant.CfDefaultBucketSize, constant.CfDefaultMaxIterations, constant.CfDefaultExpansion
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return Encode(errSyntax, false)
+		}
+		var err error
+		switch strings.ToUpper(args[i]) {
+		case "BUCKETSIZE":
+			bucketSize, err = parseCuckooOption(args[i+1], 1, 255, errors.New("ERR Bad bucket size"))
+		case "MAXITERATIONS":
+			maxIterations, err = parseCuckooOption(args[i+1], 1, 65535, errors.New("ERR MAXITERATIONS parameter needs to be a positive integer"))
+		case "EXPANSION":
+			expansion, err = parseCuckooOption(args[i+1], 0, 32768, errors.New("ERR EXPANSION parameter needs to be a non-negative integer"))
+		default:
+			err = errSyntax
+		}
+		if err != nil {
+			return Encode(err, false)
+		}
+	}
+	if !probabilistic.CuckooFilterFits(uint64(capacity), bucketSize) {
+		return Encode(errors.New("ERR Bad capacity"), false)
+	}
+	if s.exists(args[0]) {
+		return Encode(errBloomExists, false)
+	}
+
+	s.addObj(args[0], hash_table.ObjTypeCuckoo, hash_table.ObjEncodingRaw, probabilistic.NewCuckooFilter(uint64(capacity), bucketSize, maxIterations, expansion))
+	return constant.RespOk
+}
+
+func (s *Storage) cmdCFADD(args []string) []byte {
+	cf, err := s.cuckooForWrite(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if err := cf.Add(args[1]); err != nil {
+		return Encode(errCuckooFull, false)
+	}
+	return constant.RespOne
+}
+
+func (s *Storage) cmdCFADDNX(args []string) []byte {
+	cf, err := s.cuckooForWrite(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	added, err := cf.AddNX(args[1])
+	if err != nil {
+		return Encode(errCuckooFull, false)
+	}
+	return Encode(boolReply(added), false)
+}
+
+// cuckooExists checks items against the filter at key, a missing filter holds nothing.
+func (s *Storage) cuckooExists(key string, items []string) ([]interface{}, error) {
+	cf, err := s.lookupCuckoo(key)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		res = append(res, boolReply(cf != nil && cf.Exist(item)))
+	}
+	return res, nil
+}
+
+func (s *Storage) cmdCFEXISTS(args []string) []byte {
+	res, err := s.cuckooExists(args[0], args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return Encode(res[0], false)
+}
+
+func (s *Storage) cmdCFMEXISTS(args []string) []byte {
+	res, err := s.cuckooExists(args[0], args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdCFDEL(args []string) []byte {
+	cf, err := s.lookupCuckoo(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if cf == nil {
+		return Encode(errCuckooNotFound, false)
+	}
+	return Encode(boolReply(cf.Del(args[1])), false)
+}
+
+func (s *Storage) cmdCFCOUNT(args []string) []byte {
+	cf, err := s.lookupCuckoo(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if cf == nil {
+		return Encode(0, false)
+	}
+	return Encode(int(cf.Count(args[1])), false)
+}
+
+func (s *Storage) cmdCFINFO(args []string) []byte {
+	cf, err := s.lookupCuckoo(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if cf == nil {
+		return Encode(errCuckooNotFound, false)
+	}
+
+	info := cf.Info()
+	return Encode([]interface{}{
+		"Size", int(info.Size),
+		"Number of buckets", int(info.Buckets),
+		"Number of filters", info.Filters,
+		"Number of items inserted", int(info.Items),
+		"Number of items deleted", int(info.Deleted),
+		"Bucket size", info.BucketSize,
+		"Expansion rate", info.Expansion,
+		"Max iterations", info.MaxIterations,
+	}, false)
+}
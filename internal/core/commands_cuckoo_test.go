@@ -0,0 +1,81 @@
+package core_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCuckooCommands(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":0\r\n", execute(s, "CF.EXISTS", "cf", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "CF.COUNT", "cf", "a"))
+	assert.EqualValues(t, "-ERR not found\r\n", execute(s, "CF.DEL", "cf", "a"))
+	assert.EqualValues(t, "-ERR not found\r\n", execute(s, "CF.INFO", "cf"))
+
+	assert.EqualValues(t, ":1\r\n", execute(s, "CF.ADD", "cf", "a"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "CF.ADD", "cf", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "CF.ADDNX", "cf", "a"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "CF.ADDNX", "cf", "b"))
+	assert.EqualValues(t, ":2\r\n", execute(s, "CF.COUNT", "cf", "a"))
+	assert.EqualValues(t, "*3\r\n:1\r\n:1\r\n:0\r\n", execute(s, "CF.MEXISTS", "cf", "a", "b", "c"))
+	assert.EqualValues(t, "+MBbloomCF\r\n", execute(s, "TYPE", "cf"))
+
+	assert.EqualValues(t, ":1\r\n", execute(s, "CF.DEL", "cf", "a"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "CF.EXISTS", "cf", "a"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "CF.DEL", "cf", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "CF.EXISTS", "cf", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "CF.DEL", "cf", "a"))
+
+	// CF.ADD creates the filter with the default parameters
+	assert.EqualValues(t, "*16\r\n"+
+		"$4\r\nSize\r\n:1024\r\n"+
+		"$17\r\nNumber of buckets\r\n:512\r\n"+
+		"$17\r\nNumber of filters\r\n:1\r\n"+
+		"$24\r\nNumber of items inserted\r\n:1\r\n"+
+		"$23\r\nNumber of items deleted\r\n:2\r\n"+
+		"$11\r\nBucket size\r\n:2\r\n"+
+		"$14\r\nExpansion rate\r\n:1\r\n"+
+		"$14\r\nMax iterations\r\n:20\r\n", execute(s, "CF.INFO", "cf"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "CF.ADD", "str", "a"))
+	assert.EqualValues(t, wrongType, execute(s, "CF.EXISTS", "str", "a"))
+	execute(s, "BF.ADD", "bf", "a")
+	assert.EqualValues(t, wrongType, execute(s, "CF.DEL", "bf", "a"))
+}
+
+func TestCuckooReserve(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "+OK\r\n", execute(s, "CF.RESERVE", "cf", "8", "BUCKETSIZE", "4", "MAXITERATIONS", "5", "EXPANSION", "0"))
+	assert.EqualValues(t, "-ERR item exists\r\n", execute(s, "CF.RESERVE", "cf", "8"))
+
+	assert.EqualValues(t, "-ERR Bad capacity\r\n", execute(s, "CF.RESERVE", "x", "0"))
+	assert.EqualValues(t, "-ERR Bad capacity\r\n", execute(s, "CF.RESERVE", "x", "9223372036854775807"))
+	assert.EqualValues(t, "-ERR Bad capacity\r\n", execute(s, "CF.RESERVE", "x", "1000000000", "BUCKETSIZE", "4"))
+	assert.EqualValues(t, "-ERR Bad bucket size\r\n", execute(s, "CF.RESERVE", "x", "8", "BUCKETSIZE", "256"))
+	assert.EqualValues(t, "-ERR MAXITERATIONS parameter needs to be a positive integer\r\n", execute(s, "CF.RESERVE", "x", "8", "MAXITERATIONS", "0"))
+	assert.EqualValues(t, "-ERR EXPANSION parameter needs to be a non-negative integer\r\n", execute(s, "CF.RESERVE", "x", "8", "EXPANSION", "-1"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "CF.RESERVE", "x", "8", "EXPANSION"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "CF.RESERVE", "x", "8", "COLOUR", "red"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "x"))
+
+	// A filter that does not expand refuses items once full
+	full := ""
+	for i := 0; i < 100 && full == ""; i++ {
+		if reply := execute(s, "CF.ADD", "cf", "item"+strconv.Itoa(i)); reply != ":1\r\n" {
+			full = reply
+		}
+	}
+	assert.EqualValues(t, "-ERR Filter is full\r\n", full)
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "CF.RESERVE", "growing", "8", "EXPANSION", "2"))
+	for i := 0; i < 100; i++ {
+		assert.EqualValues(t, ":1\r\n", execute(s, "CF.ADD", "growing", "item"+strconv.Itoa(i)))
+	}
+	for i := 0; i < 100; i++ {
+		assert.EqualValues(t, ":1\r\n", execute(s, "CF.EXISTS", "growing", "item"+strconv.Itoa(i)))
+	}
+}
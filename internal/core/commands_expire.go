@@ -0,0 +1,186 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "EXPIRE", Handler: (*Storage).cmdEXPIRE, Arity: -3, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key seconds [NX | XX | GT | LT]", Summary: "Set a key's time to live in seconds"},
+		&CommandSpec{Name: "PEXPIRE", Handler: (*Storage).cmdPEXPIRE, Arity: -3, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key milliseconds [NX | XX | GT | LT]", Summary: "Set a key's time to live in milliseconds"},
+		&CommandSpec{Name: "EXPIREAT", Handler: (*Storage).cmdEXPIREAT, Arity: -3, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key unix-time-seconds [NX | XX | GT | LT]", Summary: "Set the expiration of a key as a Unix timestamp in seconds"},
+		&CommandSpec{Name: "PEXPIREAT", Handler: (*Storage).cmdPEXPIREAT, Arity: -3, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key unix-time-milliseconds [NX | XX | GT | LT]", Summary: "Set the expiration of a key as a Unix timestamp in milliseconds"},
+		&CommandSpec{Name: "PERSIST", Handler: (*Storage).cmdPERSIST, Arity: 2, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key", Summary: "Remove the expiration from a key"},
+		&CommandSpec{Name: "TTL", Handler: (*Storage).cmdTTL, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key", Summary: "Get the time to live for a key in seconds"},
+		&CommandSpec{Name: "PTTL", Handler: (*Storage).cmdPTTL, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key", Summary: "Get the time to live for a key in milliseconds"},
+		&CommandSpec{Name: "EXPIRETIME", Handler: (*Storage).cmdEXPIRETIME, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key", Summary: "Get the expiration Unix timestamp of a key in seconds"},
+		&CommandSpec{Name: "PEXPIRETIME", Handler: (*Storage).cmdPEXPIRETIME, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key", Summary: "Get the expiration Unix timestamp of a key in milliseconds"},
+	)
+}
+
+// expireFlags holds the optional NX | XX | GT | LT arguments of the EXPIRE family.
+type expireFlags struct {
+	nx, xx, gt, lt bool
+}
+
+func parseExpireFlags(args []string) (*expireFlags, error) {
+	flags := &expireFlags{}
+	for _, arg := range args {
+		switch strings.ToUpper(arg) {
+		case "NX":
+			flags.nx = true
+		case "XX":
+			flags.xx = true
+		case "GT":
+			flags.gt = true
+		case "LT":
+			flags.lt = true
+		default:
+			return nil, fmt.Errorf("ERR Unsupported option %s", arg)
+		}
+	}
+	if flags.nx && (flags.xx || flags.gt || flags.lt) {
+		return nil, errors.New("ERR NX and XX, GT or LT options at the same time are not compatible")
+	}
+	if flags.gt && flags.lt {
+		return nil, errors.New("ERR GT and LT options at the same time are not compatible")
+	}
+	return flags, nil
+}
+
+// allows reports whether the flags let the expiry of a key change to atMs.
+// A key without TTL never expires, so it is greater than any new expiry.
+func (f *expireFlags) allows(current uint64, hasExpiry bool, atMs int64) bool {
+	switch {
+	case f.nx && hasExpiry:
+		return false
+	case f.xx && !hasExpiry:
+		return false
+	case f.gt && (!hasExpiry || atMs <= int64(current)):
+		return false
+	case f.lt && hasExpiry && atMs >= int64(current):
+		return false
+	}
+	return true
+}
+
+// expireGeneric implements EXPIRE, PEXPIRE, EXPIREAT and PEXPIREAT. unitMs converts the given
+// time to milliseconds and absolute tells whether it is a Unix timestamp rather than a TTL.
+func (s *Storage) expireGeneric(name string, args []string, unitMs int64, absolute bool) []byte {
+	key := args[0]
+	when, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return Encode(errors.New("ERR value is not an integer or out of range"), false)
+	}
+	flags, err := parseExpireFlags(args[2:])
+	if err != nil {
+		return Encode(err, false)
+	}
+
+	nowMs := time.Now().UnixMilli()
+	if when > math.MaxInt64/unitMs || when < math.MinInt64/unitMs {
+		return Encode(fmt.Errorf("ERR invalid expire time in '%s' command", name), false)
+	}
+	atMs := when * unitMs
+	if !absolute {
+		if atMs > math.MaxInt64-nowMs {
+			return Encode(fmt.Errorf("ERR invalid expire time in '%s' command", name), false)
+		}
+		atMs += nowMs
+	}
+
+	if !s.exists(key) {
+		return constant.RespZero
+	}
+	current, hasExpiry := s.dictStore.GetExpiry(key)
+	if !flags.allows(current, hasExpiry, atMs) {
+		return constant.RespZero
+	}
+
+	if atMs <= nowMs {
+		s.dictStore.Del(key)
+		return constant.RespOne
+	}
+	s.dictStore.SetExpiryAt(key, uint64(atMs))
+	return constant.RespOne
+}
+
+func (s *Storage) cmdEXPIRE(args []string) []byte {
+	return s.expireGeneric("expire", args, 1000, false)
+}
+
+func (s *Storage) cmdPEXPIRE(args []string) []byte {
+	return s.expireGeneric("pexpire", args, 1, false)
+}
+
+func (s *Storage) cmdEXPIREAT(args []string) []byte {
+	return s.expireGeneric("expireat", args, 1000, true)
+}
+
+func (s *Storage) cmdPEXPIREAT(args []string) []byte {
+	return s.expireGeneric("pexpireat", args, 1, true)
+}
+
+func (s *Storage) cmdPERSIST(args []string) []byte {
+	key := args[0]
+	if !s.exists(key) {
+		return constant.RespZero
+	}
+	if _, hasExpiry := s.dictStore.GetExpiry(key); !hasExpiry {
+		return constant.RespZero
+	}
+	s.dictStore.Persist(key)
+	return constant.RespOne
+}
+
+// expiry returns the absolute expiry of key in Unix milliseconds, or the -2 / -1 reply
+// when the key does not exist or has no TTL.
+func (s *Storage) expiry(key string) (uint64, []byte) {
+	if !s.exists(key) {
+		return 0, constant.TtlKeyNotExist
+	}
+	exp, hasExpiry := s.dictStore.GetExpiry(key)
+	if !hasExpiry {
+		return 0, constant.TtlKeyExistNoExpire
+	}
+	return exp, nil
+}
+
+func (s *Storage) cmdTTL(args []string) []byte {
+	exp, res := s.expiry(args[0])
+	if res != nil {
+		return res
+	}
+	return Encode(int64(exp-uint64(time.Now().UnixMilli()))/1000, false)
+}
+
+func (s *Storage) cmdPTTL(args []string) []byte {
+	exp, res := s.expiry(args[0])
+	if res != nil {
+		return res
+	}
+	return Encode(int64(exp-uint64(time.Now().UnixMilli())), false)
+}
+
+func (s *Storage) cmdEXPIRETIME(args []string) []byte {
+	exp, res := s.expiry(args[0])
+	if res != nil {
+		return res
+	}
+	return Encode(int64(exp/1000), false)
+}
+
+func (s *Storage) cmdPEXPIRETIME(args []string) []byte {
+	exp, res := s.expiry(args[0])
+	if res != nil {
+		return res
+	}
+	return Encode(int64(exp), false)
+}
@@ -0,0 +1,60 @@
+package core_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpireConditions(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "SADD", "set", "a")
+
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXPIRE", "missing", "100"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXPIRE", "set", "100", "XX"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXPIRE", "set", "100", "GT"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "EXPIRE", "set", "100", "NX"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXPIRE", "set", "200", "NX"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXPIRE", "set", "50", "GT"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "EXPIRE", "set", "50", "LT"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "EXPIRE", "set", "200", "XX", "GT"))
+
+	assert.EqualValues(t, "-ERR NX and XX, GT or LT options at the same time are not compatible\r\n", execute(s, "EXPIRE", "set", "1", "NX", "GT"))
+	assert.EqualValues(t, "-ERR GT and LT options at the same time are not compatible\r\n", execute(s, "EXPIRE", "set", "1", "GT", "LT"))
+	assert.EqualValues(t, "-ERR Unsupported option FOO\r\n", execute(s, "EXPIRE", "set", "1", "FOO"))
+	assert.EqualValues(t, "-ERR value is not an integer or out of range\r\n", execute(s, "EXPIRE", "set", "x"))
+	assert.EqualValues(t, "-ERR invalid expire time in 'expire' command\r\n", execute(s, "EXPIRE", "set", "9223372036854775807"))
+}
+
+func TestExpireReadersAndPersist(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "ZADD", "zset", "1", "a")
+	at := time.Now().Add(time.Hour).Unix()
+
+	assert.EqualValues(t, ":-1\r\n", execute(s, "PTTL", "zset"))
+	assert.EqualValues(t, ":-2\r\n", execute(s, "EXPIRETIME", "missing"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "EXPIREAT", "zset", strconv.FormatInt(at, 10)))
+	assert.EqualValues(t, ":"+strconv.FormatInt(at, 10)+"\r\n", execute(s, "EXPIRETIME", "zset"))
+	assert.EqualValues(t, ":"+strconv.FormatInt(at*1000, 10)+"\r\n", execute(s, "PEXPIRETIME", "zset"))
+
+	assert.EqualValues(t, ":1\r\n", execute(s, "PERSIST", "zset"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "PERSIST", "zset"))
+	assert.EqualValues(t, ":-1\r\n", execute(s, "TTL", "zset"))
+
+	// A time in the past deletes the key right away.
+	assert.EqualValues(t, ":1\r\n", execute(s, "PEXPIRE", "zset", "-1"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "zset"))
+}
+
+func TestExpiredKeysOfAnyTypeAreDeleted(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "SADD", "set", "a")
+	execute(s, "PEXPIRE", "set", "10")
+	time.Sleep(20 * time.Millisecond)
+	s.ActiveDeleteExpiredKeys()
+	assert.EqualValues(t, ":0\r\n", execute(s, "DBSIZE"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "SMEMBERS", "set"))
+}
@@ -0,0 +1,528 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_map"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "HSET", Handler: (*Storage).cmdHSET, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field value [field value ...]", Summary: "Set the values of fields in a hash"},
+		&CommandSpec{Name: "HMSET", Handler: (*Storage).cmdHMSET, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field value [field value ...]", Summary: "Set the values of fields in a hash"},
+		&CommandSpec{Name: "HSETNX", Handler: (*Storage).cmdHSETNX, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field value", Summary: "Set the value of a field in a hash only when the field doesn't exist"},
+		&CommandSpec{Name: "HGET", Handler: (*Storage).cmdHGET, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field", Summary: "Get the value of a field in a hash"},
+		&CommandSpec{Name: "HMGET", Handler: (*Storage).cmdHMGET, Arity: -3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field [field ...]", Summary: "Get the values of fields in a hash"},
+		&CommandSpec{Name: "HDEL", Handler: (*Storage).cmdHDEL, Arity: -3, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field [field ...]", Summary: "Delete fields from a hash"},
+		&CommandSpec{Name: "HEXISTS", Handler: (*Storage).cmdHEXISTS, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field", Summary: "Check if a field exists in a hash"},
+		&CommandSpec{Name: "HLEN", Handler: (*Storage).cmdHLEN, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key", Summary: "Get the number of fields in a hash"},
+		&CommandSpec{Name: "HKEYS", Handler: (*Storage).cmdHKEYS, Arity: 2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Tips: []string{TipNondeterministicOutputOrder}, Group: "hash", Syntax: "key", Summary: "Get all fields of a hash"},
+		&CommandSpec{Name: "HVALS", Handler: (*Storage).cmdHVALS, Arity: 2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Tips: []string{TipNondeterministicOutputOrder}, Group: "hash", Syntax: "key", Summary: "Get all values of a hash"},
+		&CommandSpec{Name: "HGETALL", Handler: (*Storage).cmdHGETALL, Arity: 2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Tips: []string{TipNondeterministicOutputOrder}, Group: "hash", Syntax: "key", Summary: "Get all fields and values of a hash"},
+		&CommandSpec{Name: "HINCRBY", Handler: (*Storage).cmdHINCRBY, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field increment", Summary: "Increment the integer value of a field in a hash"},
+		&CommandSpec{Name: "HINCRBYFLOAT", Handler: (*Storage).cmdHINCRBYFLOAT, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field increment", Summary: "Increment the floating point value of a field in a hash"},
+		&CommandSpec{Name: "HSTRLEN", Handler: (*Storage).cmdHSTRLEN, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key field", Summary: "Get the length of the value of a field in a hash"},
+		&CommandSpec{Name: "HRANDFIELD", Handler: (*Storage).cmdHRANDFIELD, Arity: -2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Tips: []string{TipNondeterministicOutput}, Group: "hash", Syntax: "key [count [WITHVALUES]]", Summary: "Get random fields from a hash"},
+		&CommandSpec{Name: "HSCAN", Handler: (*Storage).cmdHSCAN, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Tips: []string{TipNondeterministicOutput}, Group: "hash", Syntax: "key cursor [MATCH pattern] [COUNT count]", Summary: "Incrementally iterate over the fields and values of a hash"},
+		&CommandSpec{Name: "HEXPIRE", Handler: (*Storage).cmdHEXPIRE, Arity: -6, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key seconds [NX | XX | GT | LT] FIELDS numfields field [field ...]", Summary: "Set the time to live of fields in a hash in seconds"},
+		&CommandSpec{Name: "HPEXPIRE", Handler: (*Storage).cmdHPEXPIRE, Arity: -6, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key milliseconds [NX | XX | GT | LT] FIELDS numfields field [field ...]", Summary: "Set the time to live of fields in a hash in milliseconds"},
+		&CommandSpec{Name: "HTTL", Handler: (*Storage).cmdHTTL, Arity: -5, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key FIELDS numfields field [field ...]", Summary: "Get the time to live of fields in a hash in seconds"},
+		&CommandSpec{Name: "HPTTL", Handler: (*Storage).cmdHPTTL, Arity: -5, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key FIELDS numfields field [field ...]", Summary: "Get the time to live of fields in a hash in milliseconds"},
+		&CommandSpec{Name: "HPERSIST", Handler: (*Storage).cmdHPERSIST, Arity: -5, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hash", Syntax: "key FIELDS numfields field [field ...]", Summary: "Remove the time to live of fields in a hash"},
+	)
+}
+
+// Replies of the field expiry commands, one per field
+const (
+	fieldNotFound   = -2
+	fieldNoExpiry   = -1
+	fieldNotUpdated = 0
+	fieldUpdated    = 1
+	fieldDeleted    = 2
+)
+
+// hashForWrite returns the hash at key, creating it if needed.
+func (s *Storage) hashForWrite(key string) (*hash_map.HashMap, error) {
+	h, err := s.lookupHash(key)
+	if err != nil || h != nil {
+		return h, err
+	}
+	h = hash_map.NewHashMap()
+	s.addObj(key, hash_table.ObjTypeHash, hash_table.ObjEncodingHT, h)
+	return h, nil
+}
+
+// deleteIfEmptyHash removes key once its hash has no field left, hashes are never stored empty.
+func (s *Storage) deleteIfEmptyHash(key string, h *hash_map.HashMap) {
+	if h.Empty() {
+		s.dictStore.Del(key)
+	}
+}
+
+func (s *Storage) hset(name string, args []string) (int, []byte) {
+	if len(args)%2 != 1 {
+		return 0, errWrongNumberOfArgs(name)
+	}
+	h, err := s.hashForWrite(args[0])
+	if err != nil {
+		return 0, Encode(err, false)
+	}
+	added := 0
+	for i := 1; i < len(args); i += 2 {
+		if h.Set(args[i], args[i+1]) {
+			added++
+		}
+	}
+	return added, nil
+}
+
+func (s *Storage) cmdHSET(args []string) []byte {
+	added, errReply := s.hset("HSET", args)
+	if errReply != nil {
+		return errReply
+	}
+	return Encode(added, false)
+}
+
+func (s *Storage) cmdHMSET(args []string) []byte {
+	if _, errReply := s.hset("HMSET", args); errReply != nil {
+		return errReply
+	}
+	return constant.RespOk
+}
+
+func (s *Storage) cmdHSETNX(args []string) []byte {
+	h, err := s.hashForWrite(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if _, exist := h.Get(args[1]); exist {
+		return constant.RespZero
+	}
+	h.Set(args[1], args[2])
+	return constant.RespOne
+}
+
+func (s *Storage) cmdHGET(args []string) []byte {
+	h, err := s.lookupHash(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if h == nil {
+		return constant.RespNil
+	}
+	value, exist := h.Get(args[1])
+	s.deleteIfEmptyHash(args[0], h)
+	if !exist {
+		return constant.RespNil
+	}
+	return Encode(value, false)
+}
+
+func (s *Storage) cmdHMGET(args []string) []byte {
+	h, err := s.lookupHash(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, len(args)-1)
+	if h != nil {
+		for i, field := range args[1:] {
+			if value, exist := h.Get(field); exist {
+				res[i] = value
+			}
+		}
+		s.deleteIfEmptyHash(args[0], h)
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdHDEL(args []string) []byte {
+	key := args[0]
+	h, err := s.lookupHash(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if h == nil {
+		return constant.RespZero
+	}
+	deleted := 0
+	for _, field := range args[1:] {
+		if h.Del(field) {
+			deleted++
+		}
+	}
+	s.deleteIfEmptyHash(key, h)
+	return Encode(deleted, false)
+}
+
+func (s *Storage) cmdHEXISTS(args []string) []byte {
+	h, err := s.lookupHash(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if h == nil {
+		return constant.RespZero
+	}
+	_, exist := h.Get(args[1])
+	s.deleteIfEmptyHash(args[0], h)
+	return Encode(boolReply(exist), false)
+}
+
+func (s *Storage) cmdHLEN(args []string) []byte {
+	h, err := s.lookupHash(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if h == nil {
+		return constant.RespZero
+	}
+	n := h.Len()
+	s.deleteIfEmptyHash(args[0], h)
+	return Encode(n, false)
+}
+
+// hashContent replies with the fields and/or values of a hash.
+func (s *Storage) hashContent(key string, withFields, withValues bool) []byte {
+	h, err := s.lookupHash(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := []string{}
+	if h != nil {
+		for field, value := range h.All() {
+			if withFields {
+				res = append(res, field)
+			}
+			if withValues {
+				res = append(res, value)
+			}
+		}
+		s.deleteIfEmptyHash(key, h)
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdHKEYS(args []string) []byte {
+	return s.hashContent(args[0], true, false)
+}
+
+func (s *Storage) cmdHVALS(args []string) []byte {
+	return s.hashContent(args[0], false, true)
+}
+
+func (s *Storage) cmdHGETALL(args []string) []byte {
+	return s.hashContent(args[0], true, true)
+}
+
+// updateField sets a field of the hash at key to update(current value), keeping the field's TTL.
+func (s *Storage) updateField(key, field string, update func(current string, exist bool) (string, error)) []byte {
+	h, err := s.hashForWrite(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	current, exist := h.Get(field)
+	value, err := update(current, exist)
+	if err != nil {
+		s.deleteIfEmptyHash(key, h)
+		return Encode(err, false)
+	}
+	exp, hasExpiry := h.Expiry(field)
+	h.Set(field, value)
+	if hasExpiry {
+		h.SetExpiry(field, exp)
+	}
+	return nil
+}
+
+func (s *Storage) cmdHINCRBY(args []string) []byte {
+	delta, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	var res int64
+	errReply := s.updateField(args[0], args[1], func(current string, exist bool) (string, error) {
+		if exist {
+			if res, err = strconv.ParseInt(current, 10, 64); err != nil {
+				return "", errors.New("ERR hash value is not an integer")
+			}
+		}
+		if (delta > 0 && res > math.MaxInt64-delta) || (delta < 0 && res < math.MinInt64-delta) {
+			return "", errors.New("ERR increment or decrement would overflow")
+		}
+		res += delta
+		return strconv.FormatInt(res, 10), nil
+	})
+	if errReply != nil {
+		return errReply
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdHINCRBYFLOAT(args []string) []byte {
+	delta, ok := parseFloat(args[2])
+	if !ok {
+		return Encode(errNotFloat, false)
+	}
+	var res string
+	errReply := s.updateField(args[0], args[1], func(current string, exist bool) (string, error) {
+		var value float64
+		if exist {
+			if value, ok = parseFloat(current); !ok {
+				return "", errors.New("ERR hash value is not a float")
+			}
+		}
+		value += delta
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return "", errors.New("ERR increment would produce NaN or Infinity")
+		}
+		res = strconv.FormatFloat(value, 'f', -1, 64)
+		return res, nil
+	})
+	if errReply != nil {
+		return errReply
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdHSTRLEN(args []string) []byte {
+	h, err := s.lookupHash(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if h == nil {
+		return constant.RespZero
+	}
+	value, _ := h.Get(args[1])
+	s.deleteIfEmptyHash(args[0], h)
+	return Encode(len(value), false)
+}
+
+func (s *Storage) cmdHRANDFIELD(args []string) []byte {
+	if len(args) > 3 || (len(args) == 3 && !strings.EqualFold(args[2], "WITHVALUES")) {
+		return Encode(errSyntax, false)
+	}
+	h, err := s.lookupHash(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	var fields []string
+	if h != nil {
+		// The fields that expired since the last access may have been the last ones
+		fields = h.Fields()
+		s.deleteIfEmptyHash(args[0], h)
+	}
+	if len(args) == 1 {
+		if len(fields) == 0 {
+			return constant.RespNil
+		}
+		return Encode(fields[rand.Intn(len(fields))], false)
+	}
+
+	count, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	withValues := len(args) == 3
+	if len(fields) == 0 || count == 0 {
+		return constant.RespEmptyArray
+	}
+	var picked []string
+	if count > 0 {
+		// Distinct fields, as many as the hash has at most
+		rand.Shuffle(len(fields), func(i, j int) { fields[i], fields[j] = fields[j], fields[i] })
+		picked = fields[:min(int(count), len(fields))]
+	} else {
+		// Exactly -count fields, possibly repeated
+		if count < -math.MaxInt32 {
+			return Encode(errors.New("ERR value is out of range"), false)
+		}
+		picked = make([]string, -count)
+		for i := range picked {
+			picked[i] = fields[rand.Intn(len(fields))]
+		}
+	}
+	res := make([]string, 0, len(picked)*2)
+	for _, field := range picked {
+		res = append(res, field)
+		if withValues {
+			value, _ := h.Get(field)
+			res = append(res, value)
+		}
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdHSCAN(args []string) []byte {
+	opts, err := parseScanOptions(args[1:], false)
+	if err != nil {
+		return Encode(err, false)
+	}
+	h, err := s.lookupHash(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if h == nil {
+		return scanReply(0, []string{})
+	}
+	batch, next := h.Scan(opts.cursor, opts.count)
+	res := []string{}
+	for _, field := range batch {
+		if opts.pattern != "" && !matchGlob(opts.pattern, field) {
+			continue
+		}
+		if value, exist := h.Get(field); exist {
+			res = append(res, field, value)
+		}
+	}
+	s.deleteIfEmptyHash(args[0], h)
+	return scanReply(next, res)
+}
+
+// parseFieldsArg parses the FIELDS numfields field [field ...] arguments of the field expiry commands.
+func parseFieldsArg(args []string) ([]string, error) {
+	if len(args) < 2 || !strings.EqualFold(args[0], "FIELDS") {
+		return nil, errors.New("ERR Mandatory argument FIELDS is missing or not at the right position")
+	}
+	numFields, err := strconv.Atoi(args[1])
+	if err != nil || numFields <= 0 {
+		return nil, errors.New("ERR Parameter `numFields` should be greater than 0")
+	}
+	if numFields != len(args)-2 {
+		return nil, errors.New("ERR The `numfields` parameter must match the number of arguments")
+	}
+	return args[2:], nil
+}
+
+// hexpire implements HEXPIRE and HPEXPIRE, unitMs converts the TTL to milliseconds.
+func (s *Storage) hexpire(name string, args []string, unitMs int64) []byte {
+	key := args[0]
+	ttl, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	rest := args[2:]
+	var flagArgs []string
+	if len(rest) > 0 && !strings.EqualFold(rest[0], "FIELDS") {
+		flagArgs, rest = rest[:1], rest[1:]
+	}
+	flags, err := parseExpireFlags(flagArgs)
+	if err != nil {
+		return Encode(err, false)
+	}
+	fields, err := parseFieldsArg(rest)
+	if err != nil {
+		return Encode(err, false)
+	}
+	nowMs := time.Now().UnixMilli()
+	if ttl < 0 || ttl > (math.MaxInt64-nowMs)/unitMs {
+		return Encode(errors.New("ERR invalid expire time in '"+name+"' command"), false)
+	}
+	atMs := nowMs + ttl*unitMs
+
+	h, err := s.lookupHash(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, len(fields))
+	for i, field := range fields {
+		res[i] = fieldNotFound
+		if h == nil {
+			continue
+		}
+		if _, exist := h.Get(field); !exist {
+			continue
+		}
+		current, hasExpiry := h.Expiry(field)
+		switch {
+		case !flags.allows(current, hasExpiry, atMs):
+			res[i] = fieldNotUpdated
+		case atMs <= nowMs:
+			h.Del(field)
+			res[i] = fieldDeleted
+		default:
+			h.SetExpiry(field, uint64(atMs))
+			s.fieldExpiryKeys[key] = struct{}{}
+			res[i] = fieldUpdated
+		}
+	}
+	if h != nil {
+		s.deleteIfEmptyHash(key, h)
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdHEXPIRE(args []string) []byte {
+	return s.hexpire("hexpire", args, 1000)
+}
+
+func (s *Storage) cmdHPEXPIRE(args []string) []byte {
+	return s.hexpire("hpexpire", args, 1)
+}
+
+// fieldsReply runs reply on every field listed by the FIELDS argument of the hash at key.
+// Fields that do not exist get fieldNotFound.
+func (s *Storage) fieldsReply(args []string, reply func(h *hash_map.HashMap, field string) int64) []byte {
+	fields, err := parseFieldsArg(args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	h, err := s.lookupHash(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, len(fields))
+	for i, field := range fields {
+		res[i] = int64(fieldNotFound)
+		if h == nil {
+			continue
+		}
+		if _, exist := h.Get(field); exist {
+			res[i] = reply(h, field)
+		}
+	}
+	if h != nil {
+		s.deleteIfEmptyHash(args[0], h)
+	}
+	return Encode(res, false)
+}
+
+// fieldTTL returns the time to live of a field in units of unitMs, or fieldNoExpiry.
+func fieldTTL(h *hash_map.HashMap, field string, unitMs uint64) int64 {
+	exp, hasExpiry := h.Expiry(field)
+	if !hasExpiry {
+		return fieldNoExpiry
+	}
+	now := uint64(time.Now().UnixMilli())
+	if exp <= now {
+		return 0
+	}
+	return int64((exp - now) / unitMs)
+}
+
+func (s *Storage) cmdHTTL(args []string) []byte {
+	return s.fieldsReply(args, func(h *hash_map.HashMap, field string) int64 {
+		return fieldTTL(h, field, 1000)
+	})
+}
+
+func (s *Storage) cmdHPTTL(args []string) []byte {
+	return s.fieldsReply(args, func(h *hash_map.HashMap, field string) int64 {
+		return fieldTTL(h, field, 1)
+	})
+}
+
+func (s *Storage) cmdHPERSIST(args []string) []byte {
+	return s.fieldsReply(args, func(h *hash_map.HashMap, field string) int64 {
+		if !h.Persist(field) {
+			return fieldNoExpiry
+		}
+		return fieldUpdated
+	})
+}
@@ -0,0 +1,91 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashCommands(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":2\r\n", execute(s, "HSET", "user", "name", "ann", "age", "30"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "HSET", "user", "name", "bob"))
+	assert.EqualValues(t, "-ERR wrong number of arguments for 'hset' command\r\n", execute(s, "HSET", "user", "a", "1", "b"))
+	assert.EqualValues(t, "+hash\r\n", execute(s, "TYPE", "user"))
+
+	assert.EqualValues(t, "$3\r\nbob\r\n", execute(s, "HGET", "user", "name"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "HGET", "user", "missing"))
+	assert.EqualValues(t, "*3\r\n$3\r\nbob\r\n$-1\r\n$2\r\n30\r\n", execute(s, "HMGET", "user", "name", "x", "age"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "HSETNX", "user", "name", "cat"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "HSETNX", "user", "city", "hn"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "HLEN", "user"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "HEXISTS", "user", "city"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "HSTRLEN", "user", "name"))
+
+	assert.EqualValues(t, ":2\r\n", execute(s, "HDEL", "user", "city", "age", "missing"))
+	assert.EqualValues(t, "*2\r\n$4\r\nname\r\n$3\r\nbob\r\n", execute(s, "HGETALL", "user"))
+	assert.EqualValues(t, "*1\r\n$4\r\nname\r\n", execute(s, "HKEYS", "user"))
+	assert.EqualValues(t, "*1\r\n$3\r\nbob\r\n", execute(s, "HVALS", "user"))
+	assert.EqualValues(t, "$4\r\nname\r\n", execute(s, "HRANDFIELD", "user"))
+	assert.EqualValues(t, "*4\r\n$4\r\nname\r\n$3\r\nbob\r\n$4\r\nname\r\n$3\r\nbob\r\n", execute(s, "HRANDFIELD", "user", "-2", "WITHVALUES"))
+	assert.EqualValues(t, "*1\r\n$4\r\nname\r\n", execute(s, "HRANDFIELD", "user", "5"))
+
+	assert.EqualValues(t, ":1\r\n", execute(s, "HDEL", "user", "name"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "user"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "HGETALL", "user"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "HSET", "str", "a", "1"))
+}
+
+func TestHashIncrAndScan(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":5\r\n", execute(s, "HINCRBY", "h", "n", "5"))
+	assert.EqualValues(t, ":2\r\n", execute(s, "HINCRBY", "h", "n", "-3"))
+	assert.EqualValues(t, "$3\r\n2.5\r\n", execute(s, "HINCRBYFLOAT", "h", "n", "0.5"))
+	assert.EqualValues(t, "-ERR hash value is not an integer\r\n", execute(s, "HINCRBY", "h", "n", "1"))
+	execute(s, "HSET", "h", "s", "abc")
+	assert.EqualValues(t, "-ERR hash value is not a float\r\n", execute(s, "HINCRBYFLOAT", "h", "s", "1"))
+
+	assert.EqualValues(t, "*2\r\n$1\r\n0\r\n*2\r\n$1\r\ns\r\n$3\r\nabc\r\n", execute(s, "HSCAN", "h", "0", "MATCH", "s*"))
+	assert.EqualValues(t, "*2\r\n$1\r\n0\r\n*0\r\n", execute(s, "HSCAN", "missing", "0"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "HSCAN", "h", "0", "TYPE", "string"))
+}
+
+func TestHashFieldExpiry(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "HSET", "h", "a", "1", "b", "2", "c", "3")
+	assert.EqualValues(t, "*3\r\n:1\r\n:1\r\n:-2\r\n", execute(s, "HEXPIRE", "h", "100", "FIELDS", "3", "a", "b", "missing"))
+	assert.EqualValues(t, "*1\r\n:0\r\n", execute(s, "HEXPIRE", "h", "200", "NX", "FIELDS", "1", "a"))
+	assert.Contains(t, []string{"*2\r\n:100\r\n:-1\r\n", "*2\r\n:99\r\n:-1\r\n"}, string(execute(s, "HTTL", "h", "FIELDS", "2", "a", "c")))
+	assert.EqualValues(t, "*2\r\n:1\r\n:-1\r\n", execute(s, "HPERSIST", "h", "FIELDS", "2", "a", "c"))
+	assert.EqualValues(t, "*1\r\n:2\r\n", execute(s, "HEXPIRE", "h", "0", "FIELDS", "1", "c"))
+	assert.EqualValues(t, "*3\r\n:-2\r\n:-2\r\n:-2\r\n", execute(s, "HTTL", "missing", "FIELDS", "3", "a", "b", "c"))
+
+	assert.EqualValues(t, "-ERR Mandatory argument FIELDS is missing or not at the right position\r\n", execute(s, "HTTL", "h", "2", "a", "b"))
+	assert.EqualValues(t, "-ERR The `numfields` parameter must match the number of arguments\r\n", execute(s, "HTTL", "h", "FIELDS", "2", "a"))
+	assert.EqualValues(t, "-ERR Parameter `numFields` should be greater than 0\r\n", execute(s, "HTTL", "h", "FIELDS", "0", "a"))
+
+	// Fields expire on access and through the active expiry cycle, the hash goes with its last field
+	execute(s, "HPEXPIRE", "h", "10", "FIELDS", "1", "a")
+	execute(s, "HPEXPIRE", "h", "10", "FIELDS", "1", "b")
+	time.Sleep(20 * time.Millisecond)
+	s.ActiveDeleteExpiredKeys()
+	assert.EqualValues(t, ":0\r\n", execute(s, "DBSIZE"))
+
+	execute(s, "HSET", "h", "a", "1")
+	execute(s, "HPEXPIRE", "h", "10", "FIELDS", "1", "a")
+	time.Sleep(20 * time.Millisecond)
+	assert.EqualValues(t, "$-1\r\n", execute(s, "HRANDFIELD", "h"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "h"))
+
+	// A read that expires the last field deletes the hash
+	execute(s, "HSET", "h", "a", "1")
+	execute(s, "HPEXPIRE", "h", "10", "FIELDS", "1", "a")
+	time.Sleep(20 * time.Millisecond)
+	assert.EqualValues(t, "$-1\r\n", execute(s, "HGET", "h", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "h"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "SADD", "h", "x"))
+}
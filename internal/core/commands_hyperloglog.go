@@ -0,0 +1,144 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/probabilistic"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "PFADD", Handler: (*Storage).cmdPFADD, Arity: -2, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "hyperloglog", Syntax: "key [element [element ...]]", Summary: "Add elements to a HyperLogLog key, creating the key if needed"},
+		&CommandSpec{Name: "PFCOUNT", Handler: (*Storage).cmdPFCOUNT, Arity: -2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: -1, Step: 1, Group: "hyperloglog", Syntax: "key [key ...]", Summary: "Return the approximated cardinality of the union of HyperLogLog keys"},
+		&CommandSpec{Name: "PFMERGE", Handler: (*Storage).cmdPFMERGE, Arity: -2, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: -1, Step: 1, Group: "hyperloglog", Syntax: "destkey [sourcekey [sourcekey ...]]", Summary: "Merge HyperLogLog keys into a destination key"},
+	)
+}
+
+// lookupHLL returns the string object at key and the HyperLogLog it holds, a nil object if there is no such key.
+// A HyperLogLog is a plain string, strings that do not have its layout are of the wrong type.
+func (s *Storage) lookupHLL(key string) (*hash_table.Obj, *probabilistic.HyperLogLog, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeString)
+	if obj == nil {
+		return nil, nil, err
+	}
+	h, err := probabilistic.ParseHyperLogLog(stringValue(obj))
+	switch err {
+	case nil:
+		return obj, h, nil
+	case probabilistic.ErrCorruptHLL:
+		return nil, nil, errors.New("INVALIDOBJ " + err.Error())
+	default:
+		return nil, nil, errors.New("WRONGTYPE " + err.Error())
+	}
+}
+
+// storeHLL writes h to key, in place when obj holds the key so that its TTL is kept. The string is
+// held as a byte slice, which PFADD updates in place once it is dense.
+func (s *Storage) storeHLL(key string, obj *hash_table.Obj, h *probabilistic.HyperLogLog) {
+	if obj == nil {
+		s.addObj(key, hash_table.ObjTypeString, hash_table.ObjEncodingRaw, h.Bytes())
+		return
+	}
+	obj.Value, obj.Encoding = h.Bytes(), hash_table.ObjEncodingRaw
+}
+
+// denseHLL returns the dense HyperLogLog string obj holds for PFADD to update in place, or nil
+func denseHLL(obj *hash_table.Obj) []byte {
+	if obj == nil {
+		return nil
+	}
+	if p, ok := obj.Value.([]byte); ok && probabilistic.IsDenseHLL(p) {
+		return p
+	}
+	return nil
+}
+
+func (s *Storage) cmdPFADD(args []string) []byte {
+	obj, err := s.lookup(args[0], hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	// A dense HyperLogLog is updated a register at a time, without decoding the others
+	if p := denseHLL(obj); p != nil {
+		updated := false
+		for _, element := range args[1:] {
+			if probabilistic.AddDense(p, element) {
+				updated = true
+			}
+		}
+		return Encode(boolReply(updated), false)
+	}
+
+	obj, h, err := s.lookupHLL(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+
+	updated := obj == nil
+	if obj == nil {
+		h = probabilistic.NewHyperLogLog()
+	}
+	for _, element := range args[1:] {
+		if h.Add(element) {
+			updated = true
+		}
+	}
+	if !updated {
+		return Encode(0, false)
+	}
+	s.storeHLL(args[0], obj, h)
+	return constant.RespOne
+}
+
+func (s *Storage) cmdPFCOUNT(args []string) []byte {
+	if len(args) == 1 {
+		obj, h, err := s.lookupHLL(args[0])
+		if err != nil {
+			return Encode(err, false)
+		}
+		if obj == nil {
+			return Encode(0, false)
+		}
+		// Like Redis, the estimated cardinality is cached in the string until an element changes it
+		if !h.CountIsCached() {
+			h.Count()
+			s.storeHLL(args[0], obj, h)
+		}
+		return Encode(int(h.Count()), false)
+	}
+
+	union := probabilistic.NewHyperLogLog()
+	for _, key := range args {
+		obj, h, err := s.lookupHLL(key)
+		if err != nil {
+			return Encode(err, false)
+		}
+		if obj != nil {
+			union.Merge(h)
+		}
+	}
+	return Encode(int(union.Count()), false)
+}
+
+func (s *Storage) cmdPFMERGE(args []string) []byte {
+	// The destination is part of the union when it exists
+	var dest *hash_table.Obj
+	union := probabilistic.NewHyperLogLog()
+	for i, key := range args {
+		obj, h, err := s.lookupHLL(key)
+		if err != nil {
+			return Encode(err, false)
+		}
+		if i == 0 {
+			dest = obj
+		}
+		if obj != nil {
+			union.Merge(h)
+		}
+	}
+
+	s.storeHLL(args[0], dest, union)
+	return constant.RespOk
+}
@@ -0,0 +1,91 @@
+package core_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLogCommands(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":0\r\n", execute(s, "PFCOUNT", "hll"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "PFADD", "hll"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "PFADD", "hll"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "PFCOUNT", "hll"))
+
+	assert.EqualValues(t, ":1\r\n", execute(s, "PFADD", "hll", "a", "b", "c"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "PFADD", "hll", "a", "b"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "PFCOUNT", "hll"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "PFCOUNT", "hll"))
+	assert.EqualValues(t, "+string\r\n", execute(s, "TYPE", "hll"))
+
+	// The HyperLogLog is a string, it can be copied with GET and SET
+	value, err := core.Decode([]byte(execute(s, "GET", "hll")))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "HYLL", value.(string)[:4])
+	execute(s, "SET", "copy", value.(string))
+	assert.EqualValues(t, ":3\r\n", execute(s, "PFCOUNT", "copy"))
+
+	execute(s, "PFADD", "other", "c", "d")
+	assert.EqualValues(t, ":4\r\n", execute(s, "PFCOUNT", "hll", "other", "missing"))
+
+	execute(s, "SET", "str", "hello")
+	assert.EqualValues(t, "-WRONGTYPE Key is not a valid HyperLogLog string value.\r\n", execute(s, "PFADD", "str", "a"))
+	assert.EqualValues(t, "-WRONGTYPE Key is not a valid HyperLogLog string value.\r\n", execute(s, "PFCOUNT", "hll", "str"))
+	execute(s, "SET", "corrupt", "HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x3f")
+	assert.EqualValues(t, "-INVALIDOBJ Corrupted HLL object detected\r\n", execute(s, "PFCOUNT", "corrupt"))
+	execute(s, "SADD", "set", "a")
+	assert.EqualValues(t, wrongType, execute(s, "PFADD", "set", "a"))
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	s := core.NewStorage()
+	for i := 0; i < 1000; i++ {
+		execute(s, "PFADD", "day1", "user"+strconv.Itoa(i))
+		execute(s, "PFADD", "day2", "user"+strconv.Itoa(i+500))
+	}
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "PFMERGE", "week", "day1", "day2", "missing"))
+	count, err := core.Decode([]byte(execute(s, "PFCOUNT", "week")))
+	assert.NoError(t, err)
+	assert.InDelta(t, 1500, count, 50)
+	assert.EqualValues(t, execute(s, "PFCOUNT", "day1", "day2"), execute(s, "PFCOUNT", "week"))
+
+	// The destination is part of the union and keeps its TTL
+	execute(s, "PFADD", "dest", "x")
+	execute(s, "EXPIRE", "dest", "100")
+	assert.EqualValues(t, "+OK\r\n", execute(s, "PFMERGE", "dest", "day1"))
+	count, _ = core.Decode([]byte(execute(s, "PFCOUNT", "dest")))
+	assert.InDelta(t, 1001, count, 40)
+	assert.Contains(t, []string{":100\r\n", ":99\r\n"}, execute(s, "TTL", "dest"))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "PFMERGE", "empty"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "PFCOUNT", "empty"))
+}
+
+func TestHyperLogLogDense(t *testing.T) {
+	s := core.NewStorage()
+	for i := 0; i < 10000; i++ {
+		execute(s, "PFADD", "hll", "user"+strconv.Itoa(i))
+	}
+	count, _ := core.Decode([]byte(execute(s, "PFCOUNT", "hll")))
+	assert.InDelta(t, 10000, count, 300)
+
+	// A dense HyperLogLog updated in place drops its cached cardinality
+	assert.EqualValues(t, ":0\r\n", execute(s, "PFADD", "hll", "user0", "user1"))
+	for i := 10000; i < 20000; i++ {
+		execute(s, "PFADD", "hll", "user"+strconv.Itoa(i))
+	}
+	count, _ = core.Decode([]byte(execute(s, "PFCOUNT", "hll")))
+	assert.InDelta(t, 20000, count, 600)
+
+	// A copy written with SET is still a HyperLogLog
+	value, _ := core.Decode([]byte(execute(s, "GET", "hll")))
+	execute(s, "SET", "copy", value.(string))
+	assert.EqualValues(t, execute(s, "PFCOUNT", "hll"), execute(s, "PFCOUNT", "copy"))
+	for i := 0; i < 2; i++ {
+		assert.EqualValues(t, ":1\r\n", execute(s, "PFADD", "copy", "new"+strconv.Itoa(i), "more"+strconv.Itoa(i), "again"+strconv.Itoa(i)))
+	}
+}
@@ -0,0 +1,305 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/simple_set"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "DEL", Handler: (*Storage).cmdDEL, Arity: -2, Flags: []string{FlagWrite}, FirstKey: 1, LastKey: -1, Step: 1, Tips: []string{TipRequestMultiShard, TipResponseAggSum}, Group: "generic", Syntax: "key [key ...]", Summary: "Delete keys"},
+		&CommandSpec{Name: "UNLINK", Handler: (*Storage).cmdDEL, Arity: -2, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: -1, Step: 1, Tips: []string{TipRequestMultiShard, TipResponseAggSum}, Group: "generic", Syntax: "key [key ...]", Summary: "Delete keys"},
+		&CommandSpec{Name: "EXISTS", Handler: (*Storage).cmdEXISTS, Arity: -2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: -1, Step: 1, Tips: []string{TipRequestMultiShard, TipResponseAggSum}, Group: "generic", Syntax: "key [key ...]", Summary: "Count how many of the keys exist"},
+		&CommandSpec{Name: "TYPE", Handler: (*Storage).cmdTYPE, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "generic", Syntax: "key", Summary: "Get the type of the value stored at a key"},
+		&CommandSpec{Name: "OBJECT", Handler: (*Storage).cmdOBJECT, Arity: -2, Flags: []string{FlagReadOnly}, FirstKey: 2, LastKey: 2, Step: 1, Group: "generic", Syntax: "ENCODING key | HELP", Summary: "Inspect the internals of the value stored at a key"},
+		&CommandSpec{Name: "RENAME", Handler: (*Storage).cmdRENAME, Arity: 3, Flags: []string{FlagWrite}, FirstKey: 1, LastKey: 2, Step: 1, Group: "generic", Syntax: "key newkey", Summary: "Rename a key"},
+		&CommandSpec{Name: "RENAMENX", Handler: (*Storage).cmdRENAMENX, Arity: 3, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 2, Step: 1, Group: "generic", Syntax: "key newkey", Summary: "Rename a key only if the new key does not exist"},
+		&CommandSpec{Name: "KEYS", Handler: (*Storage).cmdKEYS, Arity: 2, Flags: []string{FlagReadOnly}, Tips: []string{TipRequestAllShards, TipNondeterministicOutputOrder}, Group: "generic", Syntax: "pattern", Summary: "Find all keys matching a glob-style pattern"},
+		&CommandSpec{Name: "SCAN", Handler: (*Storage).cmdSCAN, Arity: -2, Flags: []string{FlagReadOnly}, Tips: []string{TipNondeterministicOutput, TipRequestSpecial, TipResponseSpecial}, Group: "generic", Syntax: "cursor [MATCH pattern] [COUNT count] [TYPE type]", Summary: "Incrementally iterate over the keys"},
+		&CommandSpec{Name: "RANDOMKEY", Handler: (*Storage).cmdRANDOMKEY, Arity: 1, Flags: []string{FlagReadOnly}, Tips: []string{TipRequestAllShards, TipResponseSpecial, TipNondeterministicOutput}, Group: "generic", Summary: "Return a random key"},
+		&CommandSpec{Name: "DBSIZE", Handler: (*Storage).cmdDBSIZE, Arity: 1, Flags: []string{FlagReadOnly, FlagFast}, Tips: []string{TipRequestAllShards, TipResponseAggSum}, Group: "server", Summary: "Return the number of keys"},
+		&CommandSpec{Name: "FLUSHDB", Handler: (*Storage).cmdFLUSHDB, Arity: -1, Flags: []string{FlagWrite}, Tips: []string{TipRequestAllShards, TipResponseAllSucceeded}, Group: "server", Syntax: "[ASYNC | SYNC]", Summary: "Remove all keys"},
+		&CommandSpec{Name: "FLUSHALL", Handler: (*Storage).cmdFLUSHDB, Arity: -1, Flags: []string{FlagWrite}, Tips: []string{TipRequestAllShards, TipResponseAllSucceeded}, Group: "server", Syntax: "[ASYNC | SYNC]", Summary: "Remove all keys"},
+	)
+}
+
+const (
+	typeNone    = "none"
+	typeString  = "string"
+	typeZSet    = "zset"
+	typeSet     = "set"
+	typeCMS     = "CMSk-TYPE"
+	typeList    = "list"
+	typeHash    = "hash"
+	typeBloom   = "MBbloom--"
+	typeCuckoo  = "MBbloomCF"
+	typeTopK    = "TopK-TYPE"
+	typeTDigest = "TDIS-TYPE"
+)
+
+var typeNames = map[hash_table.ObjType]string{
+	hash_table.ObjTypeString:  typeString,
+	hash_table.ObjTypeZSet:    typeZSet,
+	hash_table.ObjTypeSet:     typeSet,
+	hash_table.ObjTypeCMS:     typeCMS,
+	hash_table.ObjTypeList:    typeList,
+	hash_table.ObjTypeHash:    typeHash,
+	hash_table.ObjTypeBloom:   typeBloom,
+	hash_table.ObjTypeCuckoo:  typeCuckoo,
+	hash_table.ObjTypeTopK:    typeTopK,
+	hash_table.ObjTypeTDigest: typeTDigest,
+}
+
+var encodingNames = map[hash_table.ObjEncoding]string{
+	hash_table.ObjEncodingRaw:       "raw",
+	hash_table.ObjEncodingInt:       "int",
+	hash_table.ObjEncodingHT:        "hashtable",
+	hash_table.ObjEncodingBTree:     "btree",
+	hash_table.ObjEncodingSkiplist:  "skiplist",
+	hash_table.ObjEncodingQuicklist: "quicklist",
+	hash_table.ObjEncodingIntset:    "intset",
+}
+
+// objectEncoding returns the name of the encoding of obj. A set converts itself from intset to
+// hash table as it grows, so its encoding is refreshed from the value first.
+func objectEncoding(obj *hash_table.Obj) string {
+	if set, ok := obj.Value.(*simple_set.SimpleSet); ok && !set.IsIntset() {
+		obj.Encoding = hash_table.ObjEncodingHT
+	}
+	return encodingNames[obj.Encoding]
+}
+
+// keyType returns the name of the type stored at key as reported by TYPE, "none" if there is no such key.
+func (s *Storage) keyType(key string) string {
+	obj := s.dictStore.Get(key)
+	if obj == nil {
+		return typeNone
+	}
+	return typeNames[obj.Type]
+}
+
+func (s *Storage) exists(key string) bool {
+	return s.dictStore.Get(key) != nil
+}
+
+// keys returns every key of the storage. Expired keys are skipped.
+func (s *Storage) keys() []string {
+	var keys []string
+	for key := range s.dictStore.GetDictStore() {
+		if !s.dictStore.HasExpired(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// deleteKey removes key and reports whether it existed.
+func (s *Storage) deleteKey(key string) bool {
+	return s.exists(key) && s.dictStore.Del(key)
+}
+
+// moveKey moves the value and TTL of key to dstKey in dst, replacing whatever dstKey holds.
+func (s *Storage) moveKey(key string, dst *Storage, dstKey string) {
+	if dst == s && key == dstKey {
+		return
+	}
+	obj := s.dictStore.Get(key)
+	if obj == nil {
+		return
+	}
+	exp, hasExpiry := s.dictStore.GetExpiry(key)
+	s.dictStore.Del(key)
+	dst.dictStore.Del(dstKey)
+	dst.dictStore.Set(dstKey, obj)
+	if hasExpiry {
+		dst.dictStore.SetExpiryAt(dstKey, exp)
+	}
+	if _, ok := s.fieldExpiryKeys[key]; ok {
+		dst.fieldExpiryKeys[dstKey] = struct{}{}
+	}
+}
+
+func (s *Storage) flush() {
+	s.dictStore.Flush()
+	clear(s.fieldExpiryKeys)
+}
+
+func (s *Storage) cmdDEL(args []string) []byte {
+	count := 0
+	for _, key := range args {
+		if s.deleteKey(key) {
+			count++
+		}
+	}
+	return Encode(count, false)
+}
+
+func (s *Storage) cmdEXISTS(args []string) []byte {
+	count := 0
+	for _, key := range args {
+		if s.exists(key) {
+			count++
+		}
+	}
+	return Encode(count, false)
+}
+
+func (s *Storage) cmdTYPE(args []string) []byte {
+	return Encode(s.keyType(args[0]), true)
+}
+
+func (s *Storage) cmdOBJECT(args []string) []byte {
+	switch strings.ToUpper(args[0]) {
+	case "ENCODING":
+		if len(args) != 2 {
+			return errWrongNumberOfArgs("object|encoding")
+		}
+		obj := s.dictStore.Get(args[1])
+		if obj == nil {
+			return constant.RespNil
+		}
+		return Encode(objectEncoding(obj), false)
+	case "HELP":
+		return Encode([]string{
+			"OBJECT <subcommand> [<arg> [value] [opt] ...]. Subcommands are:",
+			"ENCODING <key>",
+			"    Return the kind of internal representation used in order to store the value",
+			"    associated with a <key>.",
+			"HELP",
+			"    Print this help.",
+		}, false)
+	}
+	return Encode(fmt.Errorf("ERR unknown subcommand '%s'. Try OBJECT HELP.", args[0]), false)
+}
+
+func (s *Storage) cmdRENAME(args []string) []byte {
+	key, newKey := args[0], args[1]
+	if !s.exists(key) {
+		return Encode(errNoSuchKey, false)
+	}
+	s.moveKey(key, s, newKey)
+	return constant.RespOk
+}
+
+func (s *Storage) cmdRENAMENX(args []string) []byte {
+	key, newKey := args[0], args[1]
+	if !s.exists(key) {
+		return Encode(errNoSuchKey, false)
+	}
+	if s.exists(newKey) {
+		return constant.RespZero
+	}
+	s.moveKey(key, s, newKey)
+	return constant.RespOne
+}
+
+func (s *Storage) cmdKEYS(args []string) []byte {
+	pattern := args[0]
+	res := []string{}
+	for _, key := range s.keys() {
+		if matchGlob(pattern, key) {
+			res = append(res, key)
+		}
+	}
+	sort.Strings(res)
+	return Encode(res, false)
+}
+
+// scanOptions holds the optional arguments shared by SCAN, SSCAN, ZSCAN and HSCAN.
+type scanOptions struct {
+	cursor  uint64
+	pattern string
+	count   int
+	typ     string
+}
+
+func parseScanOptions(args []string, allowType bool) (*scanOptions, error) {
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("ERR invalid cursor")
+	}
+	opts := &scanOptions{cursor: cursor, count: 10}
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return nil, errors.New("ERR syntax error")
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			opts.pattern = args[i+1]
+		case "COUNT":
+			count, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return nil, errors.New("ERR value is not an integer or out of range")
+			}
+			if count < 1 {
+				return nil, errors.New("ERR syntax error")
+			}
+			opts.count = count
+		case "TYPE":
+			if !allowType {
+				return nil, errors.New("ERR syntax error")
+			}
+			opts.typ = args[i+1]
+		default:
+			return nil, errors.New("ERR syntax error")
+		}
+	}
+	return opts, nil
+}
+
+// scanReply encodes a SCAN family reply: the next cursor followed by the batch.
+func scanReply(cursor uint64, batch []string) []byte {
+	return Encode([]interface{}{strconv.FormatUint(cursor, 10), batch}, false)
+}
+
+func (s *Storage) cmdSCAN(args []string) []byte {
+	opts, err := parseScanOptions(args, true)
+	if err != nil {
+		return Encode(err, false)
+	}
+	batch, next := s.dictStore.Scan(opts.cursor, opts.count)
+	res := []string{}
+	for _, key := range batch {
+		if opts.pattern != "" && !matchGlob(opts.pattern, key) {
+			continue
+		}
+		if opts.typ != "" && !strings.EqualFold(opts.typ, s.keyType(key)) {
+			continue
+		}
+		res = append(res, key)
+	}
+	return scanReply(next, res)
+}
+
+func (s *Storage) cmdRANDOMKEY(args []string) []byte {
+	key, ok := s.dictStore.RandomKey()
+	if !ok {
+		return constant.RespNil
+	}
+	return Encode(key, false)
+}
+
+func (s *Storage) cmdDBSIZE(args []string) []byte {
+	return Encode(s.dictStore.Len(), false)
+}
+
+func (s *Storage) cmdFLUSHDB(args []string) []byte {
+	if len(args) > 1 {
+		return Encode(errors.New("ERR syntax error"), false)
+	}
+	if len(args) == 1 {
+		mode := strings.ToUpper(args[0])
+		if mode != "ASYNC" && mode != "SYNC" {
+			return Encode(errors.New("ERR syntax error"), false)
+		}
+	}
+	s.flush()
+	return constant.RespOk
+}
@@ -0,0 +1,136 @@
+package core_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyspaceCommands(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "SET", "str", "v")
+	execute(s, "SADD", "set", "a")
+	execute(s, "ZADD", "zset", "1", "a")
+	execute(s, "CMS.INITBYDIM", "cms", "10", "2")
+
+	assert.EqualValues(t, "+string\r\n", execute(s, "TYPE", "str"))
+	assert.EqualValues(t, "+set\r\n", execute(s, "TYPE", "set"))
+	assert.EqualValues(t, "+zset\r\n", execute(s, "TYPE", "zset"))
+	assert.EqualValues(t, "+none\r\n", execute(s, "TYPE", "missing"))
+	assert.EqualValues(t, ":4\r\n", execute(s, "DBSIZE"))
+	assert.EqualValues(t, ":2\r\n", execute(s, "EXISTS", "str", "set", "missing"))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "RENAME", "set", "set2"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "SISMEMBER", "set2", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "RENAMENX", "set2", "str"))
+	assert.EqualValues(t, "-ERR no such key\r\n", execute(s, "RENAME", "set", "x"))
+
+	assert.EqualValues(t, ":2\r\n", execute(s, "DEL", "str", "set2", "missing"))
+	assert.EqualValues(t, "+OK\r\n", execute(s, "FLUSHDB"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "DBSIZE"))
+}
+
+func TestKeysGlobPatterns(t *testing.T) {
+	s := core.NewStorage()
+	for _, key := range []string{"hello", "hallo", "hxllo", "hllo", "heeeello", "h*llo"} {
+		execute(s, "SET", key, "v")
+	}
+	assert.EqualValues(t, "*4\r\n$5\r\nh*llo\r\n$5\r\nhallo\r\n$5\r\nhello\r\n$5\r\nhxllo\r\n", execute(s, "KEYS", "h?llo"))
+	assert.EqualValues(t, "*2\r\n$5\r\nhallo\r\n$5\r\nhello\r\n", execute(s, "KEYS", "h[ae]llo"))
+	assert.EqualValues(t, "*2\r\n$5\r\nh*llo\r\n$5\r\nhxllo\r\n", execute(s, "KEYS", "h[^ae]llo"))
+	assert.EqualValues(t, "*2\r\n$5\r\nhallo\r\n$5\r\nhello\r\n", execute(s, "KEYS", "h[a-e]llo"))
+	assert.EqualValues(t, "*1\r\n$5\r\nh*llo\r\n", execute(s, "KEYS", "h\\*llo"))
+	assert.EqualValues(t, "*6\r\n", execute(s, "KEYS", "*")[:4])
+}
+
+func TestScanOptions(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "SET", "a", "v")
+	execute(s, "SADD", "b", "x")
+	assert.EqualValues(t, "*2\r\n$1\r\n0\r\n*1\r\n$1\r\nb\r\n", execute(s, "SCAN", "0", "TYPE", "set"))
+	assert.EqualValues(t, "*2\r\n$1\r\n0\r\n*1\r\n$1\r\na\r\n", execute(s, "SCAN", "0", "MATCH", "a*", "COUNT", "100"))
+	assert.EqualValues(t, "-ERR invalid cursor\r\n", execute(s, "SCAN", "x"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "SCAN", "0", "COUNT"))
+}
+
+func TestObjectEncoding(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "SET", "int", "42")
+	execute(s, "SET", "str", "hello")
+	execute(s, "RPUSH", "list", "a")
+	execute(s, "HSET", "hash", "f", "v")
+	execute(s, "ZADD", "zset", "1", "a")
+	execute(s, "SADD", "ids", "1", "2", "3")
+	execute(s, "SADD", "names", "a")
+
+	assert.EqualValues(t, "$3\r\nint\r\n", execute(s, "OBJECT", "ENCODING", "int"))
+	assert.EqualValues(t, "$3\r\nraw\r\n", execute(s, "OBJECT", "ENCODING", "str"))
+	assert.EqualValues(t, "$9\r\nquicklist\r\n", execute(s, "OBJECT", "ENCODING", "list"))
+	assert.EqualValues(t, "$9\r\nhashtable\r\n", execute(s, "OBJECT", "ENCODING", "hash"))
+	assert.EqualValues(t, "$5\r\nbtree\r\n", execute(s, "OBJECT", "encoding", "zset"))
+	assert.EqualValues(t, "$6\r\nintset\r\n", execute(s, "OBJECT", "ENCODING", "ids"))
+	assert.EqualValues(t, "$9\r\nhashtable\r\n", execute(s, "OBJECT", "ENCODING", "names"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "OBJECT", "ENCODING", "missing"))
+
+	// A set converts to a hash table once a member is not an integer, and stays one
+	execute(s, "SADD", "ids", "x")
+	execute(s, "SREM", "ids", "x")
+	assert.EqualValues(t, "$9\r\nhashtable\r\n", execute(s, "OBJECT", "ENCODING", "ids"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "SCARD", "ids"))
+
+	assert.EqualValues(t, "-ERR unknown subcommand 'FREQ'. Try OBJECT HELP.\r\n", execute(s, "OBJECT", "FREQ", "ids"))
+	assert.EqualValues(t, "-ERR wrong number of arguments for 'object|encoding' command\r\n", execute(s, "OBJECT", "ENCODING"))
+}
+
+// scanAll runs a SCAN family command from cursor 0 to the end and returns every element it replied with.
+// args are the arguments following the cursor, cmd the command and key its key, if any.
+func scanAll(t *testing.T, s *core.Storage, cmd string, key string, args ...string) []string {
+	var res []string
+	cursor := "0"
+	for {
+		cmdArgs := append([]string{cursor}, args...)
+		if key != "" {
+			cmdArgs = append([]string{key}, cmdArgs...)
+		}
+		value, err := core.Decode([]byte(execute(s, cmd, cmdArgs...)))
+		assert.NoError(t, err)
+		reply := value.([]interface{})
+		batch := reply[1].([]interface{})
+		// A batch holds about COUNT elements, not the whole collection
+		assert.Less(t, len(batch), 40)
+		for _, element := range batch {
+			res = append(res, element.(string))
+		}
+		cursor = reply[0].(string)
+		if cursor == "0" {
+			return res
+		}
+	}
+}
+
+func TestScanIteration(t *testing.T) {
+	s := core.NewStorage()
+	var keys, members []string
+	for i := 0; i < 500; i++ {
+		keys = append(keys, "key:"+strconv.Itoa(i))
+		members = append(members, "m"+strconv.Itoa(i))
+		execute(s, "SET", keys[i], "v")
+		execute(s, "SADD", "set", members[i])
+		execute(s, "HSET", "hash", members[i], "v")
+	}
+
+	assert.ElementsMatch(t, append(keys, "set", "hash"), scanAll(t, s, "SCAN", "", "COUNT", "10"))
+	assert.ElementsMatch(t, members, scanAll(t, s, "SSCAN", "set", "COUNT", "10"))
+
+	var fields []string
+	for _, member := range members {
+		fields = append(fields, member, "v")
+	}
+	assert.ElementsMatch(t, fields, scanAll(t, s, "HSCAN", "hash", "COUNT", "5"))
+
+	assert.EqualValues(t, ":502\r\n", execute(s, "DBSIZE"))
+	value, _ := core.Decode([]byte(execute(s, "RANDOMKEY")))
+	assert.Contains(t, append(keys, "set", "hash"), value)
+}
@@ -0,0 +1,507 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/quicklist"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "LPUSH", Handler: (*Storage).cmdLPUSH, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key element [element ...]", Summary: "Prepend elements to a list"},
+		&CommandSpec{Name: "RPUSH", Handler: (*Storage).cmdRPUSH, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key element [element ...]", Summary: "Append elements to a list"},
+		&CommandSpec{Name: "LPUSHX", Handler: (*Storage).cmdLPUSHX, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key element [element ...]", Summary: "Prepend elements to a list only when the list exists"},
+		&CommandSpec{Name: "RPUSHX", Handler: (*Storage).cmdRPUSHX, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key element [element ...]", Summary: "Append elements to a list only when the list exists"},
+		&CommandSpec{Name: "LPOP", Handler: (*Storage).cmdLPOP, Arity: -2, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key [count]", Summary: "Remove and return the first elements of a list"},
+		&CommandSpec{Name: "RPOP", Handler: (*Storage).cmdRPOP, Arity: -2, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key [count]", Summary: "Remove and return the last elements of a list"},
+		&CommandSpec{Name: "LLEN", Handler: (*Storage).cmdLLEN, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key", Summary: "Get the length of a list"},
+		&CommandSpec{Name: "LRANGE", Handler: (*Storage).cmdLRANGE, Arity: 4, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key start stop", Summary: "Get a range of elements from a list"},
+		&CommandSpec{Name: "LINDEX", Handler: (*Storage).cmdLINDEX, Arity: 3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key index", Summary: "Get an element from a list by its index"},
+		&CommandSpec{Name: "LSET", Handler: (*Storage).cmdLSET, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key index element", Summary: "Set the value of an element in a list by its index"},
+		&CommandSpec{Name: "LINSERT", Handler: (*Storage).cmdLINSERT, Arity: 5, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key BEFORE | AFTER pivot element", Summary: "Insert an element before or after another element in a list"},
+		&CommandSpec{Name: "LREM", Handler: (*Storage).cmdLREM, Arity: 4, Flags: []string{FlagWrite}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key count element", Summary: "Remove elements from a list"},
+		&CommandSpec{Name: "LTRIM", Handler: (*Storage).cmdLTRIM, Arity: 4, Flags: []string{FlagWrite}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key start stop", Summary: "Trim a list to the specified range"},
+		&CommandSpec{Name: "LPOS", Handler: (*Storage).cmdLPOS, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "list", Syntax: "key element [RANK rank] [COUNT num-matches] [MAXLEN len]", Summary: "Return the index of matching elements in a list"},
+		&CommandSpec{Name: "LMOVE", Handler: (*Storage).cmdLMOVE, Arity: 5, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 2, Step: 1, Group: "list", Syntax: "source destination LEFT | RIGHT LEFT | RIGHT", Summary: "Pop an element from a list, push it to another list and return it"},
+		&CommandSpec{Name: "BLPOP", Handler: (*Storage).cmdBLPOP, Arity: -3, Flags: []string{FlagWrite, FlagBlocking}, FirstKey: 1, LastKey: -2, Step: 1, Group: "list", Syntax: "key [key ...] timeout", Summary: "Remove and return the first element of the first non-empty list, or block until one is available"},
+		&CommandSpec{Name: "BRPOP", Handler: (*Storage).cmdBRPOP, Arity: -3, Flags: []string{FlagWrite, FlagBlocking}, FirstKey: 1, LastKey: -2, Step: 1, Group: "list", Syntax: "key [key ...] timeout", Summary: "Remove and return the last element of the first non-empty list, or block until one is available"},
+		&CommandSpec{Name: "BLMOVE", Handler: (*Storage).cmdBLMOVE, Arity: 6, Flags: []string{FlagWrite, FlagDenyOOM, FlagBlocking}, FirstKey: 1, LastKey: 2, Step: 1, Group: "list", Syntax: "source destination LEFT | RIGHT LEFT | RIGHT timeout", Summary: "Pop an element from a list, push it to another list and return it, or block until one is available"},
+		&CommandSpec{Name: "BRPOPLPUSH", Handler: (*Storage).cmdBRPOPLPUSH, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM, FlagBlocking}, FirstKey: 1, LastKey: 2, Step: 1, Group: "list", Syntax: "source destination timeout", Summary: "Pop the last element of a list, push it to another list and return it, or block until one is available"},
+		&CommandSpec{Name: "RPOPLPUSH", Handler: (*Storage).cmdRPOPLPUSH, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 2, Step: 1, Group: "list", Syntax: "source destination", Summary: "Pop the last element of a list, push it to another list and return it"},
+	)
+}
+
+var errNoSuchKey = errors.New("ERR no such key")
+
+// listRange converts the start and stop arguments of LRANGE and LTRIM, or the ranks of ZRANGE,
+// which may be negative to count from the end, to indexes in [0, n). ok is false when the range is empty.
+func listRange(startArg, stopArg string, n int) (start, stop int, ok bool, err error) {
+	start, err = strconv.Atoi(startArg)
+	if err != nil {
+		return 0, 0, false, errNotInteger
+	}
+	stop, err = strconv.Atoi(stopArg)
+	if err != nil {
+		return 0, 0, false, errNotInteger
+	}
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop || start >= n {
+		return 0, 0, false, nil
+	}
+	return start, stop, true, nil
+}
+
+// pushList adds elements to the head or tail of the list at key, creating it unless onlyExisting is set.
+func (s *Storage) pushList(args []string, head, onlyExisting bool) []byte {
+	key := args[0]
+	list, err := s.lookupList(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if list == nil {
+		if onlyExisting {
+			return constant.RespZero
+		}
+		list = quicklist.NewQuickList(constant.ListMaxNodeSize)
+		s.addObj(key, hash_table.ObjTypeList, hash_table.ObjEncodingQuicklist, list)
+	}
+	if head {
+		list.PushHead(args[1:]...)
+	} else {
+		list.PushTail(args[1:]...)
+	}
+	return Encode(list.Len(), false)
+}
+
+func (s *Storage) cmdLPUSH(args []string) []byte {
+	return s.pushList(args, true, false)
+}
+
+func (s *Storage) cmdRPUSH(args []string) []byte {
+	return s.pushList(args, false, false)
+}
+
+func (s *Storage) cmdLPUSHX(args []string) []byte {
+	return s.pushList(args, true, true)
+}
+
+func (s *Storage) cmdRPUSHX(args []string) []byte {
+	return s.pushList(args, false, true)
+}
+
+// deleteIfEmptyList removes key once its list has no element left, lists are never stored empty.
+func (s *Storage) deleteIfEmptyList(key string, list *quicklist.QuickList) {
+	if list.Len() == 0 {
+		s.dictStore.Del(key)
+	}
+}
+
+// popList removes one element, or count elements when given, from the head or tail of a list.
+func (s *Storage) popList(args []string, head bool) []byte {
+	if len(args) > 2 {
+		return Encode(errSyntax, false)
+	}
+	key := args[0]
+	count := -1
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return Encode(errors.New("ERR value is out of range, must be positive"), false)
+		}
+		count = n
+	}
+	list, err := s.lookupList(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if list == nil {
+		if count >= 0 {
+			return constant.RespNilArray
+		}
+		return constant.RespNil
+	}
+
+	pop := list.PopTail
+	if head {
+		pop = list.PopHead
+	}
+	if count < 0 {
+		v, _ := pop()
+		s.deleteIfEmptyList(key, list)
+		return Encode(v, false)
+	}
+	res := make([]string, 0, min(count, list.Len()))
+	for len(res) < count {
+		v, ok := pop()
+		if !ok {
+			break
+		}
+		res = append(res, v)
+	}
+	s.deleteIfEmptyList(key, list)
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdLPOP(args []string) []byte {
+	return s.popList(args, true)
+}
+
+func (s *Storage) cmdRPOP(args []string) []byte {
+	return s.popList(args, false)
+}
+
+func (s *Storage) cmdLLEN(args []string) []byte {
+	list, err := s.lookupList(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if list == nil {
+		return constant.RespZero
+	}
+	return Encode(list.Len(), false)
+}
+
+func (s *Storage) cmdLRANGE(args []string) []byte {
+	list, err := s.lookupList(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	n := 0
+	if list != nil {
+		n = list.Len()
+	}
+	start, stop, ok, err := listRange(args[1], args[2], n)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if !ok {
+		return constant.RespEmptyArray
+	}
+	return Encode(list.Range(start, stop), false)
+}
+
+// listIndex converts an index that may count from the end of the list to one from the head.
+func listIndex(arg string, n int) (int, error) {
+	index, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, errNotInteger
+	}
+	if index < 0 {
+		index += n
+	}
+	return index, nil
+}
+
+func (s *Storage) cmdLINDEX(args []string) []byte {
+	list, err := s.lookupList(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if list == nil {
+		if _, err := strconv.Atoi(args[1]); err != nil {
+			return Encode(errNotInteger, false)
+		}
+		return constant.RespNil
+	}
+	index, err := listIndex(args[1], list.Len())
+	if err != nil {
+		return Encode(err, false)
+	}
+	v, ok := list.Index(index)
+	if !ok {
+		return constant.RespNil
+	}
+	return Encode(v, false)
+}
+
+func (s *Storage) cmdLSET(args []string) []byte {
+	list, err := s.lookupList(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if list == nil {
+		return Encode(errNoSuchKey, false)
+	}
+	index, err := listIndex(args[1], list.Len())
+	if err != nil {
+		return Encode(err, false)
+	}
+	if !list.Set(index, args[2]) {
+		return Encode(errors.New("ERR index out of range"), false)
+	}
+	return constant.RespOk
+}
+
+func (s *Storage) cmdLINSERT(args []string) []byte {
+	var after bool
+	switch strings.ToUpper(args[1]) {
+	case "BEFORE":
+		after = false
+	case "AFTER":
+		after = true
+	default:
+		return Encode(errSyntax, false)
+	}
+	list, err := s.lookupList(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if list == nil {
+		return constant.RespZero
+	}
+	return Encode(list.Insert(args[2], args[3], after), false)
+}
+
+func (s *Storage) cmdLREM(args []string) []byte {
+	key := args[0]
+	count, err := strconv.Atoi(args[1])
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	list, err := s.lookupList(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if list == nil {
+		return constant.RespZero
+	}
+	removed := list.Remove(count, args[2])
+	s.deleteIfEmptyList(key, list)
+	return Encode(removed, false)
+}
+
+func (s *Storage) cmdLTRIM(args []string) []byte {
+	key := args[0]
+	list, err := s.lookupList(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	n := 0
+	if list != nil {
+		n = list.Len()
+	}
+	start, stop, ok, err := listRange(args[1], args[2], n)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if list == nil {
+		return constant.RespOk
+	}
+	if !ok {
+		start, stop = 1, 0
+	}
+	list.Trim(start, stop)
+	s.deleteIfEmptyList(key, list)
+	return constant.RespOk
+}
+
+func (s *Storage) cmdLPOS(args []string) []byte {
+	key, element := args[0], args[1]
+	rank, count, maxLen := 1, -1, 0
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return Encode(errSyntax, false)
+		}
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			return Encode(errNotInteger, false)
+		}
+		switch strings.ToUpper(args[i]) {
+		case "RANK":
+			if n == 0 {
+				return Encode(errors.New("ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list"), false)
+			}
+			rank = n
+		case "COUNT":
+			if n < 0 {
+				return Encode(errors.New("ERR COUNT can't be negative"), false)
+			}
+			count = n
+		case "MAXLEN":
+			if n < 0 {
+				return Encode(errors.New("ERR MAXLEN can't be negative"), false)
+			}
+			maxLen = n
+		default:
+			return Encode(errSyntax, false)
+		}
+	}
+
+	list, err := s.lookupList(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	var matches []int
+	if list != nil {
+		skip := rank - 1
+		if rank < 0 {
+			skip = -rank - 1
+		}
+		scanned := 0
+		// Walk from the end RANK starts from, until MAXLEN elements or COUNT matches
+		for i, value := range list.Elements(rank < 0) {
+			if maxLen > 0 && scanned == maxLen {
+				break
+			}
+			scanned++
+			if value != element {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			matches = append(matches, i)
+			if count < 0 || (count > 0 && len(matches) == count) {
+				break
+			}
+		}
+	}
+
+	if count < 0 {
+		if len(matches) == 0 {
+			return constant.RespNil
+		}
+		return Encode(matches[0], false)
+	}
+	res := make([]interface{}, len(matches))
+	for i, m := range matches {
+		res[i] = m
+	}
+	return Encode(res, false)
+}
+
+// moveList pops an element from one end of src and pushes it to one end of dst.
+func (s *Storage) moveList(src, dst string, fromHead, toHead bool) []byte {
+	srcList, err := s.lookupList(src)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if srcList == nil {
+		return constant.RespNil
+	}
+	if _, err := s.lookupList(dst); err != nil {
+		return Encode(err, false)
+	}
+
+	var v string
+	if fromHead {
+		v, _ = srcList.PopHead()
+	} else {
+		v, _ = srcList.PopTail()
+	}
+	s.deleteIfEmptyList(src, srcList)
+	// Looked up after the pop: when src is dst, its only element may just have been popped
+	dstList, _ := s.lookupList(dst)
+	if dstList == nil {
+		dstList = quicklist.NewQuickList(constant.ListMaxNodeSize)
+		s.addObj(dst, hash_table.ObjTypeList, hash_table.ObjEncodingQuicklist, dstList)
+	}
+	if toHead {
+		dstList.PushHead(v)
+	} else {
+		dstList.PushTail(v)
+	}
+	return Encode(v, false)
+}
+
+func parseListSide(arg string) (head bool, err error) {
+	switch strings.ToUpper(arg) {
+	case "LEFT":
+		return true, nil
+	case "RIGHT":
+		return false, nil
+	}
+	return false, errSyntax
+}
+
+func (s *Storage) cmdLMOVE(args []string) []byte {
+	fromHead, err := parseListSide(args[2])
+	if err != nil {
+		return Encode(err, false)
+	}
+	toHead, err := parseListSide(args[3])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return s.moveList(args[0], args[1], fromHead, toHead)
+}
+
+func (s *Storage) cmdRPOPLPUSH(args []string) []byte {
+	return s.moveList(args[0], args[1], false, true)
+}
+
+// blockingPop pops from the first non-empty list among keys. It returns nil when they are all empty.
+func (s *Storage) blockingPop(args []string, head bool) []byte {
+	keys := args[:len(args)-1]
+	if _, err := parseBlockTimeout(args[len(args)-1]); err != nil {
+		return Encode(err, false)
+	}
+	for _, key := range keys {
+		list, err := s.lookupList(key)
+		if err != nil {
+			return Encode(err, false)
+		}
+		if list == nil {
+			continue
+		}
+		var v string
+		if head {
+			v, _ = list.PopHead()
+		} else {
+			v, _ = list.PopTail()
+		}
+		s.deleteIfEmptyList(key, list)
+		return Encode([]string{key, v}, false)
+	}
+	return nil
+}
+
+func (s *Storage) cmdBLPOP(args []string) []byte {
+	return s.blockingPop(args, true)
+}
+
+func (s *Storage) cmdBRPOP(args []string) []byte {
+	return s.blockingPop(args, false)
+}
+
+// blockingMove moves an element like LMOVE. It returns nil when the source list is empty.
+func (s *Storage) blockingMove(src, dst string, fromHead, toHead bool, timeout string) []byte {
+	if _, err := parseBlockTimeout(timeout); err != nil {
+		return Encode(err, false)
+	}
+	list, err := s.lookupList(src)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if list == nil {
+		return nil
+	}
+	return s.moveList(src, dst, fromHead, toHead)
+}
+
+func (s *Storage) cmdBLMOVE(args []string) []byte {
+	fromHead, err := parseListSide(args[2])
+	if err != nil {
+		return Encode(err, false)
+	}
+	toHead, err := parseListSide(args[3])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return s.blockingMove(args[0], args[1], fromHead, toHead, args[4])
+}
+
+func (s *Storage) cmdBRPOPLPUSH(args []string) []byte {
+	return s.blockingMove(args[0], args[1], false, true, args[2])
+}
@@ -0,0 +1,95 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListPushPop(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":3\r\n", execute(s, "RPUSH", "l", "a", "b", "c"))
+	assert.EqualValues(t, ":5\r\n", execute(s, "LPUSH", "l", "y", "z"))
+	assert.EqualValues(t, "*5\r\n$1\r\nz\r\n$1\r\ny\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n", execute(s, "LRANGE", "l", "0", "-1"))
+	assert.EqualValues(t, "+list\r\n", execute(s, "TYPE", "l"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "LPUSHX", "missing", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "missing"))
+
+	assert.EqualValues(t, "$1\r\nz\r\n", execute(s, "LPOP", "l"))
+	assert.EqualValues(t, "*2\r\n$1\r\nc\r\n$1\r\nb\r\n", execute(s, "RPOP", "l", "2"))
+	assert.EqualValues(t, "*2\r\n$1\r\ny\r\n$1\r\na\r\n", execute(s, "LPOP", "l", "10"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "l"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "LPOP", "l"))
+	assert.EqualValues(t, "*-1\r\n", execute(s, "LPOP", "l", "1"))
+	assert.EqualValues(t, "-ERR value is out of range, must be positive\r\n", execute(s, "LPOP", "l", "-1"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "LPUSH", "str", "a"))
+	assert.EqualValues(t, wrongType, execute(s, "LLEN", "str"))
+}
+
+func TestListIndexing(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "RPUSH", "l", "a", "b", "c", "d")
+	assert.EqualValues(t, ":4\r\n", execute(s, "LLEN", "l"))
+	assert.EqualValues(t, "$1\r\nd\r\n", execute(s, "LINDEX", "l", "-1"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "LINDEX", "l", "4"))
+	assert.EqualValues(t, "*2\r\n$1\r\nb\r\n$1\r\nc\r\n", execute(s, "LRANGE", "l", "1", "-2"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "LRANGE", "l", "5", "10"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "LRANGE", "missing", "0", "-1"))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "LSET", "l", "-2", "x"))
+	assert.EqualValues(t, "-ERR index out of range\r\n", execute(s, "LSET", "l", "10", "x"))
+	assert.EqualValues(t, "-ERR no such key\r\n", execute(s, "LSET", "missing", "0", "x"))
+
+	assert.EqualValues(t, ":5\r\n", execute(s, "LINSERT", "l", "BEFORE", "x", "p"))
+	assert.EqualValues(t, ":-1\r\n", execute(s, "LINSERT", "l", "AFTER", "missing", "p"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "LINSERT", "missing", "AFTER", "a", "p"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "LINSERT", "l", "MIDDLE", "a", "p"))
+	assert.EqualValues(t, "*5\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\np\r\n$1\r\nx\r\n$1\r\nd\r\n", execute(s, "LRANGE", "l", "0", "-1"))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "LTRIM", "l", "1", "-2"))
+	assert.EqualValues(t, "*3\r\n$1\r\nb\r\n$1\r\np\r\n$1\r\nx\r\n", execute(s, "LRANGE", "l", "0", "-1"))
+	assert.EqualValues(t, "+OK\r\n", execute(s, "LTRIM", "l", "5", "10"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "l"))
+}
+
+func TestListRemAndPos(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "RPUSH", "l", "a", "b", "a", "c", "a")
+	assert.EqualValues(t, ":2\r\n", execute(s, "LPOS", "l", "a", "RANK", "2"))
+	assert.EqualValues(t, ":2\r\n", execute(s, "LPOS", "l", "a", "RANK", "-2"))
+	assert.EqualValues(t, "*3\r\n:0\r\n:2\r\n:4\r\n", execute(s, "LPOS", "l", "a", "COUNT", "0"))
+	assert.EqualValues(t, "*1\r\n:0\r\n", execute(s, "LPOS", "l", "a", "COUNT", "2", "MAXLEN", "2"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "LPOS", "l", "x"))
+	assert.EqualValues(t, "-ERR COUNT can't be negative\r\n", execute(s, "LPOS", "l", "a", "COUNT", "-1"))
+
+	assert.EqualValues(t, ":1\r\n", execute(s, "LREM", "l", "-1", "a"))
+	assert.EqualValues(t, ":2\r\n", execute(s, "LREM", "l", "0", "a"))
+	assert.EqualValues(t, "*2\r\n$1\r\nb\r\n$1\r\nc\r\n", execute(s, "LRANGE", "l", "0", "-1"))
+	execute(s, "LREM", "l", "0", "b")
+	execute(s, "LREM", "l", "0", "c")
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "l"))
+}
+
+func TestListMove(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "RPUSH", "src", "a", "b")
+	assert.EqualValues(t, "$1\r\nb\r\n", execute(s, "RPOPLPUSH", "src", "dst"))
+	assert.EqualValues(t, "$1\r\na\r\n", execute(s, "LMOVE", "src", "dst", "LEFT", "RIGHT"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "src"))
+	assert.EqualValues(t, "*2\r\n$1\r\nb\r\n$1\r\na\r\n", execute(s, "LRANGE", "dst", "0", "-1"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "LMOVE", "src", "dst", "LEFT", "LEFT"))
+
+	// Rotating a list onto itself keeps it, even with a single element.
+	assert.EqualValues(t, "$1\r\nb\r\n", execute(s, "LMOVE", "dst", "dst", "LEFT", "RIGHT"))
+	execute(s, "RPUSH", "one", "x")
+	assert.EqualValues(t, "$1\r\nx\r\n", execute(s, "RPOPLPUSH", "one", "one"))
+	assert.EqualValues(t, "*1\r\n$1\r\nx\r\n", execute(s, "LRANGE", "one", "0", "-1"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "LMOVE", "dst", "str", "LEFT", "LEFT"))
+	assert.EqualValues(t, ":2\r\n", execute(s, "LLEN", "dst"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "LMOVE", "dst", "src", "UP", "LEFT"))
+}
@@ -2,58 +2,453 @@ package core
 
 import (
 	"errors"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
 	data_structure "github.com/spaghetti-lover/multithread-redis/internal/data_structure/simple_set"
 )
 
-func cmdSADD(args []string) []byte {
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "SADD", Handler: (*Storage).cmdSADD, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "set", Syntax: "key member [member ...]", Summary: "Add members to a set"},
+		&CommandSpec{Name: "SREM", Handler: (*Storage).cmdSREM, Arity: -3, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "set", Syntax: "key member [member ...]", Summary: "Remove members from a set"},
+		&CommandSpec{Name: "SMEMBERS", Handler: (*Storage).cmdSMEMBERS, Arity: 2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "set", Syntax: "key", Summary: "Get all members of a set"},
+		&CommandSpec{Name: "SISMEMBER", Handler: (*Storage).cmdSISMEMBER, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "set", Syntax: "key member", Summary: "Check if a member belongs to a set"},
+		&CommandSpec{Name: "SMISMEMBER", Handler: (*Storage).cmdSMISMEMBER, Arity: -3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "set", Syntax: "key member [member ...]", Summary: "Check if members belong to a set"},
+		&CommandSpec{Name: "SCARD", Handler: (*Storage).cmdSCARD, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "set", Syntax: "key", Summary: "Get the number of members in a set"},
+		&CommandSpec{Name: "SPOP", Handler: (*Storage).cmdSPOP, Arity: -2, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Tips: []string{TipNondeterministicOutput}, Group: "set", Syntax: "key [count]", Summary: "Remove and return random members of a set"},
+		&CommandSpec{Name: "SRANDMEMBER", Handler: (*Storage).cmdSRANDMEMBER, Arity: -2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Tips: []string{TipNondeterministicOutput}, Group: "set", Syntax: "key [count]", Summary: "Get random members of a set"},
+		&CommandSpec{Name: "SMOVE", Handler: (*Storage).cmdSMOVE, Arity: 4, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 2, Step: 1, Group: "set", Syntax: "source destination member", Summary: "Move a member from a set to another"},
+		&CommandSpec{Name: "SUNION", Handler: (*Storage).cmdSUNION, Arity: -2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: -1, Step: 1, Tips: []string{TipNondeterministicOutputOrder}, Group: "set", Syntax: "key [key ...]", Summary: "Return the union of multiple sets"},
+		&CommandSpec{Name: "SINTER", Handler: (*Storage).cmdSINTER, Arity: -2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: -1, Step: 1, Tips: []string{TipNondeterministicOutputOrder}, Group: "set", Syntax: "key [key ...]", Summary: "Return the intersection of multiple sets"},
+		&CommandSpec{Name: "SDIFF", Handler: (*Storage).cmdSDIFF, Arity: -2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: -1, Step: 1, Tips: []string{TipNondeterministicOutputOrder}, Group: "set", Syntax: "key [key ...]", Summary: "Return the difference of the first set and the following ones"},
+		&CommandSpec{Name: "SUNIONSTORE", Handler: (*Storage).cmdSUNIONSTORE, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: -1, Step: 1, Group: "set", Syntax: "destination key [key ...]", Summary: "Store the union of multiple sets in a key"},
+		&CommandSpec{Name: "SINTERSTORE", Handler: (*Storage).cmdSINTERSTORE, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: -1, Step: 1, Group: "set", Syntax: "destination key [key ...]", Summary: "Store the intersection of multiple sets in a key"},
+		&CommandSpec{Name: "SDIFFSTORE", Handler: (*Storage).cmdSDIFFSTORE, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: -1, Step: 1, Group: "set", Syntax: "destination key [key ...]", Summary: "Store the difference of the first set and the following ones in a key"},
+		&CommandSpec{Name: "SINTERCARD", Handler: (*Storage).cmdSINTERCARD, Arity: -3, Flags: []string{FlagReadOnly, FlagMovableKeys}, KeysFunc: sinterCardKeys, Group: "set", Syntax: "numkeys key [key ...] [LIMIT limit]", Summary: "Return the number of members in the intersection of multiple sets"},
+		&CommandSpec{Name: "SSCAN", Handler: (*Storage).cmdSSCAN, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Tips: []string{TipNondeterministicOutput}, Group: "set", Syntax: "key cursor [MATCH pattern] [COUNT count]", Summary: "Incrementally iterate over the members of a set"},
+	)
+}
+
+func (s *Storage) cmdSADD(args []string) []byte {
 	if len(args) < 2 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'SADD' command"), false)
 	}
-	key := args[0] // TODO: check key is used by other types or not
-	set, exist := setStore[key]
-	if !exist {
+	key := args[0]
+	set, err := s.lookupSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if set == nil {
 		set = data_structure.NewSimpleSet(key)
-		setStore[key] = set
+		s.addObj(key, hash_table.ObjTypeSet, hash_table.ObjEncodingIntset, set)
 	}
 	count := set.Add(args[1:]...)
 	return Encode(count, false)
 }
 
-func cmdSREM(args []string) []byte {
+func (s *Storage) cmdSREM(args []string) []byte {
 	if len(args) < 2 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'SADD' command"), false)
 	}
 	key := args[0]
-	set, exist := setStore[key]
-	if !exist {
-		set = data_structure.NewSimpleSet(key)
-		setStore[key] = set
+	set, err := s.lookupSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if set == nil {
+		return Encode(0, false)
 	}
 	count := set.Rem(args[1:]...)
+	s.deleteIfEmptySet(key, set)
 	return Encode(count, false)
 }
 
-func cmdSMEMBERS(args []string) []byte {
+// deleteIfEmptySet removes the key of a set that lost its last member, sets are never stored empty.
+func (s *Storage) deleteIfEmptySet(key string, set *data_structure.SimpleSet) {
+	if set.Len() == 0 {
+		s.dictStore.Del(key)
+	}
+}
+
+func (s *Storage) cmdSMEMBERS(args []string) []byte {
 	if len(args) != 1 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'SMEMBERS' command"), false)
 	}
 	key := args[0]
-	set, exist := setStore[key]
-	if !exist {
+	set, err := s.lookupSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if set == nil {
 		return Encode(make([]string, 0), false)
 	}
 	return Encode(set.Members(), false)
 }
 
-func cmdSISMEMBER(args []string) []byte {
+func (s *Storage) cmdSISMEMBER(args []string) []byte {
 	if len(args) != 2 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'SISMEMBER' command"), false)
 	}
 	key := args[0]
-	set, exist := setStore[key]
-	if !exist {
+	set, err := s.lookupSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if set == nil {
 		return Encode(0, false)
 	}
 	return Encode(set.IsMember(args[1]), false)
 }
+
+func (s *Storage) cmdSMISMEMBER(args []string) []byte {
+	set, err := s.lookupSet(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, len(args)-1)
+	for i, member := range args[1:] {
+		res[i] = 0
+		if set != nil {
+			res[i] = set.IsMember(member)
+		}
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdSCARD(args []string) []byte {
+	set, err := s.lookupSet(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if set == nil {
+		return Encode(0, false)
+	}
+	return Encode(set.Len(), false)
+}
+
+func (s *Storage) cmdSPOP(args []string) []byte {
+	if len(args) > 2 {
+		return Encode(errSyntax, false)
+	}
+	key := args[0]
+	count := -1
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return Encode(errors.New("ERR value is out of range, must be positive"), false)
+		}
+		count = n
+	}
+	set, err := s.lookupSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if set == nil {
+		if count >= 0 {
+			return constant.RespEmptyArray
+		}
+		return constant.RespNil
+	}
+	if count < 0 {
+		member := set.RandomMember()
+		set.Rem(member)
+		s.deleteIfEmptySet(key, set)
+		return Encode(member, false)
+	}
+	members := set.Members()
+	rand.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+	popped := members[:min(count, len(members))]
+	set.Rem(popped...)
+	s.deleteIfEmptySet(key, set)
+	return Encode(popped, false)
+}
+
+func (s *Storage) cmdSRANDMEMBER(args []string) []byte {
+	if len(args) > 2 {
+		return Encode(errSyntax, false)
+	}
+	set, err := s.lookupSet(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if len(args) == 1 {
+		if set == nil {
+			return constant.RespNil
+		}
+		return Encode(set.RandomMember(), false)
+	}
+
+	count, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	if set == nil || count == 0 {
+		return constant.RespEmptyArray
+	}
+	members := set.Members()
+	if count > 0 {
+		// Distinct members, as many as the set has at most
+		rand.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+		return Encode(members[:min(int(count), len(members))], false)
+	}
+	// Exactly -count members, possibly repeated
+	if count < -math.MaxInt32 {
+		return Encode(errors.New("ERR value is out of range"), false)
+	}
+	picked := make([]string, -count)
+	for i := range picked {
+		picked[i] = members[rand.Intn(len(members))]
+	}
+	return Encode(picked, false)
+}
+
+func (s *Storage) cmdSMOVE(args []string) []byte {
+	src, dst, member := args[0], args[1], args[2]
+	srcSet, err := s.lookupSet(src)
+	if err != nil {
+		return Encode(err, false)
+	}
+	dstSet, err := s.lookupSet(dst)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if srcSet == nil || srcSet.IsMember(member) == 0 {
+		return Encode(0, false)
+	}
+	if src == dst {
+		return Encode(1, false)
+	}
+	srcSet.Rem(member)
+	s.deleteIfEmptySet(src, srcSet)
+	if dstSet == nil {
+		dstSet = data_structure.NewSimpleSet(dst)
+		s.addObj(dst, hash_table.ObjTypeSet, hash_table.ObjEncodingIntset, dstSet)
+	}
+	dstSet.Add(member)
+	return Encode(1, false)
+}
+
+type setOp int
+
+const (
+	setUnion setOp = iota
+	setInter
+	setDiff
+)
+
+// lookupSets returns the sets at keys, nil for the keys that do not exist.
+func (s *Storage) lookupSets(keys []string) ([]*data_structure.SimpleSet, error) {
+	sets := make([]*data_structure.SimpleSet, len(keys))
+	for i, key := range keys {
+		set, err := s.lookupSet(key)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+	return sets, nil
+}
+
+// setOpMembers computes the union, intersection or difference of the sets at keys.
+// A key that does not exist counts as an empty set.
+func (s *Storage) setOpMembers(op setOp, keys []string) ([]string, error) {
+	sets, err := s.lookupSets(keys)
+	if err != nil {
+		return nil, err
+	}
+	if sets[0] == nil && op != setUnion {
+		return nil, nil
+	}
+
+	var res []string
+	switch op {
+	case setUnion:
+		seen := make(map[string]struct{})
+		for _, set := range sets {
+			if set == nil {
+				continue
+			}
+			for _, member := range set.Members() {
+				if _, exist := seen[member]; !exist {
+					seen[member] = struct{}{}
+					res = append(res, member)
+				}
+			}
+		}
+	case setInter:
+		// Check the members of the smallest set against the others
+		slices.SortFunc(sets, func(a, b *data_structure.SimpleSet) int {
+			return setLen(a) - setLen(b)
+		})
+		if sets[0] == nil {
+			return nil, nil
+		}
+	inter:
+		for _, member := range sets[0].Members() {
+			for _, set := range sets[1:] {
+				if set.IsMember(member) == 0 {
+					continue inter
+				}
+			}
+			res = append(res, member)
+		}
+	case setDiff:
+	diff:
+		for _, member := range sets[0].Members() {
+			for _, set := range sets[1:] {
+				if set != nil && set.IsMember(member) == 1 {
+					continue diff
+				}
+			}
+			res = append(res, member)
+		}
+	}
+	return res, nil
+}
+
+// setLen returns the number of members of set, 0 for a missing one.
+func setLen(set *data_structure.SimpleSet) int {
+	if set == nil {
+		return 0
+	}
+	return set.Len()
+}
+
+func (s *Storage) setOpCommand(op setOp, keys []string) []byte {
+	members, err := s.setOpMembers(op, keys)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if members == nil {
+		members = []string{}
+	}
+	return Encode(members, false)
+}
+
+// setOpStore stores the result of op on args[1:] at args[0], replacing whatever it holds or deleting
+// it when the result is empty, and replies with the number of members stored.
+func (s *Storage) setOpStore(op setOp, args []string) []byte {
+	dst := args[0]
+	members, err := s.setOpMembers(op, args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	s.dictStore.Del(dst)
+	if len(members) > 0 {
+		set := data_structure.NewSimpleSet(dst)
+		set.Add(members...)
+		s.addObj(dst, hash_table.ObjTypeSet, hash_table.ObjEncodingIntset, set)
+	}
+	return Encode(len(members), false)
+}
+
+func (s *Storage) cmdSUNION(args []string) []byte {
+	return s.setOpCommand(setUnion, args)
+}
+
+func (s *Storage) cmdSINTER(args []string) []byte {
+	return s.setOpCommand(setInter, args)
+}
+
+func (s *Storage) cmdSDIFF(args []string) []byte {
+	return s.setOpCommand(setDiff, args)
+}
+
+func (s *Storage) cmdSUNIONSTORE(args []string) []byte {
+	return s.setOpStore(setUnion, args)
+}
+
+func (s *Storage) cmdSINTERSTORE(args []string) []byte {
+	return s.setOpStore(setInter, args)
+}
+
+func (s *Storage) cmdSDIFFSTORE(args []string) []byte {
+	return s.setOpStore(setDiff, args)
+}
+
+// sinterCardKeys extracts the keys of SINTERCARD
+func sinterCardKeys(args []string) []string {
+	return numKeysArgs(args, 0)
+}
+
+func (s *Storage) cmdSINTERCARD(args []string) []byte {
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	if numKeys < 1 {
+		return Encode(errors.New("ERR numkeys should be greater than 0"), false)
+	}
+	if numKeys > len(args)-1 {
+		return Encode(errors.New("ERR Number of keys can't be greater than number of args"), false)
+	}
+	keys, rest := args[1:1+numKeys], args[1+numKeys:]
+	limit := 0
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 2 && strings.EqualFold(rest[0], "LIMIT"):
+		if limit, err = strconv.Atoi(rest[1]); err != nil {
+			return Encode(errNotInteger, false)
+		}
+		if limit < 0 {
+			return Encode(errors.New("ERR LIMIT can't be negative"), false)
+		}
+	default:
+		return Encode(errSyntax, false)
+	}
+	sets, err := s.lookupSets(keys)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if slices.Contains(sets, nil) {
+		return constant.RespZero
+	}
+
+	// Check the members of the smallest set against the others, until LIMIT of them matched.
+	// LIMIT 0 means no limit.
+	slices.SortFunc(sets, func(a, b *data_structure.SimpleSet) int {
+		return a.Len() - b.Len()
+	})
+	card := 0
+inter:
+	for member := range sets[0].All() {
+		for _, set := range sets[1:] {
+			if set.IsMember(member) == 0 {
+				continue inter
+			}
+		}
+		card++
+		if card == limit {
+			break
+		}
+	}
+	return Encode(card, false)
+}
+
+func (s *Storage) cmdSSCAN(args []string) []byte {
+	opts, err := parseScanOptions(args[1:], false)
+	if err != nil {
+		return Encode(err, false)
+	}
+	set, err := s.lookupSet(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if set == nil {
+		return scanReply(0, []string{})
+	}
+	batch, next := set.Scan(opts.cursor, opts.count)
+	res := []string{}
+	for _, member := range batch {
+		if opts.pattern == "" || matchGlob(opts.pattern, member) {
+			res = append(res, member)
+		}
+	}
+	return scanReply(next, res)
+}
@@ -0,0 +1,98 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// setReply decodes an array reply whose order does not matter
+func setReply(t *testing.T, reply string) []interface{} {
+	value, err := core.Decode([]byte(reply))
+	assert.NoError(t, err)
+	return value.([]interface{})
+}
+
+func TestSetCommands(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":0\r\n", execute(s, "SREM", "s", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "s"))
+
+	execute(s, "SADD", "s", "a", "b", "c")
+	assert.EqualValues(t, ":3\r\n", execute(s, "SCARD", "s"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "SCARD", "missing"))
+	assert.EqualValues(t, "*3\r\n:1\r\n:0\r\n:1\r\n", execute(s, "SMISMEMBER", "s", "a", "x", "c"))
+	assert.EqualValues(t, "*1\r\n:0\r\n", execute(s, "SMISMEMBER", "missing", "a"))
+
+	assert.EqualValues(t, ":1\r\n", execute(s, "SMOVE", "s", "other", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "SMOVE", "s", "other", "a"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "SISMEMBER", "other", "a"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "SMOVE", "other", "other", "a"))
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "SMOVE", "s", "str", "b"))
+
+	assert.EqualValues(t, ":2\r\n", execute(s, "SREM", "s", "b", "c", "x"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "s"))
+
+	execute(s, "SADD", "scan", "a1", "a2", "b1")
+	assert.ElementsMatch(t, []interface{}{"a1", "a2"}, setReply(t, execute(s, "SSCAN", "scan", "0", "MATCH", "a*"))[1])
+}
+
+func TestSetRandomAccess(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "SADD", "s", "a", "b", "c")
+
+	assert.Len(t, setReply(t, execute(s, "SRANDMEMBER", "s", "2")), 2)
+	assert.Len(t, setReply(t, execute(s, "SRANDMEMBER", "s", "10")), 3)
+	assert.Len(t, setReply(t, execute(s, "SRANDMEMBER", "s", "-5")), 5)
+	assert.EqualValues(t, "$-1\r\n", execute(s, "SRANDMEMBER", "missing"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "SRANDMEMBER", "missing", "3"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "SCARD", "s"))
+
+	popped := setReply(t, execute(s, "SPOP", "s", "2"))
+	assert.Len(t, popped, 2)
+	assert.EqualValues(t, ":1\r\n", execute(s, "SCARD", "s"))
+	last := execute(s, "SPOP", "s")
+	assert.NotContains(t, popped, last[4:5])
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "s"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "SPOP", "s"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "SPOP", "s", "1"))
+	assert.EqualValues(t, "-ERR value is out of range, must be positive\r\n", execute(s, "SPOP", "s", "-1"))
+}
+
+func TestSetAlgebra(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "SADD", "s1", "a", "b", "c")
+	execute(s, "SADD", "s2", "b", "c", "d")
+	execute(s, "SADD", "s3", "c", "e")
+
+	assert.ElementsMatch(t, []interface{}{"a", "b", "c", "d", "e"}, setReply(t, execute(s, "SUNION", "s1", "s2", "s3", "missing")))
+	assert.ElementsMatch(t, []interface{}{"c"}, setReply(t, execute(s, "SINTER", "s1", "s2", "s3")))
+	assert.ElementsMatch(t, []interface{}{"a"}, setReply(t, execute(s, "SDIFF", "s1", "s2", "missing")))
+	assert.EqualValues(t, "*0\r\n", execute(s, "SINTER", "s1", "missing"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "SDIFF", "missing", "s1"))
+
+	assert.EqualValues(t, ":4\r\n", execute(s, "SUNIONSTORE", "out", "s1", "s2"))
+	assert.EqualValues(t, ":2\r\n", execute(s, "SINTERSTORE", "out", "s1", "s2"))
+	assert.ElementsMatch(t, []interface{}{"b", "c"}, setReply(t, execute(s, "SMEMBERS", "out")))
+	assert.EqualValues(t, ":1\r\n", execute(s, "SDIFFSTORE", "s1", "s1", "s2"))
+	assert.EqualValues(t, "*1\r\n$1\r\na\r\n", execute(s, "SMEMBERS", "s1"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "SINTERSTORE", "out", "s1", "s2"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "out"))
+
+	assert.EqualValues(t, ":3\r\n", execute(s, "SINTERCARD", "2", "s2", "s2"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "SINTERCARD", "2", "s2", "s2", "LIMIT", "1"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "SINTERCARD", "1", "s2", "LIMIT", "0"))
+	assert.EqualValues(t, "-ERR numkeys should be greater than 0\r\n", execute(s, "SINTERCARD", "0", "s2"))
+	assert.EqualValues(t, "-ERR Number of keys can't be greater than number of args\r\n", execute(s, "SINTERCARD", "3", "s2"))
+	assert.EqualValues(t, "-ERR LIMIT can't be negative\r\n", execute(s, "SINTERCARD", "1", "s2", "LIMIT", "-1"))
+	execute(s, "SADD", "big", "a", "b", "c", "d", "e", "f")
+	assert.EqualValues(t, ":3\r\n", execute(s, "SINTERCARD", "2", "big", "s2", "LIMIT", "5"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "SINTERCARD", "2", "big", "missing"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "SINTERCARD", "2", "missing", "str"))
+	assert.EqualValues(t, wrongType, execute(s, "SUNION", "s2", "str"))
+	assert.EqualValues(t, wrongType, execute(s, "SINTERSTORE", "out", "missing", "str"))
+}
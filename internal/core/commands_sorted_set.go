@@ -3,13 +3,46 @@ package core
 import (
 	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/simple_set"
 	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/sorted_set"
 )
 
-func cmdZADD(args []string) []byte {
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "ZADD", Handler: (*Storage).cmdZADD, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key score member [score member ...]", Summary: "Add members to a sorted set"},
+		&CommandSpec{Name: "ZSCORE", Handler: (*Storage).cmdZSCORE, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key member", Summary: "Get the score of a member in a sorted set"},
+		&CommandSpec{Name: "BZPOPMIN", Handler: (*Storage).cmdBZPOPMIN, Arity: -3, Flags: []string{FlagWrite, FlagFast, FlagBlocking}, FirstKey: 1, LastKey: -2, Step: 1, Group: "sorted_set", Syntax: "key [key ...] timeout", Summary: "Remove and return the member with the lowest score from the first non-empty sorted set, or block until one is available"},
+		&CommandSpec{Name: "BZPOPMAX", Handler: (*Storage).cmdBZPOPMAX, Arity: -3, Flags: []string{FlagWrite, FlagFast, FlagBlocking}, FirstKey: 1, LastKey: -2, Step: 1, Group: "sorted_set", Syntax: "key [key ...] timeout", Summary: "Remove and return the member with the highest score from the first non-empty sorted set, or block until one is available"},
+		&CommandSpec{Name: "ZRANK", Handler: (*Storage).cmdZRANK, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key member", Summary: "Get the rank of a member in a sorted set"},
+		&CommandSpec{Name: "ZREVRANK", Handler: (*Storage).cmdZREVRANK, Arity: 3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key member", Summary: "Get the rank of a member in a sorted set, with the scores ordered from high to low"},
+		&CommandSpec{Name: "ZREM", Handler: (*Storage).cmdZREM, Arity: -3, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key member [member ...]", Summary: "Remove members from a sorted set"},
+		&CommandSpec{Name: "ZCARD", Handler: (*Storage).cmdZCARD, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key", Summary: "Get the number of members in a sorted set"},
+		&CommandSpec{Name: "ZCOUNT", Handler: (*Storage).cmdZCOUNT, Arity: 4, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key min max", Summary: "Count the members in a sorted set with scores within the given range"},
+		&CommandSpec{Name: "ZINCRBY", Handler: (*Storage).cmdZINCRBY, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key increment member", Summary: "Increment the score of a member in a sorted set"},
+		&CommandSpec{Name: "ZMSCORE", Handler: (*Storage).cmdZMSCORE, Arity: -3, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key member [member ...]", Summary: "Get the scores of members in a sorted set"},
+		&CommandSpec{Name: "ZRANGE", Handler: (*Storage).cmdZRANGE, Arity: -4, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key start stop [BYSCORE | BYLEX] [REV] [LIMIT offset count] [WITHSCORES]", Summary: "Return a range of members in a sorted set, by rank, score or lexicographical order"},
+		&CommandSpec{Name: "ZRANGEBYSCORE", Handler: (*Storage).cmdZRANGEBYSCORE, Arity: -4, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key min max [WITHSCORES] [LIMIT offset count]", Summary: "Return a range of members in a sorted set, by score"},
+		&CommandSpec{Name: "ZREMRANGEBYRANK", Handler: (*Storage).cmdZREMRANGEBYRANK, Arity: 4, Flags: []string{FlagWrite}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key start stop", Summary: "Remove the members of a sorted set within the given ranks"},
+		&CommandSpec{Name: "ZREMRANGEBYSCORE", Handler: (*Storage).cmdZREMRANGEBYSCORE, Arity: 4, Flags: []string{FlagWrite}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key min max", Summary: "Remove the members of a sorted set within the given scores"},
+		&CommandSpec{Name: "ZRANGESTORE", Handler: (*Storage).cmdZRANGESTORE, Arity: -5, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 2, Step: 1, Group: "sorted_set", Syntax: "dst src min max [BYSCORE | BYLEX] [REV] [LIMIT offset count]", Summary: "Store a range of members of a sorted set in another key"},
+		&CommandSpec{Name: "ZUNION", Handler: (*Storage).cmdZUNION, Arity: -3, Flags: []string{FlagReadOnly, FlagMovableKeys}, KeysFunc: zsetOpKeys, Group: "sorted_set", Syntax: "numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM | MIN | MAX] [WITHSCORES]", Summary: "Return the union of multiple sorted sets"},
+		&CommandSpec{Name: "ZINTER", Handler: (*Storage).cmdZINTER, Arity: -3, Flags: []string{FlagReadOnly, FlagMovableKeys}, KeysFunc: zsetOpKeys, Group: "sorted_set", Syntax: "numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM | MIN | MAX] [WITHSCORES]", Summary: "Return the intersection of multiple sorted sets"},
+		&CommandSpec{Name: "ZUNIONSTORE", Handler: (*Storage).cmdZUNIONSTORE, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM, FlagMovableKeys}, FirstKey: 1, LastKey: 1, Step: 1, KeysFunc: zsetOpStoreKeys, Group: "sorted_set", Syntax: "destination numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM | MIN | MAX]", Summary: "Store the union of multiple sorted sets in a key"},
+		&CommandSpec{Name: "ZINTERSTORE", Handler: (*Storage).cmdZINTERSTORE, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM, FlagMovableKeys}, FirstKey: 1, LastKey: 1, Step: 1, KeysFunc: zsetOpStoreKeys, Group: "sorted_set", Syntax: "destination numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM | MIN | MAX]", Summary: "Store the intersection of multiple sorted sets in a key"},
+		&CommandSpec{Name: "ZDIFFSTORE", Handler: (*Storage).cmdZDIFFSTORE, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM, FlagMovableKeys}, FirstKey: 1, LastKey: 1, Step: 1, KeysFunc: zsetOpStoreKeys, Group: "sorted_set", Syntax: "destination numkeys key [key ...]", Summary: "Store the difference of the first sorted set and the following ones in a key"},
+		&CommandSpec{Name: "ZPOPMIN", Handler: (*Storage).cmdZPOPMIN, Arity: -2, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key [count]", Summary: "Remove and return the members with the lowest scores in a sorted set"},
+		&CommandSpec{Name: "ZPOPMAX", Handler: (*Storage).cmdZPOPMAX, Arity: -2, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "sorted_set", Syntax: "key [count]", Summary: "Remove and return the members with the highest scores in a sorted set"},
+	)
+}
+
+func (s *Storage) cmdZADD(args []string) []byte {
 	if len(args) < 3 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'ZADD' command"), false)
 	}
@@ -21,20 +54,9 @@ func cmdZADD(args []string) []byte {
 		return Encode(fmt.Errorf("(error) Wrong number of (score, member) arg: %d", numScoreEleArgs), false)
 	}
 
-	zset, exist := zsetStore[key]
-	if !exist {
-		config := sorted_set.IndexConfig{
-			Type:   sorted_set.IndexTypeBTree,
-			Degree: constant.DefaultBPlusTreeDegree,
-		}
-
-		var err error
-		zset, err = sorted_set.NewSortedSet(config)
-		if err != nil {
-			return Encode(errors.New("(error) Can not initialize sorted set: "+err.Error()), false)
-		}
-
-		zsetStore[key] = zset
+	zset, err := s.zsetForWrite(key)
+	if err != nil {
+		return Encode(err, false)
 	}
 
 	count := 0
@@ -44,40 +66,764 @@ func cmdZADD(args []string) []byte {
 		if err != nil {
 			return Encode(errors.New("(error) Score must be floating point number"), false)
 		}
-		ret := zset.Add(score, member)
-		if ret != 1 {
-			return Encode(errors.New("error when adding element"), false)
-		}
-		count++
+		// Updating the score of an existing member does not count as added
+		count += zset.Add(score, member)
 	}
 	return Encode(count, false)
 }
 
-func cmdZSCORE(args []string) []byte {
+// zsetForWrite returns the sorted set at key, creating an empty one when the key does not exist.
+func (s *Storage) zsetForWrite(key string) (*sorted_set.SortedSet, error) {
+	zset, err := s.lookupZSet(key)
+	if err != nil || zset != nil {
+		return zset, err
+	}
+	config := sorted_set.IndexConfig{
+		Type:   sorted_set.IndexTypeBTree,
+		Degree: constant.DefaultBPlusTreeDegree,
+	}
+	zset, err = sorted_set.NewSortedSet(config)
+	if err != nil {
+		return nil, errors.New("(error) Can not initialize sorted set: " + err.Error())
+	}
+	s.addObj(key, hash_table.ObjTypeZSet, hash_table.ObjEncodingBTree, zset)
+	return zset, nil
+}
+
+// storeZSet replaces whatever dst holds with a sorted set of items, or deletes dst when there are
+// none, and replies with the number of members stored.
+func (s *Storage) storeZSet(dst string, items []*sorted_set.Item) []byte {
+	s.dictStore.Del(dst)
+	if len(items) == 0 {
+		return Encode(0, false)
+	}
+	zset, err := s.zsetForWrite(dst)
+	if err != nil {
+		return Encode(err, false)
+	}
+	for _, item := range items {
+		zset.Add(item.Score, item.Member)
+	}
+	return Encode(zset.Len(), false)
+}
+
+// deleteIfEmptyZSet removes the key of a sorted set that lost its last member.
+func (s *Storage) deleteIfEmptyZSet(key string, zset *sorted_set.SortedSet) {
+	if zset.Len() == 0 {
+		s.dictStore.Del(key)
+	}
+}
+
+func (s *Storage) cmdZSCORE(args []string) []byte {
 	if len(args) != 2 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'ZSCORE' command"), false)
 	}
 	key, member := args[0], args[1]
-	zset, exist := zsetStore[key]
-	if !exist {
+	zset, err := s.lookupZSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if zset == nil {
 		return constant.RespNil
 	}
 	score, exist := zset.GetScore(member)
 	if !exist {
 		return constant.RespNil
 	}
-	return Encode(fmt.Sprintf("%f", score), false)
+	return Encode(formatScore(score), false)
 }
 
-func cmdZRANK(args []string) []byte {
+func (s *Storage) cmdZRANK(args []string) []byte {
 	if len(args) != 2 {
 		return Encode(errors.New("(error) ERR wrong number of arguments for 'ZRANK' command"), false)
 	}
 	key, member := args[0], args[1]
-	zset, exist := zsetStore[key]
-	if !exist {
+	zset, err := s.lookupZSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if zset == nil {
 		return constant.RespNil
 	}
 	rank := zset.GetRank(member)
+	if rank < 0 {
+		return constant.RespNil
+	}
 	return Encode(rank, false)
 }
+
+func (s *Storage) cmdZREVRANK(args []string) []byte {
+	zset, err := s.lookupZSet(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if zset == nil {
+		return constant.RespNil
+	}
+	rank := zset.GetRank(args[1])
+	if rank < 0 {
+		return constant.RespNil
+	}
+	return Encode(zset.Len()-1-rank, false)
+}
+
+func (s *Storage) cmdZREM(args []string) []byte {
+	key := args[0]
+	zset, err := s.lookupZSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if zset == nil {
+		return Encode(0, false)
+	}
+	removed := 0
+	for _, member := range args[1:] {
+		removed += zset.Remove(member)
+	}
+	s.deleteIfEmptyZSet(key, zset)
+	return Encode(removed, false)
+}
+
+func (s *Storage) cmdZCARD(args []string) []byte {
+	zset, err := s.lookupZSet(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if zset == nil {
+		return Encode(0, false)
+	}
+	return Encode(zset.Len(), false)
+}
+
+func (s *Storage) cmdZCOUNT(args []string) []byte {
+	r, err := parseScoreRange(args[1], args[2])
+	if err != nil {
+		return Encode(err, false)
+	}
+	zset, err := s.lookupZSet(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	if zset == nil {
+		return Encode(0, false)
+	}
+	return Encode(zset.Count(*r), false)
+}
+
+func (s *Storage) cmdZINCRBY(args []string) []byte {
+	key, member := args[0], args[2]
+	delta, ok := parseScore(args[1])
+	if !ok {
+		return Encode(errNotFloat, false)
+	}
+	zset, err := s.zsetForWrite(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	score, _ := zset.GetScore(member)
+	score += delta
+	if math.IsNaN(score) {
+		s.deleteIfEmptyZSet(key, zset)
+		return Encode(errors.New("ERR resulting score is not a number (NaN)"), false)
+	}
+	zset.Add(score, member)
+	return Encode(formatScore(score), false)
+}
+
+func (s *Storage) cmdZMSCORE(args []string) []byte {
+	zset, err := s.lookupZSet(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, len(args)-1)
+	if zset != nil {
+		for i, member := range args[1:] {
+			if score, exist := zset.GetScore(member); exist {
+				res[i] = formatScore(score)
+			}
+		}
+	}
+	return Encode(res, false)
+}
+
+// zrangeOptions holds the optional arguments of ZRANGE and ZRANGEBYSCORE.
+type zrangeOptions struct {
+	byScore, byLex, rev, withScores, limit bool
+	offset, count                          int
+}
+
+// parseZRangeOptions parses args into opts. ZRANGEBYSCORE does not take BYSCORE, BYLEX or REV,
+// which only allowBy accepts.
+func parseZRangeOptions(args []string, opts *zrangeOptions, allowBy bool) error {
+	for i := 0; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); {
+		case opt == "BYSCORE" && allowBy:
+			opts.byScore = true
+		case opt == "BYLEX" && allowBy:
+			opts.byLex = true
+		case opt == "REV" && allowBy:
+			opts.rev = true
+		case opt == "WITHSCORES":
+			opts.withScores = true
+		case opt == "LIMIT" && i+2 < len(args):
+			offset, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return errNotInteger
+			}
+			count, err := strconv.Atoi(args[i+2])
+			if err != nil {
+				return errNotInteger
+			}
+			opts.limit, opts.offset, opts.count = true, offset, count
+			i += 2
+		default:
+			return errSyntax
+		}
+	}
+	switch {
+	case opts.byScore && opts.byLex:
+		return errSyntax
+	case opts.limit && !opts.byScore && !opts.byLex:
+		return errors.New("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+	case opts.withScores && opts.byLex:
+		return errors.New("ERR syntax error, WITHSCORES not supported in combination with BYLEX")
+	}
+	return nil
+}
+
+// parseScore parses a score, which unlike parseFloat may be infinite.
+func parseScore(value string) (float64, bool) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, false
+	}
+	return f, true
+}
+
+// parseScoreRange parses the min and max of a score range, "(" in front of a bound excludes it.
+func parseScoreRange(minArg, maxArg string) (*sorted_set.ScoreRange, error) {
+	r := &sorted_set.ScoreRange{}
+	var ok bool
+	minArg, r.MinEx = strings.CutPrefix(minArg, "(")
+	maxArg, r.MaxEx = strings.CutPrefix(maxArg, "(")
+	if r.Min, ok = parseScore(minArg); !ok {
+		return nil, errors.New("ERR min or max is not a float")
+	}
+	if r.Max, ok = parseScore(maxArg); !ok {
+		return nil, errors.New("ERR min or max is not a float")
+	}
+	return r, nil
+}
+
+// parseLexBound parses a bound of a lex range: "-" or "+" for the infinities, or a member
+// prefixed with "[" to include it or "(" to exclude it.
+func parseLexBound(arg string) (member string, ex, inf bool, err error) {
+	switch {
+	case arg == "-" || arg == "+":
+		return "", false, true, nil
+	case strings.HasPrefix(arg, "["):
+		return arg[1:], false, false, nil
+	case strings.HasPrefix(arg, "("):
+		return arg[1:], true, false, nil
+	}
+	return "", false, false, errors.New("ERR min or max not valid string range item")
+}
+
+func parseLexRange(minArg, maxArg string) (*sorted_set.LexRange, error) {
+	r := &sorted_set.LexRange{}
+	var err error
+	if r.Min, r.MinEx, r.MinInf, err = parseLexBound(minArg); err != nil {
+		return nil, err
+	}
+	if r.Max, r.MaxEx, r.MaxInf, err = parseLexBound(maxArg); err != nil {
+		return nil, err
+	}
+	// "+" as min or "-" as max make an empty range
+	if minArg == "+" || maxArg == "-" {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// zsetReply encodes items as a flat array of members, each followed by its score when withScores is set.
+func zsetReply(items []*sorted_set.Item, withScores bool) []byte {
+	res := make([]string, 0, len(items)*2)
+	for _, item := range items {
+		res = append(res, item.Member)
+		if withScores {
+			res = append(res, formatScore(item.Score))
+		}
+	}
+	return Encode(res, false)
+}
+
+// zrange returns the members of the sorted set at key between the start and stop arguments,
+// which are ranks, scores or members depending on opts.
+func (s *Storage) zrange(key, startArg, stopArg string, opts *zrangeOptions) ([]*sorted_set.Item, error) {
+	count := -1
+	if opts.limit {
+		count = opts.count
+	}
+	var scores *sorted_set.ScoreRange
+	var lex *sorted_set.LexRange
+	var err error
+	// With REV the range is given from the highest bound to the lowest
+	minArg, maxArg := startArg, stopArg
+	if opts.rev {
+		minArg, maxArg = stopArg, startArg
+	}
+	switch {
+	case opts.byScore:
+		scores, err = parseScoreRange(minArg, maxArg)
+	case opts.byLex:
+		lex, err = parseLexRange(minArg, maxArg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	zset, err := s.lookupZSet(key)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case opts.byScore:
+		if zset != nil {
+			return zset.RangeByScore(*scores, opts.offset, count, opts.rev), nil
+		}
+	case opts.byLex:
+		if zset != nil && lex != nil {
+			return zset.RangeByLex(*lex, opts.offset, count, opts.rev), nil
+		}
+	default:
+		n := 0
+		if zset != nil {
+			n = zset.Len()
+		}
+		// The ranks are validated even when the key does not exist
+		start, stop, ok, err := listRange(startArg, stopArg, n)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return zset.RangeByRank(start, stop, opts.rev), nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Storage) cmdZRANGE(args []string) []byte {
+	opts := &zrangeOptions{}
+	if err := parseZRangeOptions(args[3:], opts, true); err != nil {
+		return Encode(err, false)
+	}
+	items, err := s.zrange(args[0], args[1], args[2], opts)
+	if err != nil {
+		return Encode(err, false)
+	}
+	return zsetReply(items, opts.withScores)
+}
+
+func (s *Storage) cmdZRANGEBYSCORE(args []string) []byte {
+	opts := &zrangeOptions{byScore: true}
+	if err := parseZRangeOptions(args[3:], opts, false); err != nil {
+		return Encode(err, false)
+	}
+	items, err := s.zrange(args[0], args[1], args[2], opts)
+	if err != nil {
+		return Encode(err, false)
+	}
+	return zsetReply(items, opts.withScores)
+}
+
+func (s *Storage) cmdZRANGESTORE(args []string) []byte {
+	opts := &zrangeOptions{}
+	if err := parseZRangeOptions(args[4:], opts, true); err != nil {
+		return Encode(err, false)
+	}
+	if opts.withScores {
+		return Encode(errSyntax, false)
+	}
+	items, err := s.zrange(args[1], args[2], args[3], opts)
+	if err != nil {
+		return Encode(err, false)
+	}
+	return s.storeZSet(args[0], items)
+}
+
+func (s *Storage) cmdZREMRANGEBYRANK(args []string) []byte {
+	key := args[0]
+	zset, err := s.lookupZSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	n := 0
+	if zset != nil {
+		n = zset.Len()
+	}
+	start, stop, ok, err := listRange(args[1], args[2], n)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if !ok {
+		return Encode(0, false)
+	}
+	removed := zset.RemoveRangeByRank(start, stop)
+	s.deleteIfEmptyZSet(key, zset)
+	return Encode(removed, false)
+}
+
+func (s *Storage) cmdZREMRANGEBYSCORE(args []string) []byte {
+	key := args[0]
+	r, err := parseScoreRange(args[1], args[2])
+	if err != nil {
+		return Encode(err, false)
+	}
+	zset, err := s.lookupZSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if zset == nil {
+		return Encode(0, false)
+	}
+	removed := zset.RemoveRangeByScore(*r)
+	s.deleteIfEmptyZSet(key, zset)
+	return Encode(removed, false)
+}
+
+// zpop removes and returns the members with the lowest or highest scores, one unless a count is given.
+func (s *Storage) zpop(args []string, lowest bool) []byte {
+	if len(args) > 2 {
+		return Encode(errSyntax, false)
+	}
+	key := args[0]
+	count := 1
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return Encode(errors.New("ERR value is out of range, must be positive"), false)
+		}
+		count = n
+	}
+	zset, err := s.lookupZSet(key)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if zset == nil {
+		return constant.RespEmptyArray
+	}
+	var items []*sorted_set.Item
+	if lowest {
+		items = zset.PopMin(count)
+	} else {
+		items = zset.PopMax(count)
+	}
+	s.deleteIfEmptyZSet(key, zset)
+	return zsetReply(items, true)
+}
+
+func (s *Storage) cmdZPOPMIN(args []string) []byte {
+	return s.zpop(args, true)
+}
+
+func (s *Storage) cmdZPOPMAX(args []string) []byte {
+	return s.zpop(args, false)
+}
+
+// formatScore formats a score the way Redis replies with it, without trailing zeros.
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	case score != 0 && (math.Abs(score) >= 1e21 || math.Abs(score) < 1e-6):
+		return strconv.FormatFloat(score, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+// blockingZPop pops the lowest or highest member of the first non-empty sorted set among keys.
+// It returns nil when they are all empty.
+func (s *Storage) blockingZPop(args []string, lowest bool) []byte {
+	keys := args[:len(args)-1]
+	if _, err := parseBlockTimeout(args[len(args)-1]); err != nil {
+		return Encode(err, false)
+	}
+	for _, key := range keys {
+		zset, err := s.lookupZSet(key)
+		if err != nil {
+			return Encode(err, false)
+		}
+		if zset == nil {
+			continue
+		}
+		var items []*sorted_set.Item
+		if lowest {
+			items = zset.PopMin(1)
+		} else {
+			items = zset.PopMax(1)
+		}
+		s.deleteIfEmptyZSet(key, zset)
+		if len(items) == 0 {
+			continue
+		}
+		return Encode([]string{key, items[0].Member, formatScore(items[0].Score)}, false)
+	}
+	return nil
+}
+
+func (s *Storage) cmdBZPOPMIN(args []string) []byte {
+	return s.blockingZPop(args, true)
+}
+
+func (s *Storage) cmdBZPOPMAX(args []string) []byte {
+	return s.blockingZPop(args, false)
+}
+
+// numKeysArgs returns the keys that follow the numkeys argument at args[pos]. It returns nil when
+// numkeys is invalid, the handler then replies with the error.
+func numKeysArgs(args []string, pos int) []string {
+	if pos >= len(args) {
+		return nil
+	}
+	n, err := strconv.Atoi(args[pos])
+	if err != nil || n < 1 || n > len(args)-pos-1 {
+		return nil
+	}
+	return args[pos+1 : pos+1+n]
+}
+
+// zsetOpKeys extracts the keys of ZUNION and ZINTER
+func zsetOpKeys(args []string) []string {
+	return numKeysArgs(args, 0)
+}
+
+// zsetOpStoreKeys extracts the keys of ZUNIONSTORE, ZINTERSTORE, ZDIFFSTORE, CMS.MERGE and TDIGEST.MERGE, the destination first
+func zsetOpStoreKeys(args []string) []string {
+	keys := numKeysArgs(args, 1)
+	if keys == nil {
+		return nil
+	}
+	return append([]string{args[0]}, keys...)
+}
+
+type zsetOp int
+
+const (
+	zsetUnion zsetOp = iota
+	zsetInter
+	zsetDiff
+)
+
+type zsetAggregate int
+
+const (
+	aggregateSum zsetAggregate = iota
+	aggregateMin
+	aggregateMax
+)
+
+// apply combines the score a member has so far with its score in another sorted set.
+func (a zsetAggregate) apply(acc, score float64) float64 {
+	switch a {
+	case aggregateMin:
+		return math.Min(acc, score)
+	case aggregateMax:
+		return math.Max(acc, score)
+	}
+	return zeroIfNaN(acc + score)
+}
+
+// zeroIfNaN is how Redis resolves inf - inf and 0 * inf in the scores of set operations
+func zeroIfNaN(score float64) float64 {
+	if math.IsNaN(score) {
+		return 0
+	}
+	return score
+}
+
+// zsetOpOptions holds the arguments of ZUNION, ZINTER, ZDIFFSTORE and their STORE variants.
+type zsetOpOptions struct {
+	keys       []string
+	weights    []float64 // nil unless WEIGHTS is given
+	aggregate  zsetAggregate
+	withScores bool
+}
+
+// parseZSetOp parses "numkeys key [key ...]" and the options that follow: WEIGHTS and AGGREGATE
+// except for a difference, and WITHSCORES except when storing the result.
+func parseZSetOp(name string, args []string, op zsetOp, store bool) (*zsetOpOptions, error) {
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, errNotInteger
+	}
+	if numKeys < 1 {
+		return nil, fmt.Errorf("ERR at least 1 input key is needed for '%s' command", name)
+	}
+	if numKeys > len(args)-1 {
+		return nil, errSyntax
+	}
+	opts := &zsetOpOptions{keys: args[1 : 1+numKeys]}
+	rest := args[1+numKeys:]
+	for i := 0; i < len(rest); i++ {
+		switch opt := strings.ToUpper(rest[i]); {
+		case opt == "WEIGHTS" && op != zsetDiff && i+numKeys < len(rest):
+			opts.weights = make([]float64, numKeys)
+			for j := range opts.weights {
+				weight, ok := parseScore(rest[i+1+j])
+				if !ok {
+					return nil, errors.New("ERR weight value is not a float")
+				}
+				opts.weights[j] = weight
+			}
+			i += numKeys
+		case opt == "AGGREGATE" && op != zsetDiff && i+1 < len(rest):
+			switch strings.ToUpper(rest[i+1]) {
+			case "SUM":
+				opts.aggregate = aggregateSum
+			case "MIN":
+				opts.aggregate = aggregateMin
+			case "MAX":
+				opts.aggregate = aggregateMax
+			default:
+				return nil, errSyntax
+			}
+			i++
+		case opt == "WITHSCORES" && !store:
+			opts.withScores = true
+		default:
+			return nil, errSyntax
+		}
+	}
+	return opts, nil
+}
+
+// zsetSource returns the members and scores of the sorted set at key, nil if the key does not exist.
+// A set counts as a sorted set whose members all have a score of 1.
+func (s *Storage) zsetSource(key string) (map[string]float64, error) {
+	obj := s.dictStore.Get(key)
+	if obj == nil {
+		return nil, nil
+	}
+	switch obj.Type {
+	case hash_table.ObjTypeZSet:
+		return obj.Value.(*sorted_set.SortedSet).MemberScore, nil
+	case hash_table.ObjTypeSet:
+		members := obj.Value.(*simple_set.SimpleSet).Members()
+		res := make(map[string]float64, len(members))
+		for _, member := range members {
+			res[member] = 1
+		}
+		return res, nil
+	}
+	return nil, errWrongType
+}
+
+// zsetOpItems computes the union, intersection or difference of the sorted sets of opts, sorted by score.
+func (s *Storage) zsetOpItems(op zsetOp, opts *zsetOpOptions) ([]*sorted_set.Item, error) {
+	sources := make([]map[string]float64, len(opts.keys))
+	for i, key := range opts.keys {
+		src, err := s.zsetSource(key)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = src
+	}
+	weighted := func(i int, score float64) float64 {
+		if opts.weights == nil {
+			return score
+		}
+		return zeroIfNaN(opts.weights[i] * score)
+	}
+
+	res := make(map[string]float64)
+	switch op {
+	case zsetUnion:
+		for i, src := range sources {
+			for member, score := range src {
+				score = weighted(i, score)
+				if acc, exist := res[member]; exist {
+					score = opts.aggregate.apply(acc, score)
+				}
+				res[member] = score
+			}
+		}
+	case zsetInter:
+	members:
+		for member, score := range sources[0] {
+			acc := weighted(0, score)
+			for i, src := range sources[1:] {
+				score, exist := src[member]
+				if !exist {
+					continue members
+				}
+				acc = opts.aggregate.apply(acc, weighted(i+1, score))
+			}
+			res[member] = acc
+		}
+	case zsetDiff:
+	diff:
+		for member, score := range sources[0] {
+			for _, src := range sources[1:] {
+				if _, exist := src[member]; exist {
+					continue diff
+				}
+			}
+			res[member] = score
+		}
+	}
+
+	items := make([]*sorted_set.Item, 0, len(res))
+	for member, score := range res {
+		items = append(items, &sorted_set.Item{Score: score, Member: member})
+	}
+	slices.SortFunc(items, (*sorted_set.Item).CompareTo)
+	return items, nil
+}
+
+// zsetOpCommand implements ZUNION and ZINTER
+func (s *Storage) zsetOpCommand(name string, args []string, op zsetOp) []byte {
+	opts, err := parseZSetOp(name, args, op, false)
+	if err != nil {
+		return Encode(err, false)
+	}
+	items, err := s.zsetOpItems(op, opts)
+	if err != nil {
+		return Encode(err, false)
+	}
+	return zsetReply(items, opts.withScores)
+}
+
+// zsetOpStore implements ZUNIONSTORE, ZINTERSTORE and ZDIFFSTORE
+func (s *Storage) zsetOpStore(name string, args []string, op zsetOp) []byte {
+	opts, err := parseZSetOp(name, args[1:], op, true)
+	if err != nil {
+		return Encode(err, false)
+	}
+	items, err := s.zsetOpItems(op, opts)
+	if err != nil {
+		return Encode(err, false)
+	}
+	return s.storeZSet(args[0], items)
+}
+
+func (s *Storage) cmdZUNION(args []string) []byte {
+	return s.zsetOpCommand("zunion", args, zsetUnion)
+}
+
+func (s *Storage) cmdZINTER(args []string) []byte {
+	return s.zsetOpCommand("zinter", args, zsetInter)
+}
+
+func (s *Storage) cmdZUNIONSTORE(args []string) []byte {
+	return s.zsetOpStore("zunionstore", args, zsetUnion)
+}
+
+func (s *Storage) cmdZINTERSTORE(args []string) []byte {
+	return s.zsetOpStore("zinterstore", args, zsetInter)
+}
+
+func (s *Storage) cmdZDIFFSTORE(args []string) []byte {
+	return s.zsetOpStore("zdiffstore", args, zsetDiff)
+}
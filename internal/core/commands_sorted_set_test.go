@@ -0,0 +1,137 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedSetBasics(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":3\r\n", execute(s, "ZADD", "z", "1", "a", "2", "b", "3", "c"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "ZADD", "z", "2.5", "a", "4", "d"))
+	assert.EqualValues(t, ":4\r\n", execute(s, "ZCARD", "z"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "ZCARD", "missing"))
+
+	assert.EqualValues(t, "$3\r\n2.5\r\n", execute(s, "ZSCORE", "z", "a"))
+	assert.EqualValues(t, "*3\r\n$1\r\n2\r\n$-1\r\n$3\r\n2.5\r\n", execute(s, "ZMSCORE", "z", "b", "x", "a"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "ZRANK", "z", "a"))
+	assert.EqualValues(t, ":2\r\n", execute(s, "ZREVRANK", "z", "a"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "ZRANK", "z", "x"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "ZREVRANK", "z", "x"))
+
+	assert.EqualValues(t, "$3\r\n3.5\r\n", execute(s, "ZINCRBY", "z", "1", "a"))
+	assert.EqualValues(t, "$2\r\n-1\r\n", execute(s, "ZINCRBY", "new", "-1", "m"))
+	assert.EqualValues(t, "-ERR value is not a valid float\r\n", execute(s, "ZINCRBY", "z", "abc", "a"))
+	execute(s, "ZADD", "inf", "+inf", "m")
+	assert.EqualValues(t, "-ERR resulting score is not a number (NaN)\r\n", execute(s, "ZINCRBY", "inf", "-inf", "m"))
+
+	assert.EqualValues(t, ":4\r\n", execute(s, "ZCOUNT", "z", "2", "+inf"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "ZCOUNT", "z", "(2", "4"))
+	assert.EqualValues(t, "-ERR min or max is not a float\r\n", execute(s, "ZCOUNT", "z", "x", "4"))
+
+	assert.EqualValues(t, ":2\r\n", execute(s, "ZREM", "z", "a", "b", "x"))
+	assert.EqualValues(t, ":2\r\n", execute(s, "ZREM", "z", "c", "d"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "z"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "ZRANGE", "str", "0", "-1"))
+	assert.EqualValues(t, wrongType, execute(s, "ZINCRBY", "str", "1", "a"))
+}
+
+func TestZRange(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "ZADD", "z", "1", "a", "2", "b", "3", "c", "4", "d")
+
+	assert.EqualValues(t, "*4\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n$1\r\nd\r\n", execute(s, "ZRANGE", "z", "0", "-1"))
+	assert.EqualValues(t, "*4\r\n$1\r\nd\r\n$1\r\n4\r\n$1\r\nc\r\n$1\r\n3\r\n", execute(s, "ZRANGE", "z", "0", "1", "REV", "WITHSCORES"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "ZRANGE", "z", "5", "10"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "ZRANGE", "missing", "0", "-1"))
+	assert.EqualValues(t, "-ERR value is not an integer or out of range\r\n", execute(s, "ZRANGE", "missing", "a", "-1"))
+
+	assert.EqualValues(t, "*2\r\n$1\r\nb\r\n$1\r\nc\r\n", execute(s, "ZRANGE", "z", "(1", "3", "BYSCORE"))
+	assert.EqualValues(t, "*2\r\n$1\r\nc\r\n$1\r\nb\r\n", execute(s, "ZRANGE", "z", "+inf", "-inf", "BYSCORE", "REV", "LIMIT", "1", "2"))
+	assert.EqualValues(t, "*2\r\n$1\r\nc\r\n$1\r\nd\r\n", execute(s, "ZRANGEBYSCORE", "z", "3", "+inf"))
+	assert.EqualValues(t, "*2\r\n$1\r\nb\r\n$1\r\n2\r\n", execute(s, "ZRANGEBYSCORE", "z", "-inf", "+inf", "WITHSCORES", "LIMIT", "1", "1"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "ZRANGEBYSCORE", "z", "0", "1", "REV"))
+	assert.EqualValues(t, "-ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX\r\n", execute(s, "ZRANGE", "z", "0", "1", "LIMIT", "0", "1"))
+
+	execute(s, "ZADD", "lex", "0", "a", "0", "b", "0", "c", "0", "d")
+	assert.EqualValues(t, "*2\r\n$1\r\nb\r\n$1\r\nc\r\n", execute(s, "ZRANGE", "lex", "[b", "(d", "BYLEX"))
+	assert.EqualValues(t, "*2\r\n$1\r\nd\r\n$1\r\nc\r\n", execute(s, "ZRANGE", "lex", "+", "-", "BYLEX", "REV", "LIMIT", "0", "2"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "ZRANGE", "lex", "+", "[c", "BYLEX"))
+	assert.EqualValues(t, "-ERR min or max not valid string range item\r\n", execute(s, "ZRANGE", "lex", "b", "d", "BYLEX"))
+	assert.EqualValues(t, "-ERR syntax error, WITHSCORES not supported in combination with BYLEX\r\n", execute(s, "ZRANGE", "lex", "-", "+", "BYLEX", "WITHSCORES"))
+}
+
+func TestSortedSetRemoveRangeAndPop(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "ZADD", "z", "1", "a", "2", "b", "3", "c", "4", "d", "5", "e")
+
+	assert.EqualValues(t, ":2\r\n", execute(s, "ZREMRANGEBYRANK", "z", "-2", "-1"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "ZREMRANGEBYSCORE", "z", "(1", "2"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "ZREMRANGEBYRANK", "missing", "0", "-1"))
+
+	assert.EqualValues(t, "*2\r\n$1\r\na\r\n$1\r\n1\r\n", execute(s, "ZPOPMIN", "z"))
+	assert.EqualValues(t, "*2\r\n$1\r\nc\r\n$1\r\n3\r\n", execute(s, "ZPOPMAX", "z", "5"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "z"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "ZPOPMIN", "z"))
+	assert.EqualValues(t, "-ERR value is out of range, must be positive\r\n", execute(s, "ZPOPMAX", "z", "-1"))
+
+	execute(s, "ZADD", "z", "1", "a")
+	assert.EqualValues(t, ":1\r\n", execute(s, "ZREMRANGEBYSCORE", "z", "-inf", "+inf"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "z"))
+}
+
+func TestSortedSetAlgebra(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "ZADD", "z1", "1", "a", "2", "b", "3", "c")
+	execute(s, "ZADD", "z2", "4", "b", "5", "c", "6", "d")
+	execute(s, "SADD", "set", "a", "d")
+
+	assert.EqualValues(t, "*8\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$1\r\n6\r\n$1\r\nd\r\n$1\r\n6\r\n$1\r\nc\r\n$1\r\n8\r\n", execute(s, "ZUNION", "2", "z1", "z2", "WITHSCORES"))
+	assert.EqualValues(t, "*4\r\n$1\r\nb\r\n$1\r\n2\r\n$1\r\nc\r\n$1\r\n3\r\n", execute(s, "ZINTER", "2", "z1", "z2", "AGGREGATE", "MIN", "WITHSCORES"))
+	assert.EqualValues(t, "*4\r\n$1\r\nb\r\n$2\r\n10\r\n$1\r\nc\r\n$4\r\n13.5\r\n", execute(s, "ZINTER", "2", "z1", "z2", "WEIGHTS", "2", "1.5", "WITHSCORES"))
+	assert.EqualValues(t, "*2\r\n$1\r\na\r\n$1\r\nd\r\n", execute(s, "ZUNION", "1", "set"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "ZINTER", "2", "z1", "missing"))
+
+	assert.EqualValues(t, ":4\r\n", execute(s, "ZUNIONSTORE", "out", "2", "z1", "z2", "AGGREGATE", "MAX"))
+	assert.EqualValues(t, "$1\r\n5\r\n", execute(s, "ZSCORE", "out", "c"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "ZINTERSTORE", "out", "2", "z1", "set", "WEIGHTS", "1", "10"))
+	assert.EqualValues(t, "$2\r\n11\r\n", execute(s, "ZSCORE", "out", "a"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "ZDIFFSTORE", "out", "2", "z1", "z2"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "ZDIFFSTORE", "out", "3", "z1", "z2", "set"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "out"))
+
+	// An empty result deletes the destination, whatever it held
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, ":0\r\n", execute(s, "ZINTERSTORE", "str", "2", "z1", "missing"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "str"))
+
+	// inf - inf is 0 when summing
+	execute(s, "ZADD", "pinf", "+inf", "m")
+	execute(s, "ZADD", "ninf", "-inf", "m")
+	assert.EqualValues(t, "*2\r\n$1\r\nm\r\n$1\r\n0\r\n", execute(s, "ZUNION", "2", "pinf", "ninf", "WITHSCORES"))
+
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "ZUNION", "3", "z1", "z2"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "ZUNION", "2", "z1", "z2", "WEIGHTS", "1"))
+	assert.EqualValues(t, "-ERR weight value is not a float\r\n", execute(s, "ZUNION", "2", "z1", "z2", "WEIGHTS", "1", "x"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "ZUNIONSTORE", "out", "2", "z1", "z2", "WITHSCORES"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "ZDIFFSTORE", "out", "2", "z1", "z2", "AGGREGATE", "SUM"))
+	assert.EqualValues(t, "-ERR at least 1 input key is needed for 'zinter' command\r\n", execute(s, "ZINTER", "0", "z1"))
+	execute(s, "RPUSH", "list", "a")
+	assert.EqualValues(t, wrongType, execute(s, "ZUNION", "2", "z1", "list"))
+}
+
+func TestZRangeStore(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "ZADD", "src", "1", "a", "2", "b", "3", "c")
+	assert.EqualValues(t, ":2\r\n", execute(s, "ZRANGESTORE", "dst", "src", "(1", "+inf", "BYSCORE"))
+	assert.EqualValues(t, "*2\r\n$1\r\nb\r\n$1\r\nc\r\n", execute(s, "ZRANGE", "dst", "0", "-1"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "ZRANGESTORE", "src", "src", "0", "0", "REV"))
+	assert.EqualValues(t, "*1\r\n$1\r\nc\r\n", execute(s, "ZRANGE", "src", "0", "-1"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "ZRANGESTORE", "dst", "missing", "0", "-1"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "dst"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "ZRANGESTORE", "dst", "src", "0", "-1", "WITHSCORES"))
+}
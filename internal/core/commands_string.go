@@ -0,0 +1,535 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/config"
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "SET", Handler: (*Storage).cmdSET, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key value [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | KEEPTTL]", Summary: "Set the value of a key"},
+		&CommandSpec{Name: "GET", Handler: (*Storage).cmdGET, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key", Summary: "Get the value of a key"},
+		&CommandSpec{Name: "MGET", Handler: (*Storage).cmdMGET, Arity: -2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: -1, Step: 1, Tips: []string{TipRequestMultiShard}, Group: "string", Syntax: "key [key ...]", Summary: "Get the values of several keys"},
+		&CommandSpec{Name: "MSET", Handler: (*Storage).cmdMSET, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: -1, Step: 2, Tips: []string{TipRequestMultiShard, TipResponseAllSucceeded}, Group: "string", Syntax: "key value [key value ...]", Summary: "Set the values of several keys"},
+		&CommandSpec{Name: "MSETNX", Handler: (*Storage).cmdMSETNX, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: -1, Step: 2, Group: "string", Syntax: "key value [key value ...]", Summary: "Set the values of several keys only if none of them exist"},
+		&CommandSpec{Name: "SETNX", Handler: (*Storage).cmdSETNX, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key value", Summary: "Set the value of a key only if the key does not exist"},
+		&CommandSpec{Name: "SETEX", Handler: (*Storage).cmdSETEX, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key seconds value", Summary: "Set the value and the expiration in seconds of a key"},
+		&CommandSpec{Name: "PSETEX", Handler: (*Storage).cmdPSETEX, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key milliseconds value", Summary: "Set the value and the expiration in milliseconds of a key"},
+		&CommandSpec{Name: "GETSET", Handler: (*Storage).cmdGETSET, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key value", Summary: "Set the value of a key and return its old value"},
+		&CommandSpec{Name: "GETEX", Handler: (*Storage).cmdGETEX, Arity: -2, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | PERSIST]", Summary: "Get the value of a key and optionally set its expiration"},
+		&CommandSpec{Name: "INCR", Handler: (*Storage).cmdINCR, Arity: 2, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key", Summary: "Increment the integer value of a key by one"},
+		&CommandSpec{Name: "DECR", Handler: (*Storage).cmdDECR, Arity: 2, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key", Summary: "Decrement the integer value of a key by one"},
+		&CommandSpec{Name: "INCRBY", Handler: (*Storage).cmdINCRBY, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key increment", Summary: "Increment the integer value of a key by a number"},
+		&CommandSpec{Name: "DECRBY", Handler: (*Storage).cmdDECRBY, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key decrement", Summary: "Decrement the integer value of a key by a number"},
+		&CommandSpec{Name: "INCRBYFLOAT", Handler: (*Storage).cmdINCRBYFLOAT, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key increment", Summary: "Increment the floating point value of a key by a number"},
+		&CommandSpec{Name: "APPEND", Handler: (*Storage).cmdAPPEND, Arity: 3, Flags: []string{FlagWrite, FlagDenyOOM, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key value", Summary: "Append a value to a key"},
+		&CommandSpec{Name: "STRLEN", Handler: (*Storage).cmdSTRLEN, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key", Summary: "Get the length of the value stored at a key"},
+		&CommandSpec{Name: "GETRANGE", Handler: (*Storage).cmdGETRANGE, Arity: 4, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key start end", Summary: "Get a substring of the value stored at a key"},
+		&CommandSpec{Name: "SETRANGE", Handler: (*Storage).cmdSETRANGE, Arity: 4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key offset value", Summary: "Overwrite part of a string at a key starting at an offset"},
+		&CommandSpec{Name: "GETDEL", Handler: (*Storage).cmdGETDEL, Arity: 2, Flags: []string{FlagWrite, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "string", Syntax: "key", Summary: "Get the value of a key and delete it"},
+	)
+}
+
+var (
+	errSyntax     = errors.New("ERR syntax error")
+	errNotInteger = errors.New("ERR value is not an integer or out of range")
+	errNotFloat   = errors.New("ERR value is not a valid float")
+
+	errStringTooLong = errors.New("ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+)
+
+// parseExpireAt converts the argument of an EX, PX, EXAT or PXAT option to an absolute
+// Unix time in milliseconds. name is the command reported in the error message.
+func parseExpireAt(name, option, arg string) (int64, error) {
+	when, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, errNotInteger
+	}
+	errInvalid := fmt.Errorf("ERR invalid expire time in '%s' command", name)
+	if when <= 0 {
+		return 0, errInvalid
+	}
+	switch option {
+	case "EX", "EXAT":
+		if when > math.MaxInt64/1000 {
+			return 0, errInvalid
+		}
+		when *= 1000
+	}
+	switch option {
+	case "EX", "PX":
+		nowMs := time.Now().UnixMilli()
+		if when > math.MaxInt64-nowMs {
+			return 0, errInvalid
+		}
+		when += nowMs
+	}
+	return when, nil
+}
+
+// setOptions holds the parsed options of SET. expireAtMs is 0 when no expiry was given.
+type setOptions struct {
+	nx, xx, get, keepTTL bool
+	expireAtMs           int64
+}
+
+func parseSetOptions(args []string) (*setOptions, error) {
+	opts := &setOptions{}
+	hasExpiry := false
+	for i := 0; i < len(args); i++ {
+		option := strings.ToUpper(args[i])
+		switch option {
+		case "NX":
+			if opts.xx {
+				return nil, errSyntax
+			}
+			opts.nx = true
+		case "XX":
+			if opts.nx {
+				return nil, errSyntax
+			}
+			opts.xx = true
+		case "GET":
+			opts.get = true
+		case "KEEPTTL":
+			if hasExpiry {
+				return nil, errSyntax
+			}
+			opts.keepTTL = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if hasExpiry || opts.keepTTL || i+1 >= len(args) {
+				return nil, errSyntax
+			}
+			at, err := parseExpireAt("set", option, args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			opts.expireAtMs = at
+			hasExpiry = true
+			i++
+		default:
+			return nil, errSyntax
+		}
+	}
+	return opts, nil
+}
+
+// newStringValue returns the value and encoding a string is stored with. Strings that are the
+// canonical form of a 64-bit integer are kept as int64, so counters are not reparsed on every increment.
+func newStringValue(value string) (interface{}, hash_table.ObjEncoding) {
+	if len(value) <= 20 {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && strconv.FormatInt(n, 10) == value {
+			return n, hash_table.ObjEncodingInt
+		}
+	}
+	return value, hash_table.ObjEncodingRaw
+}
+
+// stringValue returns the value of a string object whatever its encoding. A raw string is held
+// as a byte slice once the bitmap commands have updated it in place, see mutableBytes.
+func stringValue(obj *hash_table.Obj) string {
+	switch v := obj.Value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []byte:
+		return string(v)
+	}
+	return obj.Value.(string)
+}
+
+// setStringValue replaces the value of an existing string object in place, keeping its TTL.
+func setStringValue(obj *hash_table.Obj, value string) {
+	obj.Value, obj.Encoding = newStringValue(value)
+}
+
+// setString stores a string value at key, replacing any value of any type. The key gets the
+// expiry expireAtMs, or no expiry when it is 0, unless keepTTL asks to retain the current one.
+func (s *Storage) setString(key, value string, expireAtMs int64, keepTTL bool) {
+	exp, hasExpiry := s.dictStore.GetExpiry(key)
+	obj := s.dictStore.NewObj(key, nil, -1)
+	setStringValue(obj, value)
+	s.dictStore.Set(key, obj)
+	if keepTTL && hasExpiry {
+		s.dictStore.SetExpiryAt(key, exp)
+	} else if expireAtMs > 0 {
+		s.dictStore.SetExpiryAt(key, uint64(expireAtMs))
+	}
+}
+
+func (s *Storage) cmdSET(args []string) []byte {
+	key, value := args[0], args[1]
+	opts, err := parseSetOptions(args[2:])
+	if err != nil {
+		return Encode(err, false)
+	}
+
+	old := s.dictStore.Get(key)
+	var res []byte = constant.RespOk
+	if opts.get {
+		if old != nil && old.Type != hash_table.ObjTypeString {
+			return Encode(errWrongType, false)
+		}
+		res = constant.RespNil
+		if old != nil {
+			res = Encode(stringValue(old), false)
+		}
+	}
+	if (opts.nx && old != nil) || (opts.xx && old == nil) {
+		if opts.get {
+			return res
+		}
+		return constant.RespNil
+	}
+
+	s.setString(key, value, opts.expireAtMs, opts.keepTTL)
+	return res
+}
+
+func (s *Storage) cmdGET(args []string) []byte {
+	key := args[0]
+	obj, err := s.lookup(key, hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if obj == nil {
+		return constant.RespNil
+	}
+
+	return Encode(stringValue(obj), false)
+}
+
+func (s *Storage) cmdMGET(args []string) []byte {
+	res := make([]interface{}, len(args))
+	for i, key := range args {
+		// A key holding another type is reported as missing rather than failing the whole command.
+		if obj, err := s.lookup(key, hash_table.ObjTypeString); err == nil && obj != nil {
+			res[i] = stringValue(obj)
+		}
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdMSET(args []string) []byte {
+	if len(args)%2 != 0 {
+		return errWrongNumberOfArgs("MSET")
+	}
+	for i := 0; i < len(args); i += 2 {
+		s.setString(args[i], args[i+1], 0, false)
+	}
+	return constant.RespOk
+}
+
+func (s *Storage) cmdMSETNX(args []string) []byte {
+	if len(args)%2 != 0 {
+		return errWrongNumberOfArgs("MSETNX")
+	}
+	for i := 0; i < len(args); i += 2 {
+		if s.exists(args[i]) {
+			return constant.RespZero
+		}
+	}
+	for i := 0; i < len(args); i += 2 {
+		s.setString(args[i], args[i+1], 0, false)
+	}
+	return constant.RespOne
+}
+
+func (s *Storage) cmdSETNX(args []string) []byte {
+	key, value := args[0], args[1]
+	if s.exists(key) {
+		return constant.RespZero
+	}
+	s.setString(key, value, 0, false)
+	return constant.RespOne
+}
+
+func (s *Storage) setWithTTL(name, option string, args []string) []byte {
+	key, value := args[0], args[2]
+	at, err := parseExpireAt(name, option, args[1])
+	if err != nil {
+		return Encode(err, false)
+	}
+	s.setString(key, value, at, false)
+	return constant.RespOk
+}
+
+func (s *Storage) cmdSETEX(args []string) []byte {
+	return s.setWithTTL("setex", "EX", args)
+}
+
+func (s *Storage) cmdPSETEX(args []string) []byte {
+	return s.setWithTTL("psetex", "PX", args)
+}
+
+func (s *Storage) cmdGETSET(args []string) []byte {
+	key, value := args[0], args[1]
+	old, err := s.lookup(key, hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	s.setString(key, value, 0, false)
+	if old == nil {
+		return constant.RespNil
+	}
+	return Encode(stringValue(old), false)
+}
+
+func (s *Storage) cmdGETEX(args []string) []byte {
+	key := args[0]
+	var expireAtMs int64
+	persist := false
+	for i := 1; i < len(args); i++ {
+		option := strings.ToUpper(args[i])
+		switch option {
+		case "PERSIST":
+			if expireAtMs != 0 || persist {
+				return Encode(errSyntax, false)
+			}
+			persist = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if expireAtMs != 0 || persist || i+1 >= len(args) {
+				return Encode(errSyntax, false)
+			}
+			at, err := parseExpireAt("getex", option, args[i+1])
+			if err != nil {
+				return Encode(err, false)
+			}
+			expireAtMs = at
+			i++
+		default:
+			return Encode(errSyntax, false)
+		}
+	}
+
+	obj, err := s.lookup(key, hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if obj == nil {
+		return constant.RespNil
+	}
+	if persist {
+		s.dictStore.Persist(key)
+	} else if expireAtMs > 0 {
+		s.dictStore.SetExpiryAt(key, uint64(expireAtMs))
+	}
+	return Encode(stringValue(obj), false)
+}
+
+func (s *Storage) cmdGETDEL(args []string) []byte {
+	key := args[0]
+	obj, err := s.lookup(key, hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if obj == nil {
+		return constant.RespNil
+	}
+	s.dictStore.Del(key)
+	return Encode(stringValue(obj), false)
+}
+
+// incrBy adds delta to the integer stored at key, creating the key with 0 when it does not exist.
+func (s *Storage) incrBy(key string, delta int64) []byte {
+	obj, err := s.lookup(key, hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	var current int64
+	if obj != nil {
+		// A bitmap updated in place keeps the raw encoding even when it holds an integer
+		value, encoding := obj.Value, obj.Encoding
+		if _, ok := value.([]byte); ok {
+			value, encoding = newStringValue(stringValue(obj))
+		}
+		if encoding != hash_table.ObjEncodingInt {
+			return Encode(errNotInteger, false)
+		}
+		current = value.(int64)
+	}
+	if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+		return Encode(errors.New("ERR increment or decrement would overflow"), false)
+	}
+	current += delta
+	if obj == nil {
+		s.setString(key, strconv.FormatInt(current, 10), 0, false)
+	} else {
+		obj.Value, obj.Encoding = current, hash_table.ObjEncodingInt
+	}
+	return Encode(current, false)
+}
+
+func (s *Storage) cmdINCR(args []string) []byte {
+	return s.incrBy(args[0], 1)
+}
+
+func (s *Storage) cmdDECR(args []string) []byte {
+	return s.incrBy(args[0], -1)
+}
+
+func (s *Storage) cmdINCRBY(args []string) []byte {
+	delta, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	return s.incrBy(args[0], delta)
+}
+
+func (s *Storage) cmdDECRBY(args []string) []byte {
+	delta, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	if delta == math.MinInt64 {
+		return Encode(errors.New("ERR decrement would overflow"), false)
+	}
+	return s.incrBy(args[0], -delta)
+}
+
+func parseFloat(value string) (float64, bool) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+func (s *Storage) cmdINCRBYFLOAT(args []string) []byte {
+	key := args[0]
+	delta, ok := parseFloat(args[1])
+	if !ok {
+		return Encode(errNotFloat, false)
+	}
+	obj, err := s.lookup(key, hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	var current float64
+	if obj != nil {
+		if current, ok = parseFloat(stringValue(obj)); !ok {
+			return Encode(errNotFloat, false)
+		}
+	}
+	current += delta
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		return Encode(errors.New("ERR increment would produce NaN or Infinity"), false)
+	}
+	value := strconv.FormatFloat(current, 'f', -1, 64)
+	if obj == nil {
+		s.setString(key, value, 0, false)
+	} else {
+		setStringValue(obj, value)
+	}
+	return Encode(value, false)
+}
+
+func (s *Storage) cmdAPPEND(args []string) []byte {
+	key, value := args[0], args[1]
+	obj, err := s.lookup(key, hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if obj == nil {
+		s.setString(key, value, 0, false)
+		return Encode(len(value), false)
+	}
+	value = stringValue(obj) + value
+	if len(value) > config.ProtoMaxBulkLen {
+		return Encode(errStringTooLong, false)
+	}
+	setStringValue(obj, value)
+	return Encode(len(value), false)
+}
+
+func (s *Storage) cmdSTRLEN(args []string) []byte {
+	obj, err := s.lookup(args[0], hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if obj == nil {
+		return constant.RespZero
+	}
+	return Encode(len(stringValue(obj)), false)
+}
+
+func (s *Storage) cmdGETRANGE(args []string) []byte {
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	end, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	obj, err := s.lookup(args[0], hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+	if obj == nil {
+		return Encode("", false)
+	}
+
+	value := stringValue(obj)
+	n := int64(len(value))
+	if start < 0 && end < 0 && start > end {
+		return Encode("", false)
+	}
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if end < 0 {
+		end = max(n+end, 0)
+	}
+	end = min(end, n-1)
+	if start > end || n == 0 {
+		return Encode("", false)
+	}
+	return Encode(value[start:end+1], false)
+}
+
+func (s *Storage) cmdSETRANGE(args []string) []byte {
+	key, value := args[0], args[2]
+	offset, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return Encode(errNotInteger, false)
+	}
+	if offset < 0 {
+		return Encode(errors.New("ERR offset is out of range"), false)
+	}
+	obj, err := s.lookup(key, hash_table.ObjTypeString)
+	if err != nil {
+		return Encode(err, false)
+	}
+
+	var current string
+	if obj != nil {
+		current = stringValue(obj)
+	}
+	if len(value) == 0 {
+		return Encode(len(current), false)
+	}
+	// Compare without adding, offset+len(value) could overflow
+	if offset > int64(config.ProtoMaxBulkLen)-int64(len(value)) {
+		return Encode(errStringTooLong, false)
+	}
+
+	buf := []byte(current)
+	if end := int(offset) + len(value); end > len(buf) {
+		buf = append(buf, make([]byte, end-len(buf))...)
+	}
+	copy(buf[offset:], value)
+	if obj == nil {
+		s.setString(key, string(buf), 0, false)
+	} else {
+		setStringValue(obj, string(buf))
+	}
+	return Encode(len(buf), false)
+}
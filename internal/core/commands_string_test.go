@@ -0,0 +1,121 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetOptions(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "+OK\r\n", execute(s, "SET", "lock", "a", "NX", "PX", "30000"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "SET", "lock", "b", "NX"))
+	assert.EqualValues(t, "$1\r\na\r\n", execute(s, "SET", "lock", "b", "NX", "GET"))
+	assert.EqualValues(t, "$1\r\na\r\n", execute(s, "SET", "lock", "b", "XX", "GET", "KEEPTTL"))
+	assert.NotEqual(t, ":-1\r\n", execute(s, "PTTL", "lock"))
+	assert.EqualValues(t, "+OK\r\n", execute(s, "SET", "lock", "c"))
+	assert.EqualValues(t, ":-1\r\n", execute(s, "PTTL", "lock"))
+
+	assert.EqualValues(t, "$-1\r\n", execute(s, "SET", "missing", "v", "XX"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "SET", "new", "v", "GET", "EXAT", "4102444800"))
+	assert.EqualValues(t, ":4102444800\r\n", execute(s, "EXPIRETIME", "new"))
+
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "SET", "k", "v", "NX", "XX"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "SET", "k", "v", "EX", "10", "PX", "10"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "SET", "k", "v", "KEEPTTL", "EX", "10"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "SET", "k", "v", "EX"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "SET", "k", "v", "FOO"))
+	assert.EqualValues(t, "-ERR invalid expire time in 'set' command\r\n", execute(s, "SET", "k", "v", "EX", "0"))
+	assert.EqualValues(t, "-ERR value is not an integer or out of range\r\n", execute(s, "SET", "k", "v", "EX", "x"))
+
+	execute(s, "SADD", "set", "a")
+	assert.EqualValues(t, wrongType, execute(s, "SET", "set", "v", "GET"))
+}
+
+func TestStringGetSetVariants(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":1\r\n", execute(s, "SETNX", "k", "a"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "SETNX", "k", "b"))
+	assert.EqualValues(t, "$1\r\na\r\n", execute(s, "GETSET", "k", "b"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "GETSET", "other", "b"))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "SETEX", "k", "100", "c"))
+	assert.Contains(t, []string{":99\r\n", ":100\r\n"}, execute(s, "TTL", "k"))
+	assert.EqualValues(t, "-ERR invalid expire time in 'psetex' command\r\n", execute(s, "PSETEX", "k", "-5", "c"))
+
+	assert.EqualValues(t, "$1\r\nc\r\n", execute(s, "GETEX", "k", "PERSIST"))
+	assert.EqualValues(t, ":-1\r\n", execute(s, "TTL", "k"))
+	assert.EqualValues(t, "$1\r\nc\r\n", execute(s, "GETEX", "k", "PXAT", "4102444800000"))
+	assert.EqualValues(t, ":4102444800000\r\n", execute(s, "PEXPIRETIME", "k"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "GETEX", "k", "EX", "1", "PERSIST"))
+
+	assert.EqualValues(t, "$1\r\nc\r\n", execute(s, "GETDEL", "k"))
+	assert.EqualValues(t, "$-1\r\n", execute(s, "GETDEL", "k"))
+}
+
+func TestIncrFamily(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":1\r\n", execute(s, "INCR", "n"))
+	assert.EqualValues(t, ":11\r\n", execute(s, "INCRBY", "n", "10"))
+	assert.EqualValues(t, ":10\r\n", execute(s, "DECR", "n"))
+	assert.EqualValues(t, ":-5\r\n", execute(s, "DECRBY", "n", "15"))
+	assert.EqualValues(t, "$2\r\n-5\r\n", execute(s, "GET", "n"))
+
+	execute(s, "SET", "n", "9223372036854775807", "EX", "100")
+	assert.EqualValues(t, "-ERR increment or decrement would overflow\r\n", execute(s, "INCR", "n"))
+	assert.EqualValues(t, ":9223372036854775806\r\n", execute(s, "DECR", "n"))
+	assert.NotEqual(t, ":-1\r\n", execute(s, "TTL", "n"))
+	assert.EqualValues(t, "-ERR decrement would overflow\r\n", execute(s, "DECRBY", "n", "-9223372036854775808"))
+
+	execute(s, "SET", "s", "012")
+	assert.EqualValues(t, "-ERR value is not an integer or out of range\r\n", execute(s, "INCR", "s"))
+	assert.EqualValues(t, "-ERR value is not an integer or out of range\r\n", execute(s, "INCRBY", "n", "1.5"))
+
+	assert.EqualValues(t, "$4\r\n10.5\r\n", execute(s, "INCRBYFLOAT", "f", "10.5"))
+	assert.EqualValues(t, "$4\r\n5000\r\n", execute(s, "INCRBYFLOAT", "f", "4.9895e3"))
+	assert.EqualValues(t, ":5001\r\n", execute(s, "INCR", "f"))
+	assert.EqualValues(t, "-ERR value is not a valid float\r\n", execute(s, "INCRBYFLOAT", "f", "abc"))
+	execute(s, "SADD", "set", "a")
+	assert.EqualValues(t, wrongType, execute(s, "INCR", "set"))
+}
+
+func TestSubstringCommands(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, ":5\r\n", execute(s, "APPEND", "k", "Hello"))
+	assert.EqualValues(t, ":11\r\n", execute(s, "APPEND", "k", " World"))
+	assert.EqualValues(t, ":11\r\n", execute(s, "STRLEN", "k"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "STRLEN", "missing"))
+
+	assert.EqualValues(t, "$5\r\nHello\r\n", execute(s, "GETRANGE", "k", "0", "4"))
+	assert.EqualValues(t, "$5\r\nWorld\r\n", execute(s, "GETRANGE", "k", "-5", "-1"))
+	assert.EqualValues(t, "$11\r\nHello World\r\n", execute(s, "GETRANGE", "k", "0", "100"))
+	assert.EqualValues(t, "$0\r\n\r\n", execute(s, "GETRANGE", "k", "5", "3"))
+	assert.EqualValues(t, "$0\r\n\r\n", execute(s, "GETRANGE", "missing", "0", "-1"))
+
+	assert.EqualValues(t, ":11\r\n", execute(s, "SETRANGE", "k", "6", "Redis"))
+	assert.EqualValues(t, "$11\r\nHello Redis\r\n", execute(s, "GET", "k"))
+	assert.EqualValues(t, ":3\r\n", execute(s, "SETRANGE", "pad", "2", "x"))
+	assert.EqualValues(t, "$3\r\n\x00\x00x\r\n", execute(s, "GET", "pad"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "SETRANGE", "empty", "5", ""))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "empty"))
+	assert.EqualValues(t, "-ERR offset is out of range\r\n", execute(s, "SETRANGE", "k", "-1", "x"))
+	assert.EqualValues(t, "-ERR string exceeds maximum allowed size (proto-max-bulk-len)\r\n", execute(s, "SETRANGE", "k", "9223372036854775807", "abc"))
+
+	execute(s, "SET", "n", "12")
+	assert.EqualValues(t, ":3\r\n", execute(s, "APPEND", "n", "3"))
+	assert.EqualValues(t, ":124\r\n", execute(s, "INCR", "n"))
+}
+
+func TestMultiKeyStringCommands(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "+OK\r\n", execute(s, "MSET", "a", "1", "b", "2"))
+	execute(s, "SADD", "set", "x")
+	assert.EqualValues(t, "*4\r\n$1\r\n1\r\n$1\r\n2\r\n$-1\r\n$-1\r\n", execute(s, "MGET", "a", "b", "set", "missing"))
+	assert.EqualValues(t, "-ERR wrong number of arguments for 'mset' command\r\n", execute(s, "MSET", "a", "1", "b"))
+
+	assert.EqualValues(t, ":0\r\n", execute(s, "MSETNX", "c", "3", "a", "4"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "c"))
+	assert.EqualValues(t, ":1\r\n", execute(s, "MSETNX", "c", "3", "d", "4"))
+	assert.EqualValues(t, "*2\r\n$1\r\n3\r\n$1\r\n4\r\n", execute(s, "MGET", "c", "d"))
+}
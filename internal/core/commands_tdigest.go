@@ -0,0 +1,304 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/probabilistic"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "TDIGEST.CREATE", Handler: (*Storage).cmdTDIGESTCREATE, Arity: -2, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key [COMPRESSION compression]", Summary: "Create an empty t-digest"},
+		&CommandSpec{Name: "TDIGEST.RESET", Handler: (*Storage).cmdTDIGESTRESET, Arity: 2, Flags: []string{FlagWrite}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key", Summary: "Remove every observation from a t-digest"},
+		&CommandSpec{Name: "TDIGEST.ADD", Handler: (*Storage).cmdTDIGESTADD, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key value [value ...]", Summary: "Add observations to a t-digest"},
+		&CommandSpec{Name: "TDIGEST.MERGE", Handler: (*Storage).cmdTDIGESTMERGE, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM, FlagMovableKeys}, FirstKey: 1, LastKey: 1, Step: 1, KeysFunc: zsetOpStoreKeys, Group: "tdigest", Syntax: "destination numkeys source [source ...] [COMPRESSION compression] [OVERRIDE]", Summary: "Merge t-digests into a destination t-digest"},
+		&CommandSpec{Name: "TDIGEST.MIN", Handler: (*Storage).cmdTDIGESTMIN, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key", Summary: "Return the smallest observation of a t-digest"},
+		&CommandSpec{Name: "TDIGEST.MAX", Handler: (*Storage).cmdTDIGESTMAX, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key", Summary: "Return the largest observation of a t-digest"},
+		&CommandSpec{Name: "TDIGEST.QUANTILE", Handler: (*Storage).cmdTDIGESTQUANTILE, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key quantile [quantile ...]", Summary: "Return the estimated values below which fractions of the observations fall"},
+		&CommandSpec{Name: "TDIGEST.CDF", Handler: (*Storage).cmdTDIGESTCDF, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key value [value ...]", Summary: "Return the estimated fractions of the observations below values"},
+		&CommandSpec{Name: "TDIGEST.RANK", Handler: (*Storage).cmdTDIGESTRANK, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key value [value ...]", Summary: "Return the estimated numbers of observations below values"},
+		&CommandSpec{Name: "TDIGEST.REVRANK", Handler: (*Storage).cmdTDIGESTREVRANK, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key value [value ...]", Summary: "Return the estimated numbers of observations above values"},
+		&CommandSpec{Name: "TDIGEST.TRIMMED_MEAN", Handler: (*Storage).cmdTDIGESTTRIMMEDMEAN, Arity: 4, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key low_cut_quantile high_cut_quantile", Summary: "Return the mean of the observations between two quantiles"},
+		&CommandSpec{Name: "TDIGEST.INFO", Handler: (*Storage).cmdTDIGESTINFO, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "tdigest", Syntax: "key", Summary: "Return the parameters and usage of a t-digest"},
+	)
+}
+
+var (
+	errTDigestNotFound    = errors.New("ERR T-Digest: key does not exist")
+	errTDigestCompression = errors.New("ERR T-Digest: compression parameter needs to be a positive integer no larger than " + strconv.Itoa(constant.TDigestMaxCompression))
+	errTDigestValue       = errors.New("ERR T-Digest: error parsing value")
+)
+
+// existingTDigest returns the t-digest at key, it is an error if there is none
+func (s *Storage) existingTDigest(key string) (probabilistic.QuantileEstimator, error) {
+	td, err := s.lookupTDigest(key)
+	if err != nil {
+		return nil, err
+	}
+	if td == nil {
+		return nil, errTDigestNotFound
+	}
+	return td, nil
+}
+
+func parseTDigestCompression(arg string) (float64, error) {
+	compression, err := strconv.Atoi(arg)
+	if err != nil || compression < 1 || compression > constant.TDigestMaxCompression {
+		return 0, errTDigestCompression
+	}
+	return float64(compression), nil
+}
+
+// parseTDigestValues parses the values of TDIGEST.CDF, RANK and REVRANK
+func parseTDigestValues(args []string) ([]float64, error) {
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil || math.IsNaN(v) {
+			return nil, errTDigestValue
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// formatTDigestFloat formats an estimate, which is NaN when the t-digest is empty
+func formatTDigestFloat(v float64) string {
+	if math.IsNaN(v) {
+		return "nan"
+	}
+	return formatScore(v)
+}
+
+func (s *Storage) cmdTDIGESTCREATE(args []string) []byte {
+	compression := float64(constant.TDigestDefaultCompression)
+	switch {
+	case len(args) == 3 && strings.EqualFold(args[1], "COMPRESSION"):
+		c, err := parseTDigestCompression(args[2])
+		if err != nil {
+			return Encode(err, false)
+		}
+		compression = c
+	case len(args) != 1:
+		return Encode(errSyntax, false)
+	}
+	if s.exists(args[0]) {
+		return Encode(errors.New("ERR T-Digest: key already exists"), false)
+	}
+
+	s.addObj(args[0], hash_table.ObjTypeTDigest, hash_table.ObjEncodingRaw, probabilistic.NewTDigest(compression))
+	return constant.RespOk
+}
+
+func (s *Storage) cmdTDIGESTRESET(args []string) []byte {
+	td, err := s.existingTDigest(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	td.Reset()
+	return constant.RespOk
+}
+
+func (s *Storage) cmdTDIGESTADD(args []string) []byte {
+	td, err := s.existingTDigest(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	// Parse every value first so that the digest is left untouched on error
+	values := make([]float64, len(args)-1)
+	for i, arg := range args[1:] {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return Encode(errors.New("ERR T-Digest: error parsing val parameter"), false)
+		}
+		values[i] = v
+	}
+
+	for _, v := range values {
+		td.Add(v)
+	}
+	return constant.RespOk
+}
+
+func (s *Storage) cmdTDIGESTMERGE(args []string) []byte {
+	keys := numKeysArgs(args, 1)
+	if keys == nil {
+		return Encode(errors.New("ERR T-Digest: numkeys needs to be a positive integer"), false)
+	}
+
+	compression, override := 0.0, false
+	rest := args[2+len(keys):]
+	for i := 0; i < len(rest); i++ {
+		switch {
+		case strings.EqualFold(rest[i], "COMPRESSION") && i+1 < len(rest):
+			c, err := parseTDigestCompression(rest[i+1])
+			if err != nil {
+				return Encode(err, false)
+			}
+			compression = c
+			i++
+		case strings.EqualFold(rest[i], "OVERRIDE"):
+			override = true
+		default:
+			return Encode(errSyntax, false)
+		}
+	}
+
+	dest, err := s.lookup(args[0], hash_table.ObjTypeTDigest)
+	if err != nil {
+		return Encode(err, false)
+	}
+	sources := make([]probabilistic.QuantileEstimator, 0, len(keys)+1)
+	for _, key := range keys {
+		td, err := s.existingTDigest(key)
+		if err != nil {
+			return Encode(err, false)
+		}
+		sources = append(sources, td)
+	}
+
+	// Without COMPRESSION the result keeps the compression of the destination, or the largest of the sources
+	if compression == 0 {
+		if dest != nil {
+			compression = dest.Value.(probabilistic.QuantileEstimator).Compression()
+		} else {
+			for _, td := range sources {
+				compression = math.Max(compression, td.Compression())
+			}
+		}
+	}
+	if dest != nil && !override {
+		sources = append(sources, dest.Value.(probabilistic.QuantileEstimator))
+	}
+
+	merged := probabilistic.NewTDigest(compression)
+	merged.Merge(sources)
+	if dest == nil {
+		s.addObj(args[0], hash_table.ObjTypeTDigest, hash_table.ObjEncodingRaw, merged)
+	} else {
+		dest.Value = merged
+	}
+	return constant.RespOk
+}
+
+func (s *Storage) cmdTDIGESTMIN(args []string) []byte {
+	td, err := s.existingTDigest(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return Encode(formatTDigestFloat(td.Min()), false)
+}
+
+func (s *Storage) cmdTDIGESTMAX(args []string) []byte {
+	td, err := s.existingTDigest(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	return Encode(formatTDigestFloat(td.Max()), false)
+}
+
+func (s *Storage) cmdTDIGESTQUANTILE(args []string) []byte {
+	td, err := s.existingTDigest(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		q, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return Encode(errors.New("ERR T-Digest: error parsing quantile"), false)
+		}
+		if q < 0 || q > 1 {
+			return Encode(errors.New("ERR T-Digest: quantile should be in [0,1]"), false)
+		}
+		res = append(res, formatTDigestFloat(td.Quantile(q)))
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdTDIGESTCDF(args []string) []byte {
+	td, err := s.existingTDigest(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	values, err := parseTDigestValues(args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		res = append(res, formatTDigestFloat(td.CDF(v)))
+	}
+	return Encode(res, false)
+}
+
+// tdigestRank replies with the ranks of values, counted from the largest observation when reverse is set
+func (s *Storage) tdigestRank(args []string, reverse bool) []byte {
+	td, err := s.existingTDigest(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	values, err := parseTDigestValues(args[1:])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		res = append(res, td.Rank(v, reverse))
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdTDIGESTRANK(args []string) []byte {
+	return s.tdigestRank(args, false)
+}
+
+func (s *Storage) cmdTDIGESTREVRANK(args []string) []byte {
+	return s.tdigestRank(args, true)
+}
+
+func (s *Storage) cmdTDIGESTTRIMMEDMEAN(args []string) []byte {
+	td, err := s.existingTDigest(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	low, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return Encode(errors.New("ERR T-Digest: error parsing low_cut_percentile"), false)
+	}
+	high, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return Encode(errors.New("ERR T-Digest: error parsing high_cut_percentile"), false)
+	}
+	if low < 0 || low > 1 || high < 0 || high > 1 {
+		return Encode(errors.New("ERR T-Digest: low_cut_percentile and high_cut_percentile should be in [0,1]"), false)
+	}
+	if low >= high {
+		return Encode(errors.New("ERR T-Digest: low_cut_percentile should be lower than high_cut_percentile"), false)
+	}
+	return Encode(formatTDigestFloat(td.TrimmedMean(low, high)), false)
+}
+
+func (s *Storage) cmdTDIGESTINFO(args []string) []byte {
+	td, err := s.existingTDigest(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	info := td.Info()
+	return Encode([]interface{}{
+		"Compression", int(info.Compression),
+		"Capacity", info.Capacity,
+		"Merged nodes", info.MergedNodes,
+		"Unmerged nodes", info.UnmergedNodes,
+		"Merged weight", int(info.MergedWeight),
+		"Unmerged weight", int(info.UnmergedWeight),
+		"Observations", int(info.Observations),
+		"Total compressions", info.Compressions,
+		"Memory usage", info.MemoryUsage,
+	}, false)
+}
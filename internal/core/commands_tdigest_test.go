@@ -0,0 +1,125 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// tdigestInfo decodes the reply of TDIGEST.INFO into a map of its fields
+func tdigestInfo(t *testing.T, s *core.Storage, key string) map[string]interface{} {
+	value, err := core.Decode([]byte(execute(s, "TDIGEST.INFO", key)))
+	assert.Nil(t, err)
+	pairs := value.([]interface{})
+	info := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		info[pairs[i].(string)] = pairs[i+1]
+	}
+	return info
+}
+
+func TestTDigestCommands(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "-ERR T-Digest: key does not exist\r\n", execute(s, "TDIGEST.ADD", "td", "1"))
+	assert.EqualValues(t, "-ERR T-Digest: key does not exist\r\n", execute(s, "TDIGEST.MIN", "td"))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "TDIGEST.CREATE", "td"))
+	assert.EqualValues(t, "-ERR T-Digest: key already exists\r\n", execute(s, "TDIGEST.CREATE", "td"))
+	assert.EqualValues(t, "+TDIS-TYPE\r\n", execute(s, "TYPE", "td"))
+
+	// An empty t-digest has no estimate
+	assert.EqualValues(t, "$3\r\nnan\r\n", execute(s, "TDIGEST.MIN", "td"))
+	assert.EqualValues(t, "*1\r\n$3\r\nnan\r\n", execute(s, "TDIGEST.QUANTILE", "td", "0.5"))
+	assert.EqualValues(t, "*1\r\n:-2\r\n", execute(s, "TDIGEST.RANK", "td", "1"))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "TDIGEST.ADD", "td", "1", "2", "2", "3", "3", "3", "4", "4", "4", "4", "5", "5", "5", "5", "5"))
+	assert.EqualValues(t, "$1\r\n1\r\n", execute(s, "TDIGEST.MIN", "td"))
+	assert.EqualValues(t, "$1\r\n5\r\n", execute(s, "TDIGEST.MAX", "td"))
+	assert.EqualValues(t, "*5\r\n$1\r\n1\r\n$1\r\n3\r\n$1\r\n4\r\n$1\r\n5\r\n$1\r\n5\r\n", execute(s, "TDIGEST.QUANTILE", "td", "0", "0.25", "0.5", "0.9", "1"))
+	assert.EqualValues(t, "*3\r\n$1\r\n0\r\n$19\r\n0.03333333333333333\r\n$1\r\n1\r\n", execute(s, "TDIGEST.CDF", "td", "0", "1", "6"))
+
+	assert.EqualValues(t, "-ERR T-Digest: error parsing val parameter\r\n", execute(s, "TDIGEST.ADD", "td", "6", "nan"))
+	assert.EqualValues(t, "-ERR T-Digest: quantile should be in [0,1]\r\n", execute(s, "TDIGEST.QUANTILE", "td", "1.5"))
+	assert.EqualValues(t, "-ERR T-Digest: error parsing value\r\n", execute(s, "TDIGEST.CDF", "td", "x"))
+	assert.EqualValues(t, "$1\r\n5\r\n", execute(s, "TDIGEST.MAX", "td"))
+
+	info := tdigestInfo(t, s, "td")
+	assert.EqualValues(t, 100, info["Compression"])
+	assert.EqualValues(t, 500, info["Capacity"])
+	assert.EqualValues(t, 15, info["Merged nodes"])
+	assert.EqualValues(t, 0, info["Unmerged nodes"])
+	assert.EqualValues(t, 15, info["Merged weight"])
+	assert.EqualValues(t, 15, info["Observations"])
+	assert.EqualValues(t, 1, info["Total compressions"])
+	assert.Greater(t, info["Memory usage"], int64(0))
+	assert.Len(t, info, 9)
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "TDIGEST.RESET", "td"))
+	assert.EqualValues(t, "$3\r\nnan\r\n", execute(s, "TDIGEST.MAX", "td"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "TDIGEST.ADD", "str", "1"))
+	assert.EqualValues(t, wrongType, execute(s, "TDIGEST.QUANTILE", "str", "0.5"))
+}
+
+func TestTDigestCreate(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "+OK\r\n", execute(s, "TDIGEST.CREATE", "td", "COMPRESSION", "200"))
+	info := tdigestInfo(t, s, "td")
+	assert.EqualValues(t, 200, info["Compression"])
+	assert.EqualValues(t, 1000, info["Capacity"])
+
+	errCompression := "-ERR T-Digest: compression parameter needs to be a positive integer no larger than 10000\r\n"
+	assert.EqualValues(t, errCompression, execute(s, "TDIGEST.CREATE", "x", "COMPRESSION", "0"))
+	assert.EqualValues(t, errCompression, execute(s, "TDIGEST.CREATE", "x", "COMPRESSION", "9223372036854775807"))
+	assert.EqualValues(t, errCompression, execute(s, "TDIGEST.MERGE", "x", "1", "td", "COMPRESSION", "9223372036854775807"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "TDIGEST.CREATE", "x", "COMPRESSION"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "x"))
+}
+
+func TestTDigestRank(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "TDIGEST.CREATE", "td")
+	execute(s, "TDIGEST.ADD", "td", "10", "20", "30", "40", "50", "60")
+
+	assert.EqualValues(t, "*8\r\n:-1\r\n:0\r\n:1\r\n:2\r\n:3\r\n:4\r\n:5\r\n:6\r\n", execute(s, "TDIGEST.RANK", "td", "0", "10", "20", "30", "40", "50", "60", "70"))
+	assert.EqualValues(t, "*8\r\n:6\r\n:5\r\n:4\r\n:3\r\n:2\r\n:1\r\n:0\r\n:-1\r\n", execute(s, "TDIGEST.REVRANK", "td", "0", "10", "20", "30", "40", "50", "60", "70"))
+
+	assert.EqualValues(t, "$2\r\n35\r\n", execute(s, "TDIGEST.TRIMMED_MEAN", "td", "0", "1"))
+	assert.EqualValues(t, "$2\r\n20\r\n", execute(s, "TDIGEST.TRIMMED_MEAN", "td", "0", "0.5"))
+	assert.EqualValues(t, "-ERR T-Digest: low_cut_percentile should be lower than high_cut_percentile\r\n", execute(s, "TDIGEST.TRIMMED_MEAN", "td", "0.5", "0.5"))
+	assert.EqualValues(t, "-ERR T-Digest: low_cut_percentile and high_cut_percentile should be in [0,1]\r\n", execute(s, "TDIGEST.TRIMMED_MEAN", "td", "0", "2"))
+}
+
+func TestTDigestMerge(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "TDIGEST.CREATE", "a", "COMPRESSION", "50")
+	execute(s, "TDIGEST.CREATE", "b", "COMPRESSION", "200")
+	execute(s, "TDIGEST.ADD", "a", "1", "2", "3")
+	execute(s, "TDIGEST.ADD", "b", "4", "5", "6")
+
+	assert.EqualValues(t, "-ERR T-Digest: key does not exist\r\n", execute(s, "TDIGEST.MERGE", "dest", "2", "a", "missing"))
+	assert.EqualValues(t, "-ERR T-Digest: numkeys needs to be a positive integer\r\n", execute(s, "TDIGEST.MERGE", "dest", "0", "a"))
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "TDIGEST.MERGE", "dest", "1", "a", "WEIGHTS"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "dest"))
+
+	// A new destination takes the largest compression of the sources
+	assert.EqualValues(t, "+OK\r\n", execute(s, "TDIGEST.MERGE", "dest", "2", "a", "b"))
+	assert.EqualValues(t, "*2\r\n$1\r\n1\r\n$1\r\n6\r\n", execute(s, "TDIGEST.QUANTILE", "dest", "0", "1"))
+	assert.EqualValues(t, 200, tdigestInfo(t, s, "dest")["Compression"])
+
+	// An existing destination is part of the merge and keeps its TTL
+	execute(s, "EXPIRE", "dest", "100")
+	assert.EqualValues(t, "+OK\r\n", execute(s, "TDIGEST.MERGE", "dest", "1", "a", "COMPRESSION", "100"))
+	assert.EqualValues(t, "*1\r\n:9\r\n", execute(s, "TDIGEST.RANK", "dest", "7"))
+	assert.EqualValues(t, 100, tdigestInfo(t, s, "dest")["Compression"])
+	assert.Contains(t, []string{":100\r\n", ":99\r\n"}, string(execute(s, "TTL", "dest")))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "TDIGEST.MERGE", "dest", "1", "b", "OVERRIDE"))
+	assert.EqualValues(t, "$1\r\n4\r\n", execute(s, "TDIGEST.MIN", "dest"))
+	assert.EqualValues(t, "*1\r\n:3\r\n", execute(s, "TDIGEST.RANK", "dest", "7"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "TDIGEST.MERGE", "str", "1", "a"))
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/probabilistic"
+)
+
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "TOPK.RESERVE", Handler: (*Storage).cmdTOPKRESERVE, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "topk", Syntax: "key topk [width depth decay]", Summary: "Create a Top-K tracking the topk items with the highest counts"},
+		&CommandSpec{Name: "TOPK.ADD", Handler: (*Storage).cmdTOPKADD, Arity: -3, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "topk", Syntax: "key item [item ...]", Summary: "Add items to a Top-K, return the items expelled from the list"},
+		&CommandSpec{Name: "TOPK.INCRBY", Handler: (*Storage).cmdTOPKINCRBY, Arity: -4, Flags: []string{FlagWrite, FlagDenyOOM}, FirstKey: 1, LastKey: 1, Step: 1, Group: "topk", Syntax: "key item increment [item increment ...]", Summary: "Increase the count of items in a Top-K, return the items expelled from the list"},
+		&CommandSpec{Name: "TOPK.QUERY", Handler: (*Storage).cmdTOPKQUERY, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "topk", Syntax: "key item [item ...]", Summary: "Check whether items are in a Top-K"},
+		&CommandSpec{Name: "TOPK.COUNT", Handler: (*Storage).cmdTOPKCOUNT, Arity: -3, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "topk", Syntax: "key item [item ...]", Summary: "Return the estimated count of items in a Top-K"},
+		&CommandSpec{Name: "TOPK.LIST", Handler: (*Storage).cmdTOPKLIST, Arity: -2, Flags: []string{FlagReadOnly}, FirstKey: 1, LastKey: 1, Step: 1, Group: "topk", Syntax: "key [WITHCOUNT]", Summary: "Return the items of a Top-K, highest count first"},
+		&CommandSpec{Name: "TOPK.INFO", Handler: (*Storage).cmdTOPKINFO, Arity: 2, Flags: []string{FlagReadOnly, FlagFast}, FirstKey: 1, LastKey: 1, Step: 1, Group: "topk", Syntax: "key", Summary: "Return the parameters of a Top-K"},
+	)
+}
+
+// topkMaxIncrement bounds TOPK.INCRBY, every unit of an increment can decay a bucket
+const topkMaxIncrement = 100000
+
+var errTopKNotFound = errors.New("TopK: key does not exist")
+
+// existingTopK returns the Top-K at key, it is an error if there is none
+func (s *Storage) existingTopK(key string) (probabilistic.HeavyHitterTracker, error) {
+	topk, err := s.lookupTopK(key)
+	if err != nil {
+		return nil, err
+	}
+	if topk == nil {
+		return nil, errTopKNotFound
+	}
+	return topk, nil
+}
+
+func (s *Storage) cmdTOPKRESERVE(args []string) []byte {
+	if len(args) != 2 && len(args) != 5 {
+		return errWrongNumberOfArgs("TOPK.RESERVE")
+	}
+	k, err := strconv.Atoi(args[1])
+	if err != nil || k < 1 || k > constant.TopkMaxK {
+		return Encode(errors.New("TopK: invalid k"), false)
+	}
+
+	width, depth, decay := uint64(constant.TopkDefaultWidth), uint64(constant.TopkDefaultDepth), constant.TopkDefaultDecay
+	if len(args) == 5 {
+		if width, err = strconv.ParseUint(args[2], 10, 64); err != nil || width < 1 {
+			return Encode(errors.New("TopK: invalid width"), false)
+		}
+		if depth, err = strconv.ParseUint(args[3], 10, 64); err != nil || depth < 1 {
+			return Encode(errors.New("TopK: invalid depth"), false)
+		}
+		if decay, err = strconv.ParseFloat(args[4], 64); err != nil || decay <= 0 || decay > 1 {
+			return Encode(errors.New("TopK: invalid decay value. must be '<= 1' & '> 0'"), false)
+		}
+		// Checked by division so that width * depth cannot overflow
+		if width > constant.TopkMaxBuckets || depth > constant.TopkMaxBuckets/width {
+			return Encode(errors.New("TopK: width * depth is too large"), false)
+		}
+	}
+	if s.exists(args[0]) {
+		return Encode(errors.New("TopK: key already exists"), false)
+	}
+
+	s.addObj(args[0], hash_table.ObjTypeTopK, hash_table.ObjEncodingRaw, probabilistic.NewTopK(k, width, depth, decay))
+	return constant.RespOk
+}
+
+// topkIncrBy increases the counts of items and replies with the item each one expelled, nil if none
+func topkIncrBy(topk probabilistic.HeavyHitterTracker, items []string, increments []uint64) []byte {
+	res := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		if expelled, ok := topk.IncrBy(item, increments[i]); ok {
+			res = append(res, expelled)
+		} else {
+			res = append(res, nil)
+		}
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdTOPKADD(args []string) []byte {
+	topk, err := s.existingTopK(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	increments := make([]uint64, len(args)-1)
+	for i := range increments {
+		increments[i] = 1
+	}
+	return topkIncrBy(topk, args[1:], increments)
+}
+
+func (s *Storage) cmdTOPKINCRBY(args []string) []byte {
+	if len(args)%2 == 0 {
+		return errWrongNumberOfArgs("TOPK.INCRBY")
+	}
+	topk, err := s.existingTopK(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+
+	var items []string
+	var increments []uint64
+	for i := 1; i < len(args); i += 2 {
+		incr, err := strconv.ParseUint(args[i+1], 10, 64)
+		if err != nil || incr > topkMaxIncrement {
+			return Encode(errors.New("TopK: increment must be an integer greater or equal to 0 and less than or equal to 100,000"), false)
+		}
+		items = append(items, args[i])
+		increments = append(increments, incr)
+	}
+	return topkIncrBy(topk, items, increments)
+}
+
+func (s *Storage) cmdTOPKQUERY(args []string) []byte {
+	topk, err := s.existingTopK(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, 0, len(args)-1)
+	for _, item := range args[1:] {
+		res = append(res, boolReply(topk.Query(item)))
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdTOPKCOUNT(args []string) []byte {
+	topk, err := s.existingTopK(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	res := make([]interface{}, 0, len(args)-1)
+	for _, item := range args[1:] {
+		res = append(res, int(topk.Count(item)))
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdTOPKLIST(args []string) []byte {
+	withCount := false
+	if len(args) == 2 {
+		if !strings.EqualFold(args[1], "WITHCOUNT") {
+			return Encode(errSyntax, false)
+		}
+		withCount = true
+	} else if len(args) > 2 {
+		return Encode(errSyntax, false)
+	}
+	topk, err := s.existingTopK(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+
+	res := []interface{}{}
+	for _, e := range topk.List() {
+		res = append(res, e.Item)
+		if withCount {
+			res = append(res, int(e.Count))
+		}
+	}
+	return Encode(res, false)
+}
+
+func (s *Storage) cmdTOPKINFO(args []string) []byte {
+	topk, err := s.existingTopK(args[0])
+	if err != nil {
+		return Encode(err, false)
+	}
+	info := topk.Info()
+	return Encode([]interface{}{
+		"k", info.K,
+		"width", int(info.Width),
+		"depth", int(info.Depth),
+		"decay", strconv.FormatFloat(info.Decay, 'f', -1, 64),
+	}, false)
+}
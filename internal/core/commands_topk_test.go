@@ -0,0 +1,55 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopKCommands(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "-TopK: key does not exist\r\n", execute(s, "TOPK.ADD", "tk", "a"))
+	assert.EqualValues(t, "-TopK: key does not exist\r\n", execute(s, "TOPK.LIST", "tk"))
+
+	assert.EqualValues(t, "+OK\r\n", execute(s, "TOPK.RESERVE", "tk", "2"))
+	assert.EqualValues(t, "-TopK: key already exists\r\n", execute(s, "TOPK.RESERVE", "tk", "2"))
+	assert.EqualValues(t, "+TopK-TYPE\r\n", execute(s, "TYPE", "tk"))
+	assert.EqualValues(t, "*8\r\n$1\r\nk\r\n:2\r\n$5\r\nwidth\r\n:8\r\n$5\r\ndepth\r\n:7\r\n$5\r\ndecay\r\n$3\r\n0.9\r\n", execute(s, "TOPK.INFO", "tk"))
+
+	assert.EqualValues(t, "*2\r\n$-1\r\n$-1\r\n", execute(s, "TOPK.ADD", "tk", "a", "b"))
+	assert.EqualValues(t, "*1\r\n$-1\r\n", execute(s, "TOPK.INCRBY", "tk", "b", "2"))
+	// c takes the place of a, the item with the lowest count
+	assert.EqualValues(t, "*1\r\n$1\r\na\r\n", execute(s, "TOPK.INCRBY", "tk", "c", "10"))
+
+	assert.EqualValues(t, "*2\r\n$1\r\nc\r\n$1\r\nb\r\n", execute(s, "TOPK.LIST", "tk"))
+	assert.EqualValues(t, "*4\r\n$1\r\nc\r\n:10\r\n$1\r\nb\r\n:3\r\n", execute(s, "TOPK.LIST", "tk", "WITHCOUNT"))
+	assert.EqualValues(t, "*3\r\n:1\r\n:0\r\n:1\r\n", execute(s, "TOPK.QUERY", "tk", "b", "a", "c"))
+	assert.EqualValues(t, "*3\r\n:3\r\n:1\r\n:0\r\n", execute(s, "TOPK.COUNT", "tk", "b", "a", "x"))
+
+	assert.EqualValues(t, "-ERR syntax error\r\n", execute(s, "TOPK.LIST", "tk", "WITHSCORES"))
+	assert.EqualValues(t, "-TopK: increment must be an integer greater or equal to 0 and less than or equal to 100,000\r\n", execute(s, "TOPK.INCRBY", "tk", "a", "100001"))
+	assert.EqualValues(t, "-ERR wrong number of arguments for 'topk.incrby' command\r\n", execute(s, "TOPK.INCRBY", "tk", "a", "1", "b"))
+
+	execute(s, "SET", "str", "v")
+	assert.EqualValues(t, wrongType, execute(s, "TOPK.ADD", "str", "a"))
+	assert.EqualValues(t, wrongType, execute(s, "TOPK.QUERY", "str", "a"))
+}
+
+func TestTopKReserve(t *testing.T) {
+	s := core.NewStorage()
+	assert.EqualValues(t, "+OK\r\n", execute(s, "TOPK.RESERVE", "tk", "10", "100", "5", "0.95"))
+	assert.EqualValues(t, "*8\r\n$1\r\nk\r\n:10\r\n$5\r\nwidth\r\n:100\r\n$5\r\ndepth\r\n:5\r\n$5\r\ndecay\r\n$4\r\n0.95\r\n", execute(s, "TOPK.INFO", "tk"))
+	assert.EqualValues(t, "*0\r\n", execute(s, "TOPK.LIST", "tk"))
+
+	assert.EqualValues(t, "-TopK: invalid k\r\n", execute(s, "TOPK.RESERVE", "x", "0"))
+	assert.EqualValues(t, "-TopK: invalid width\r\n", execute(s, "TOPK.RESERVE", "x", "10", "0", "5", "0.9"))
+	assert.EqualValues(t, "-TopK: invalid depth\r\n", execute(s, "TOPK.RESERVE", "x", "10", "100", "five", "0.9"))
+	assert.EqualValues(t, "-TopK: invalid decay value. must be '<= 1' & '> 0'\r\n", execute(s, "TOPK.RESERVE", "x", "10", "100", "5", "1.5"))
+	assert.EqualValues(t, "-ERR wrong number of arguments for 'topk.reserve' command\r\n", execute(s, "TOPK.RESERVE", "x", "10", "100"))
+	assert.EqualValues(t, "-TopK: invalid k\r\n", execute(s, "TOPK.RESERVE", "x", "9223372036854775807"))
+	// width * depth would wrap around to 0
+	assert.EqualValues(t, "-TopK: width * depth is too large\r\n", execute(s, "TOPK.RESERVE", "x", "1", "4294967296", "4294967296", "0.9"))
+	assert.EqualValues(t, "-TopK: width * depth is too large\r\n", execute(s, "TOPK.RESERVE", "x", "1", "100000", "1000", "0.9"))
+	assert.EqualValues(t, ":0\r\n", execute(s, "EXISTS", "x"))
+}
@@ -2,14 +2,16 @@ package core
 
 import (
 	"errors"
-	"strconv"
 	"syscall"
-	"time"
-
-	"github.com/spaghetti-lover/multithread-redis/internal/constant"
 )
 
-func cmdPING(args []string) []byte {
+func init() {
+	registerCommands(
+		&CommandSpec{Name: "PING", Handler: (*Storage).cmdPING, Arity: -1, Flags: []string{FlagFast}, Group: "connection", Syntax: "[message]", Summary: "Ping the server"},
+	)
+}
+
+func (s *Storage) cmdPING(args []string) []byte {
 	var res []byte
 
 	// edge case
@@ -26,115 +28,39 @@ func cmdPING(args []string) []byte {
 	return res
 }
 
-func cmdSET(args []string) []byte {
-	if len(args) < 2 || len(args) == 3 || len(args) > 4 {
-		return Encode(errors.New("(error) ERR wrong number of arguments for 'SET' command"), false)
+// Execute runs a command against the storage and returns the RESP encoded reply.
+// Both the single-threaded executor and the Worker go through it, so every command
+// behaves the same in both server modes.
+// Blocking commands never wait here and reply as if they timed out, see Worker for how they wait.
+func (s *Storage) Execute(cmd *Command) []byte {
+	spec := LookupCommand(cmd.Cmd)
+	if spec == nil {
+		return errUnknownCommand(cmd)
 	}
-
-	var key, value string
-	var ttlMs int64 = -1
-
-	key, value = args[0], args[1]
-	if len(args) > 2 {
-		if args[2] != "EX" {
-			return Encode(errors.New("(error) ERR syntax error. Must be EX instead of "+args[2]), false)
-		}
-		ttlSec, err := strconv.ParseInt(args[3], 10, 64)
-		if err != nil {
-			return Encode(errors.New("(error) ERR value is not an integer or out of range"), false)
-		}
-		ttlMs = ttlSec * 1000
+	res := s.tryExecute(spec, cmd)
+	if res == nil {
+		return timeoutReply(spec)
 	}
-
-	dictStore.Set(key, dictStore.NewObj(key, value, ttlMs))
-	return constant.RespOk
+	return res
 }
 
-func cmdGET(args []string) []byte {
-	if len(args) != 1 {
-		return Encode(errors.New("(error) ERR wrong number of arguments for 'GET' command"), false)
-	}
-
-	key := args[0]
-	obj := dictStore.Get(key)
-	if obj == nil {
-		return constant.RespNil
-	}
-
-	if dictStore.HasExpired(key) {
-		return constant.RespNil
+// tryExecute runs a command known to the command table. It returns nil when a blocking command would block.
+func (s *Storage) tryExecute(spec *CommandSpec, cmd *Command) []byte {
+	if !spec.CheckArity(cmd.Args) {
+		return errWrongNumberOfArgs(spec.Name)
 	}
-
-	return Encode(obj.Value, false)
+	return spec.Handler(s, cmd.Args)
 }
 
-func cmdTTL(args []string) []byte {
-	if len(args) != 1 {
-		return Encode(errors.New("(error) ERR wrong number of arguments for 'TTL' command"), false)
-	}
-	key := args[0]
-	obj := dictStore.Get(key)
-	if obj == nil {
-		return constant.TtlKeyNotExist
-	}
-
-	exp, isExpirySet := dictStore.GetExpiry(key)
-	if !isExpirySet {
-		return constant.TtlKeyExistNoExpire
-	}
-
-	remainMs := exp - uint64(time.Now().UnixMilli())
-	if exp <= uint64(time.Now().UnixMilli()) {
-		return constant.TtlKeyNotExist
+// ExecuteAndResponse executes pipelined commands in order and writes all their replies at once.
+func ExecuteAndResponse(cmds []*Command, connFd int) error {
+	if len(cmds) == 0 {
+		return nil
 	}
-
-	return Encode(int64(remainMs/1000), false)
-}
-
-func ExecuteAndResponse(cmd *Command, connFd int) error {
 	var res []byte
-
-	switch cmd.Cmd {
-	case "PING":
-		res = cmdPING(cmd.Args)
-	case "SET":
-		res = cmdSET(cmd.Args)
-	case "GET":
-		res = cmdGET(cmd.Args)
-	case "TTL":
-		res = cmdTTL(cmd.Args)
-	case "ZADD":
-		res = cmdZADD(cmd.Args)
-	case "ZSCORE":
-		res = cmdZSCORE(cmd.Args)
-	case "ZRANK":
-		res = cmdZRANK(cmd.Args)
-	case "SADD":
-		res = cmdSADD(cmd.Args)
-	case "SREM":
-		res = cmdSREM(cmd.Args)
-	case "SMEMBERS":
-		res = cmdSMEMBERS(cmd.Args)
-	case "SISMEMBER":
-		res = cmdSISMEMBER(cmd.Args)
-	// Count-min Sketch
-	case "CMS.INITBYDIM":
-		res = cmdCMSINITBYDIM(cmd.Args)
-	case "CMS.INITBYPROB":
-		res = cmdCMSINITBYPROB(cmd.Args)
-	case "CMS.INCRBY":
-		res = cmdCMSINCRBY(cmd.Args)
-	case "CMS.QUERY":
-		res = cmdCMSQUERY(cmd.Args)
-	// INFO
-	case "INFO":
-		res = cmdINFO(cmd.Args)
-	case "HELP":
-		res = cmdHELP()
-	default:
-		res = []byte("-CMD NOT FOUND\r\n")
+	for _, cmd := range cmds {
+		res = append(res, defaultStorage.Execute(cmd)...)
 	}
-
 	_, err := syscall.Write(connFd, res)
 	return err
 }
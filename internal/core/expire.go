@@ -6,18 +6,23 @@ import (
 	"github.com/spaghetti-lover/multithread-redis/internal/constant"
 )
 
+// ActiveDeleteExpiredKeys runs the active expiry cycle on the single-threaded server's storage.
 func ActiveDeleteExpiredKeys() {
+	defaultStorage.ActiveDeleteExpiredKeys()
+}
+
+func (s *Storage) ActiveDeleteExpiredKeys() {
 	for {
 		var expiredCount = 0
 		var sampleCountRemain = constant.ActiveExpireSampleSize
 
-		for key, expiredTime := range dictStore.GetExpireDictStore() {
+		for key, expiredTime := range s.dictStore.GetExpireDictStore() {
 			sampleCountRemain--
 			if sampleCountRemain < 0 {
 				break
 			}
 			if time.Now().UnixMilli() > int64(expiredTime) {
-				dictStore.Del(key)
+				s.dictStore.Del(key)
 				expiredCount++
 			}
 		}
@@ -26,4 +31,28 @@ func ActiveDeleteExpiredKeys() {
 			break
 		}
 	}
+	s.activeDeleteExpiredFields()
+}
+
+// activeDeleteExpiredFields deletes the expired fields of a sample of the hashes having fields with a TTL.
+// Hashes left without fields are deleted, and forgotten once none of their fields has a TTL.
+func (s *Storage) activeDeleteExpiredFields() {
+	sampleCountRemain := constant.ActiveExpireSampleSize
+	for key := range s.fieldExpiryKeys {
+		sampleCountRemain--
+		if sampleCountRemain < 0 {
+			break
+		}
+		h, err := s.lookupHash(key)
+		if err != nil || h == nil {
+			// deleted, overwritten by another type or emptied by the expiry
+			delete(s.fieldExpiryKeys, key)
+			continue
+		}
+		h.DeleteExpired()
+		s.deleteIfEmptyHash(key, h)
+		if !h.HasExpiringFields() {
+			delete(s.fieldExpiryKeys, key)
+		}
+	}
 }
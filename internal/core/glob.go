@@ -0,0 +1,89 @@
+package core
+
+// matchGlob reports whether s matches a Redis glob-style pattern:
+// '*' matches any sequence, '?' any single character, '[abc]', '[^abc]' and '[a-z]' a character class,
+// and '\' escapes the next character.
+func matchGlob(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchGlob(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			var matched bool
+			matched, pattern = matchClass(pattern[1:], s[0])
+			if !matched {
+				return false
+			}
+			s = s[1:]
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// matchClass matches c against the character class at the start of pattern (just after '[')
+// and returns the rest of the pattern after the closing ']'.
+func matchClass(pattern string, c byte) (bool, string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			if pattern[1] == c {
+				matched = true
+			}
+			pattern = pattern[2:]
+		case len(pattern) >= 3 && pattern[1] == '-' && pattern[2] != ']':
+			start, end := pattern[0], pattern[2]
+			if start > end {
+				start, end = end, start
+			}
+			if c >= start && c <= end {
+				matched = true
+			}
+			pattern = pattern[3:]
+		default:
+			if pattern[0] == c {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+	if len(pattern) > 0 {
+		pattern = pattern[1:] // skip ']'
+	}
+	return matched != negate, pattern
+}
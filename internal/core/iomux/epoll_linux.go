@@ -13,6 +13,7 @@ type Epoll struct {
 	fd            int
 	epollEvents   []syscall.EpollEvent
 	genericEvents []Event
+	interests     map[int]uint32 // Events currently monitored for each fd
 }
 
 func CreateIOMultiplexer() (*Epoll, error) {
@@ -25,18 +26,49 @@ func CreateIOMultiplexer() (*Epoll, error) {
 	return &Epoll{
 		fd:            epollFD,
 		epollEvents:   make([]syscall.EpollEvent, config.MaxConnection),
-		genericEvents: make([]Event, config.MaxConnection),
+		genericEvents: make([]Event, 0, 2*config.MaxConnection),
+		interests:     make(map[int]uint32),
 	}, nil
 }
 
+func (ep *Epoll) ctl(fd int, events uint32) error {
+	old, registered := ep.interests[fd]
+	if registered && old == events {
+		return nil
+	}
+
+	var err error
+	switch {
+	case events == 0:
+		err = syscall.EpollCtl(ep.fd, syscall.EPOLL_CTL_DEL, fd, nil)
+		delete(ep.interests, fd)
+	case registered:
+		epollEvent := syscall.EpollEvent{Fd: int32(fd), Events: events}
+		err = syscall.EpollCtl(ep.fd, syscall.EPOLL_CTL_MOD, fd, &epollEvent)
+		if err == syscall.ENOENT {
+			// The fd was closed and reused without being unmonitored
+			err = syscall.EpollCtl(ep.fd, syscall.EPOLL_CTL_ADD, fd, &epollEvent)
+		}
+	default:
+		epollEvent := syscall.EpollEvent{Fd: int32(fd), Events: events}
+		err = syscall.EpollCtl(ep.fd, syscall.EPOLL_CTL_ADD, fd, &epollEvent)
+	}
+	if err == nil && events != 0 {
+		ep.interests[fd] = events
+	}
+	return err
+}
+
 func (ep *Epoll) Monitor(event Event) error {
-	epollEvent := event.toNative()
-	// Add event.Fd to the monitoring list of ep.fd
-	return syscall.EpollCtl(ep.fd, syscall.EPOLL_CTL_ADD, event.Fd, &epollEvent)
+	// Add event.Fd to the monitoring list of ep.fd, or extend the events watched on it
+	return ep.ctl(event.Fd, ep.interests[event.Fd]|event.toNative())
 }
 
 func (ep *Epoll) Unmonitor(event Event) error {
-	return syscall.EpollCtl(ep.fd, syscall.EPOLL_CTL_DEL, event.Fd, nil)
+	if _, registered := ep.interests[event.Fd]; !registered {
+		return syscall.EpollCtl(ep.fd, syscall.EPOLL_CTL_DEL, event.Fd, nil)
+	}
+	return ep.ctl(event.Fd, ep.interests[event.Fd]&^event.toNative())
 }
 
 func (ep *Epoll) Wait() ([]Event, error) {
@@ -44,11 +76,12 @@ func (ep *Epoll) Wait() ([]Event, error) {
 	if err != nil {
 		return nil, err
 	}
+	ep.genericEvents = ep.genericEvents[:0]
 	for i := 0; i < n; i++ {
-		ep.genericEvents[i] = createEvent(ep.epollEvents[i])
+		ep.genericEvents = appendEvents(ep.genericEvents, ep.epollEvents[i])
 	}
 
-	return ep.genericEvents[:n], nil
+	return ep.genericEvents, nil
 }
 
 func (ep *Epoll) Close() error {
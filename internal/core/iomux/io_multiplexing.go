@@ -11,7 +11,11 @@ type Event struct {
 }
 
 type IOMultiplexer interface {
+	// Monitor adds the operation to the ones watched on the fd. Read and write can be monitored together.
 	Monitor(event Event) error
+	// Unmonitor stops watching the operation on the fd.
+	Unmonitor(event Event) error
+	// Wait blocks until some fds are ready. An fd ready for both read and write yields two events.
 	Wait() ([]Event, error)
 	Close() error
 }
@@ -4,24 +4,21 @@ package iomux
 
 import "syscall"
 
-func (e Event) toNative() syscall.EpollEvent {
-	var event uint32 = syscall.EPOLLIN
+func (e Event) toNative() uint32 {
 	if e.Op == OpWrite {
-		event = syscall.EPOLLOUT
-	}
-	return syscall.EpollEvent{
-		Fd:     int32(e.Fd),
-		Events: event,
+		return syscall.EPOLLOUT
 	}
+	return syscall.EPOLLIN
 }
 
-func createEvent(ep syscall.EpollEvent) Event {
-	var op Operation = OpRead
-	if ep.Events == syscall.EPOLLOUT {
-		op = OpWrite
+// appendEvents converts a native event into one generic event per ready operation.
+// Hang-ups and errors are reported as read events so the reader sees EOF or the error.
+func appendEvents(events []Event, ep syscall.EpollEvent) []Event {
+	if ep.Events&(syscall.EPOLLIN|syscall.EPOLLHUP|syscall.EPOLLERR) != 0 {
+		events = append(events, Event{Fd: int(ep.Fd), Op: OpRead})
 	}
-	return Event{
-		Fd: int(ep.Fd),
-		Op: op,
+	if ep.Events&syscall.EPOLLOUT != 0 {
+		events = append(events, Event{Fd: int(ep.Fd), Op: OpWrite})
 	}
+	return events
 }
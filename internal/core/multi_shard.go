@@ -0,0 +1,17 @@
+package core
+
+// ExecuteOnShards runs a command whose keys are owned by several storages as if they were in one.
+// The keys are moved into a scratch storage, the command runs there, and every key left afterwards is
+// moved back to the storage owning it, so writes and deletions land on the right shard.
+// The caller must have exclusive access to all the owner storages, see Worker.Pause.
+func ExecuteOnShards(cmd *Command, owners map[string]*Storage) []byte {
+	scratch := NewStorage()
+	for key, owner := range owners {
+		owner.moveKey(key, scratch, key)
+	}
+	res := scratch.Execute(cmd)
+	for key, owner := range owners {
+		scratch.moveKey(key, owner, key)
+	}
+	return res
+}
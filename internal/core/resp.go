@@ -59,7 +59,7 @@ func readLen(data []byte) (int, int) {
 func readBulkString(data []byte) (string, int, error) {
 	length, pos := readLen(data)
 	if length == -1 {
-		return "Null value", pos + 2, nil
+		return "Null value", pos, nil
 	}
 	return string(data[pos:(pos + length)]), pos + length + 2, nil
 }
@@ -67,6 +67,9 @@ func readBulkString(data []byte) (string, int, error) {
 // *2\r\n$5\r\nhello\r\n$5\r\nworld\r\n => {"hello", "world"}
 func readArray(data []byte) (interface{}, int, error) {
 	length, pos := readLen(data)
+	if length == -1 {
+		return nil, pos, nil
+	}
 	var res []interface{} = make([]interface{}, length)
 
 	// implement start
@@ -107,6 +110,36 @@ func Decode(data []byte) (interface{}, error) {
 	return res, err
 }
 
+// DecodeArrayElements splits an encoded array into the raw encoding of each of its elements,
+// so replies can be regrouped without losing the difference between nil and other values.
+func DecodeArrayElements(data []byte) ([][]byte, error) {
+	if len(data) == 0 || data[0] != '*' {
+		return nil, errors.New("not an array")
+	}
+	length, pos := readLen(data)
+	elements := make([][]byte, 0, max(length, 0))
+	for i := 0; i < length; i++ {
+		_, delta, err := DecodeOne(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, data[pos:pos+delta])
+		pos += delta
+	}
+	return elements, nil
+}
+
+// EncodeArrayElements joins already encoded elements into an array.
+func EncodeArrayElements(elements [][]byte) []byte {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	fmt.Fprintf(buf, "*%d\r\n", len(elements))
+	for _, e := range elements {
+		buf.Write(e)
+	}
+	return buf.Bytes()
+}
+
 func encodeString(s string) []byte {
 	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(s), s))
 }
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/config"
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+)
+
+// ProtocolError is returned by RespReader when a client sends a malformed request.
+// The client gets it as an error reply, then the connection is closed.
+type ProtocolError struct {
+	msg string
+}
+
+func (e *ProtocolError) Error() string {
+	return "ERR Protocol error: " + e.msg
+}
+
+var (
+	errProtoInvalidMultiBulkLen = &ProtocolError{"invalid multibulk length"}
+	errProtoInvalidBulkLen      = &ProtocolError{"invalid bulk length"}
+	errProtoBigMultiBulkCount   = &ProtocolError{"too big mbulk count string"}
+	errProtoBigBulkCount        = &ProtocolError{"too big bulk count string"}
+	errProtoBigInline           = &ProtocolError{"too big inline request"}
+)
+
+// RespReader accumulates the bytes received on one client connection and splits them into commands.
+// Frames may arrive in any number of pieces, and one read may contain many pipelined commands.
+type RespReader struct {
+	buf []byte
+	pos int // Start of the unparsed data in buf
+
+	// State of the multibulk command being parsed, kept across reads so a partial frame is never parsed twice
+	multiBulkLen int // Number of bulk strings still missing, 0 if no command is in progress
+	bulkLen      int // Length of the next bulk string, -1 if its header has not been read yet
+	args         []string
+}
+
+func NewRespReader() *RespReader {
+	return &RespReader{bulkLen: -1}
+}
+
+// Feed appends data read from the connection to the reader's buffer.
+func (r *RespReader) Feed(data []byte) {
+	r.buf = append(r.buf, data...)
+}
+
+// ReadCommands returns every complete command in the buffer, in order. A partial command stays buffered
+// until the rest of it is fed. On a protocol error the commands parsed before it are returned along
+// with the error, and the connection is expected to be closed after replying with it.
+func (r *RespReader) ReadCommands() ([]*Command, error) {
+	var cmds []*Command
+	for r.pos < len(r.buf) {
+		args, complete, err := r.parseCommand()
+		if err != nil {
+			r.reset()
+			return cmds, err
+		}
+		if !complete {
+			break
+		}
+		if len(args) > 0 {
+			cmds = append(cmds, &Command{Cmd: strings.ToUpper(args[0]), Args: args[1:]})
+		}
+	}
+	r.compact()
+	return cmds, nil
+}
+
+func (r *RespReader) reset() {
+	r.buf = r.buf[:0]
+	r.pos = 0
+	r.multiBulkLen = 0
+	r.bulkLen = -1
+	r.args = nil
+}
+
+// compact drops the parsed bytes so the buffer only holds the pending partial command.
+func (r *RespReader) compact() {
+	if r.pos == 0 {
+		return
+	}
+	n := copy(r.buf, r.buf[r.pos:])
+	r.buf = r.buf[:n]
+	r.pos = 0
+}
+
+func (r *RespReader) parseCommand() ([]string, bool, error) {
+	if r.multiBulkLen == 0 {
+		if r.buf[r.pos] != '*' {
+			return r.parseInline()
+		}
+		n, complete, err := r.readLengthLine(errProtoBigMultiBulkCount)
+		if err != nil || !complete {
+			return nil, false, err
+		}
+		if n > int64(config.ProtoMaxMultiBulkLen) {
+			return nil, false, errProtoInvalidMultiBulkLen
+		}
+		if n <= 0 {
+			// *0 and *-1 are empty commands which are silently skipped
+			return nil, true, nil
+		}
+		r.multiBulkLen = int(n)
+		r.args = make([]string, 0, min(r.multiBulkLen, 1024))
+	}
+
+	for r.multiBulkLen > 0 {
+		if r.bulkLen == -1 {
+			if r.pos == len(r.buf) {
+				return nil, false, nil
+			}
+			if r.buf[r.pos] != '$' {
+				return nil, false, &ProtocolError{fmt.Sprintf("expected '$', got '%c'", r.buf[r.pos])}
+			}
+			n, complete, err := r.readLengthLine(errProtoBigBulkCount)
+			if err != nil || !complete {
+				return nil, false, err
+			}
+			if n < 0 || n > int64(config.ProtoMaxBulkLen) {
+				return nil, false, errProtoInvalidBulkLen
+			}
+			r.bulkLen = int(n)
+		}
+
+		if len(r.buf)-r.pos < r.bulkLen+2 {
+			return nil, false, nil
+		}
+		r.args = append(r.args, string(r.buf[r.pos:r.pos+r.bulkLen]))
+		r.pos += r.bulkLen + 2
+		r.bulkLen = -1
+		r.multiBulkLen--
+	}
+
+	args := r.args
+	r.args = nil
+	return args, true, nil
+}
+
+// readLengthLine parses a "*<n>\r\n" or "$<n>\r\n" header at the current position.
+func (r *RespReader) readLengthLine(errTooBig error) (int64, bool, error) {
+	end := bytes.Index(r.buf[r.pos:], []byte(CRLF))
+	if end == -1 {
+		if len(r.buf)-r.pos > constant.ProtoInlineMaxSize {
+			return 0, false, errTooBig
+		}
+		return 0, false, nil
+	}
+	line := r.buf[r.pos+1 : r.pos+end]
+	n, err := strconv.ParseInt(string(line), 10, 64)
+	if err != nil {
+		if r.buf[r.pos] == '*' {
+			return 0, false, errProtoInvalidMultiBulkLen
+		}
+		return 0, false, errProtoInvalidBulkLen
+	}
+	r.pos += end + len(CRLF)
+	return n, true, nil
+}
+
+// parseInline parses a telnet style command terminated by a newline, e.g. "PING\r\n".
+func (r *RespReader) parseInline() ([]string, bool, error) {
+	end := bytes.IndexByte(r.buf[r.pos:], '\n')
+	if end == -1 {
+		if len(r.buf)-r.pos > constant.ProtoInlineMaxSize {
+			return nil, false, errProtoBigInline
+		}
+		return nil, false, nil
+	}
+	line := bytes.TrimSuffix(r.buf[r.pos:r.pos+end], []byte("\r"))
+	r.pos += end + 1
+	return strings.Fields(string(line)), true, nil
+}
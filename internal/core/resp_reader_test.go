@@ -0,0 +1,73 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespReaderPipeline(t *testing.T) {
+	r := core.NewRespReader()
+	r.Feed([]byte("*1\r\n$4\r\nPING\r\n*3\r\n$3\r\nset\r\n$1\r\nk\r\n$1\r\nv\r\nGET k\r\n"))
+	cmds, err := r.ReadCommands()
+	assert.NoError(t, err)
+	assert.Len(t, cmds, 3)
+	assert.EqualValues(t, &core.Command{Cmd: "PING", Args: []string{}}, cmds[0])
+	assert.EqualValues(t, &core.Command{Cmd: "SET", Args: []string{"k", "v"}}, cmds[1])
+	assert.EqualValues(t, &core.Command{Cmd: "GET", Args: []string{"k"}}, cmds[2])
+}
+
+func TestRespReaderPartialFrames(t *testing.T) {
+	r := core.NewRespReader()
+	frame := "*2\r\n$3\r\nGET\r\n$10\r\n0123456789\r\n"
+	for i := 0; i < len(frame)-1; i++ {
+		r.Feed([]byte{frame[i]})
+		cmds, err := r.ReadCommands()
+		assert.NoError(t, err)
+		assert.Empty(t, cmds)
+	}
+	r.Feed([]byte{frame[len(frame)-1]})
+	cmds, err := r.ReadCommands()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*core.Command{{Cmd: "GET", Args: []string{"0123456789"}}}, cmds)
+}
+
+func TestRespReaderLargeBulk(t *testing.T) {
+	r := core.NewRespReader()
+	value := make([]byte, 4096)
+	for i := range value {
+		value[i] = 'x'
+	}
+	r.Feed([]byte("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$4096\r\n"))
+	r.Feed(value)
+	r.Feed([]byte("\r\n"))
+	cmds, err := r.ReadCommands()
+	assert.NoError(t, err)
+	assert.Len(t, cmds, 1)
+	assert.EqualValues(t, string(value), cmds[0].Args[1])
+}
+
+func TestRespReaderProtocolErrors(t *testing.T) {
+	cases := map[string]string{
+		"*abc\r\n":                   "ERR Protocol error: invalid multibulk length",
+		"*99999999999\r\n":           "ERR Protocol error: invalid multibulk length",
+		"*1\r\n$-5\r\n":              "ERR Protocol error: invalid bulk length",
+		"*1\r\n$999999999999\r\n":    "ERR Protocol error: invalid bulk length",
+		"*1\r\n+PING\r\n":            "ERR Protocol error: expected '$', got '+'",
+		"*1\r\n$4\r\nPING\r\n*x\r\n": "ERR Protocol error: invalid multibulk length",
+	}
+	for input, expected := range cases {
+		r := core.NewRespReader()
+		r.Feed([]byte(input))
+		_, err := r.ReadCommands()
+		assert.EqualError(t, err, expected, input)
+	}
+
+	// Commands before the malformed frame are still returned
+	r := core.NewRespReader()
+	r.Feed([]byte("*1\r\n$4\r\nPING\r\n*x\r\n"))
+	cmds, err := r.ReadCommands()
+	assert.Error(t, err)
+	assert.Len(t, cmds, 1)
+}
@@ -1,20 +1,134 @@
 package core
 
 import (
+	"errors"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_map"
 	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
 	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/probabilistic"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/quicklist"
 	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/simple_set"
 	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/sorted_set"
 )
 
-var dictStore *hash_table.Dict
-var zsetStore map[string]*sorted_set.SortedSet
-var setStore map[string]*simple_set.SimpleSet
-var cmsStore map[string]probabilistic.FrequencyEstimator
+// Storage holds one keyspace. Values of every type live in the same dict, tagged with their type,
+// so expiry and eviction apply to all of them. The single-threaded server uses defaultStorage,
+// while each Worker of the multi-threaded server owns its own shard.
+type Storage struct {
+	dictStore *hash_table.Dict
+
+	// Keys of the hashes having fields with a TTL, visited by the active expiry cycle
+	fieldExpiryKeys map[string]struct{}
+}
+
+func NewStorage() *Storage {
+	return &Storage{
+		dictStore:       hash_table.CreateDict(),
+		fieldExpiryKeys: make(map[string]struct{}),
+	}
+}
+
+var defaultStorage = NewStorage()
+
+var errWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// lookup returns the live object stored at key, nil if there is none.
+// It fails with errWrongType if the object is not of type typ.
+func (s *Storage) lookup(key string, typ hash_table.ObjType) (*hash_table.Obj, error) {
+	obj := s.dictStore.Get(key)
+	if obj == nil {
+		return nil, nil
+	}
+	if obj.Type != typ {
+		return nil, errWrongType
+	}
+	return obj, nil
+}
+
+// addObj stores a new value without TTL at key, replacing whatever the key holds.
+func (s *Storage) addObj(key string, typ hash_table.ObjType, encoding hash_table.ObjEncoding, value interface{}) *hash_table.Obj {
+	obj := s.dictStore.NewObj(key, value, -1)
+	obj.Type = typ
+	obj.Encoding = encoding
+	s.dictStore.Set(key, obj)
+	return obj
+}
+
+func (s *Storage) lookupZSet(key string) (*sorted_set.SortedSet, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeZSet)
+	if obj == nil {
+		return nil, err
+	}
+	return obj.Value.(*sorted_set.SortedSet), nil
+}
+
+func (s *Storage) lookupSet(key string) (*simple_set.SimpleSet, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeSet)
+	if obj == nil {
+		return nil, err
+	}
+	return obj.Value.(*simple_set.SimpleSet), nil
+}
+
+func (s *Storage) lookupCMS(key string) (probabilistic.FrequencyEstimator, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeCMS)
+	if obj == nil {
+		return nil, err
+	}
+	return obj.Value.(probabilistic.FrequencyEstimator), nil
+}
+
+func (s *Storage) lookupBloom(key string) (probabilistic.MembershipTester, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeBloom)
+	if obj == nil {
+		return nil, err
+	}
+	return obj.Value.(probabilistic.MembershipTester), nil
+}
+
+func (s *Storage) lookupCuckoo(key string) (probabilistic.DeletableMembershipTester, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeCuckoo)
+	if obj == nil {
+		return nil, err
+	}
+	return obj.Value.(probabilistic.DeletableMembershipTester), nil
+}
+
+func (s *Storage) lookupTopK(key string) (probabilistic.HeavyHitterTracker, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeTopK)
+	if obj == nil {
+		return nil, err
+	}
+	return obj.Value.(probabilistic.HeavyHitterTracker), nil
+}
+
+func (s *Storage) lookupTDigest(key string) (probabilistic.QuantileEstimator, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeTDigest)
+	if obj == nil {
+		return nil, err
+	}
+	return obj.Value.(probabilistic.QuantileEstimator), nil
+}
+
+func (s *Storage) lookupList(key string) (*quicklist.QuickList, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeList)
+	if obj == nil {
+		return nil, err
+	}
+	return obj.Value.(*quicklist.QuickList), nil
+}
 
-func init() {
-	dictStore = hash_table.CreateDict()
-	zsetStore = make(map[string]*sorted_set.SortedSet)
-	setStore = make(map[string]*simple_set.SimpleSet)
-	cmsStore = make(map[string]probabilistic.FrequencyEstimator)
+// lookupHash returns the hash stored at key. Expired fields are deleted as they are accessed or by the
+// active expiry cycle, not here, and a hash left without fields is deleted.
+func (s *Storage) lookupHash(key string) (*hash_map.HashMap, error) {
+	obj, err := s.lookup(key, hash_table.ObjTypeHash)
+	if obj == nil {
+		return nil, err
+	}
+	h := obj.Value.(*hash_map.HashMap)
+	if h.Empty() {
+		s.dictStore.Del(key)
+		return nil, nil
+	}
+	return h, nil
 }
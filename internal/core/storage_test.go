@@ -0,0 +1,29 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+const wrongType = "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+
+func TestWrongType(t *testing.T) {
+	s := core.NewStorage()
+	execute(s, "SET", "str", "v")
+	execute(s, "SADD", "set", "a")
+	execute(s, "ZADD", "zset", "1", "a")
+
+	assert.EqualValues(t, wrongType, execute(s, "SADD", "str", "a"))
+	assert.EqualValues(t, wrongType, execute(s, "ZADD", "set", "1", "a"))
+	assert.EqualValues(t, wrongType, execute(s, "GET", "zset"))
+	assert.EqualValues(t, wrongType, execute(s, "SISMEMBER", "zset", "a"))
+	assert.EqualValues(t, wrongType, execute(s, "ZSCORE", "str", "a"))
+	assert.EqualValues(t, "-CMS: key already exists\r\n", execute(s, "CMS.INITBYDIM", "set", "10", "2"))
+	assert.EqualValues(t, wrongType, execute(s, "CMS.QUERY", "set", "a"))
+
+	// SET overwrites a value of any type.
+	assert.EqualValues(t, "+OK\r\n", execute(s, "SET", "set", "v"))
+	assert.EqualValues(t, "+string\r\n", execute(s, "TYPE", "set"))
+}
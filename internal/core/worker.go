@@ -2,24 +2,46 @@ package core
 
 import (
 	"context"
-	"errors"
 	"log"
-	"strconv"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/spaghetti-lover/multithread-redis/internal/constant"
-	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/hash_table"
 )
 
 type Task struct {
-	Command *Command
-	ReplyCh chan []byte // Channel to send the result back to the client's handler
+	Command  *Command
+	ClientID uint64      // Connection the command was received on
+	Seq      uint64      // Position of the command in the client's pipeline
+	ReplyCh  chan *Reply // Channel to send the result back to the client's handler
+
+	pause      *pauseRequest // Set on the internal tasks queued by Worker.Pause
+	clientGone bool          // Set on the internal tasks queued by Worker.ClientGone
+}
+
+type pauseRequest struct {
+	storageCh chan *Storage
+	release   <-chan struct{}
+}
+
+// Reply carries the result of a task back to the handler owning the client connection.
+type Reply struct {
+	Task *Task
+	Data []byte
+}
+
+// Reply sends the result of the task to its ReplyCh.
+func (t *Task) Reply(data []byte) {
+	t.ReplyCh <- &Reply{Task: t, Data: data}
 }
 
 type Worker struct {
 	id        int
-	dictStore *hash_table.Dict
-	TaskCh    chan *Task         // Receives tasks from the I/O handler
+	storage   *Storage       // Keyspace shard owned exclusively by this worker
+	blocking  *blockingState // Clients blocked on keys of the shard
+	TaskCh    chan *Task     // Receives tasks from the I/O handler
+	timeoutCh chan *blockedClient
 	ctx       context.Context    // Use context to manage goroutine
 	cancel    context.CancelFunc // Set `Context` object's internal state to `canceled`. It closes the `Done()` channel of that Context
 	waitGroup *sync.WaitGroup
@@ -28,8 +50,10 @@ type Worker struct {
 func NewWorker(id int, bufferSize int) *Worker {
 	w := &Worker{
 		id:        id,
-		dictStore: hash_table.CreateDict(),
+		storage:   NewStorage(),
+		blocking:  newBlockingState(),
 		TaskCh:    make(chan *Task, bufferSize),
+		timeoutCh: make(chan *blockedClient, bufferSize),
 		ctx:       context.Background(),
 		cancel:    nil,
 		waitGroup: &sync.WaitGroup{},
@@ -51,91 +75,74 @@ func (w *Worker) Stop() {
 	w.waitGroup.Wait()
 }
 
-func (w *Worker) cmdPING(args []string) []byte {
-	var res []byte
-
-	if len(args) > 1 {
-		return Encode(errors.New("ERR wrong number of arguments for 'ping' command"), false)
-	}
-
-	if len(args) == 0 {
-		res = Encode("PONG", true)
-	} else {
-		res = Encode(args[0], false)
-	}
-	return res
-
+// Pause queues a request to park the worker. Once every task queued before it has run, the worker sends
+// its storage on the returned channel and executes nothing else until release is closed, so the caller
+// has exclusive access to the storage in the meantime.
+func (w *Worker) Pause(release <-chan struct{}) <-chan *Storage {
+	storageCh := make(chan *Storage, 1)
+	w.TaskCh <- &Task{pause: &pauseRequest{storageCh: storageCh, release: release}}
+	return storageCh
 }
 
-func (w *Worker) cmdSET(args []string) []byte {
-	if len(args) < 2 || len(args) == 3 || len(args) > 4 {
-		return Encode(errors.New("(error) ERR wrong number of arguments for 'SET' command"), false)
-	}
-
-	var key, value string
-	var ttlMs int64 = -1
-
-	key, value = args[0], args[1]
-	if len(args) > 2 {
-		ttlSec, err := strconv.ParseInt(args[3], 10, 64)
-		if err != nil {
-			return Encode(errors.New("(error) ERR value is not an integer or out of range"), false)
+func (w *Worker) ExecuteAndResponse(task *Task) {
+	log.Printf("worker %d executes command %s", w.id, task.Command)
+	spec := LookupCommand(task.Command.Cmd)
+	if spec == nil || !spec.HasFlag(FlagBlocking) {
+		task.Reply(w.storage.Execute(task.Command))
+		if spec != nil && spec.HasFlag(FlagWrite) {
+			w.serveKeys(spec.GetKeys(task.Command.Args))
 		}
-		ttlMs = ttlSec * 1000
+		return
 	}
 
-	w.dictStore.Set(key, w.dictStore.NewObj(key, value, ttlMs))
-	return constant.RespOk
-}
-
-func (w *Worker) cmdGET(args []string) []byte {
-	if len(args) != 1 {
-		return Encode(errors.New("(error) ERR wrong number of arguments for 'GET' command"), false)
-	}
-
-	key := args[0]
-	obj := w.dictStore.Get(key)
-	if obj == nil {
-		return constant.RespNil
-	}
-
-	if w.dictStore.HasExpired(key) {
-		return constant.RespNil
-	}
-
-	return Encode(obj.Value, false)
-}
-
-func (w *Worker) ExecuteAndResponse(task *Task) {
-	log.Printf("worker %d executes command %s", w.id, task.Command)
-	var res []byte
-
-	switch task.Command.Cmd {
-	case "SET":
-		res = w.cmdSET(task.Command.Args)
-	case "GET":
-		res = w.cmdGET(task.Command.Args)
-	case "PING":
-		res = w.cmdPING(task.Command.Args)
-	default:
-		res = []byte("-CMD NOT FOUND\r\n")
+	res := w.storage.tryExecute(spec, task.Command)
+	if res == nil {
+		w.block(task, spec)
+		return
 	}
-	task.ReplyCh <- res
+	task.Reply(res)
+	w.serveKeys(spec.GetKeys(task.Command.Args))
 }
 
 func (w *Worker) run(ctx context.Context) {
 	defer w.waitGroup.Done()
+	// The shard is only touched from this goroutine, so the active expiry cycle runs here as well
+	expireTicker := time.NewTicker(constant.ActiveExpireFrequency)
+	defer expireTicker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			log.Printf("Worker %d shutting down gracefully", w.id)
 			return
 
+		case <-expireTicker.C:
+			w.storage.ActiveDeleteExpiredKeys()
+
+		case bc := <-w.timeoutCh:
+			w.unblock(bc)
+
 		case task, ok := <-w.TaskCh:
 			if !ok {
 				log.Printf("Worker %d channel closed, shutting down", w.id)
 				return
 			}
+			if task.pause != nil {
+				task.pause.storageCh <- w.storage
+				select {
+				case <-task.pause.release:
+				case <-ctx.Done():
+					return
+				}
+				// A command run on several shards may have pushed to a key someone waits on
+				w.serveAllBlocked()
+				continue
+			}
+			if task.clientGone {
+				for _, bc := range slices.Clone(w.blocking.byClient[task.ClientID]) {
+					w.unblock(bc)
+				}
+				continue
+			}
 			w.ExecuteAndResponse(task)
 		}
 
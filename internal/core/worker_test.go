@@ -0,0 +1,44 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func execOnWorker(w *core.Worker, cmd string, args ...string) string {
+	replyCh := make(chan *core.Reply, 1)
+	w.TaskCh <- &core.Task{Command: &core.Command{Cmd: cmd, Args: args}, ReplyCh: replyCh}
+	return string((<-replyCh).Data)
+}
+
+func TestWorkerCommandParity(t *testing.T) {
+	w := core.NewWorker(0, 16)
+	w.Start(context.Background())
+	defer w.Stop()
+
+	assert.EqualValues(t, ":2\r\n", execOnWorker(w, "ZADD", "zs", "1", "a", "2", "b"))
+	assert.EqualValues(t, ":1\r\n", execOnWorker(w, "ZRANK", "zs", "b"))
+	assert.EqualValues(t, ":2\r\n", execOnWorker(w, "SADD", "s", "x", "y"))
+	assert.EqualValues(t, ":1\r\n", execOnWorker(w, "SISMEMBER", "s", "x"))
+	assert.EqualValues(t, "+OK\r\n", execOnWorker(w, "CMS.INITBYDIM", "c", "100", "5"))
+	assert.EqualValues(t, "*1\r\n$1\r\n3\r\n", execOnWorker(w, "CMS.INCRBY", "c", "item", "3"))
+	assert.EqualValues(t, "+OK\r\n", execOnWorker(w, "SET", "k", "v"))
+	assert.EqualValues(t, ":-1\r\n", execOnWorker(w, "TTL", "k"))
+	assert.EqualValues(t, ":-2\r\n", execOnWorker(w, "TTL", "missing"))
+}
+
+func TestWorkerStoragesAreIsolated(t *testing.T) {
+	w1 := core.NewWorker(1, 16)
+	w2 := core.NewWorker(2, 16)
+	w1.Start(context.Background())
+	w2.Start(context.Background())
+	defer w1.Stop()
+	defer w2.Stop()
+
+	execOnWorker(w1, "SADD", "s", "x")
+	assert.EqualValues(t, ":1\r\n", execOnWorker(w1, "SISMEMBER", "s", "x"))
+	assert.EqualValues(t, ":0\r\n", execOnWorker(w2, "SISMEMBER", "s", "x"))
+}
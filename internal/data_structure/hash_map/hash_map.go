@@ -0,0 +1,153 @@
+package hash_map
+
+import (
+	"time"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/scan_index"
+)
+
+// HashMap is the value of a hash key: a map of fields to values where each field may have its own
+// expiry. Expired fields are removed when they are accessed, or by DeleteExpired.
+type HashMap struct {
+	fields  map[string]string
+	expires map[string]uint64 // Expiry of the fields that have one, in Unix milliseconds
+	index   scan_index.ScanIndex
+}
+
+func NewHashMap() *HashMap {
+	return &HashMap{
+		fields:  make(map[string]string),
+		expires: make(map[string]uint64),
+	}
+}
+
+func nowMs() uint64 {
+	return uint64(time.Now().UnixMilli())
+}
+
+// expireIfNeeded deletes field if its expiry has passed, and reports whether it did.
+func (h *HashMap) expireIfNeeded(field string) bool {
+	exp, ok := h.expires[field]
+	if !ok || exp > nowMs() {
+		return false
+	}
+	h.remove(field)
+	return true
+}
+
+func (h *HashMap) remove(field string) {
+	delete(h.fields, field)
+	delete(h.expires, field)
+	h.index.Remove(field)
+}
+
+// HSET, returns true if the field is new. The value replaces the field along with its expiry.
+func (h *HashMap) Set(field, value string) bool {
+	h.expireIfNeeded(field)
+	_, exist := h.fields[field]
+	if !exist {
+		h.index.Add(field)
+	}
+	h.fields[field] = value
+	delete(h.expires, field)
+	return !exist
+}
+
+// HGET
+func (h *HashMap) Get(field string) (string, bool) {
+	if h.expireIfNeeded(field) {
+		return "", false
+	}
+	value, exist := h.fields[field]
+	return value, exist
+}
+
+// HDEL
+func (h *HashMap) Del(field string) bool {
+	if h.expireIfNeeded(field) {
+		return false
+	}
+	if _, exist := h.fields[field]; !exist {
+		return false
+	}
+	h.remove(field)
+	return true
+}
+
+// HLEN
+func (h *HashMap) Len() int {
+	h.DeleteExpired()
+	return len(h.fields)
+}
+
+// Empty reports whether the hash has no field, without deleting the expired ones first.
+func (h *HashMap) Empty() bool {
+	return len(h.fields) == 0
+}
+
+// All returns every field with its value, in no particular order.
+func (h *HashMap) All() map[string]string {
+	h.DeleteExpired()
+	return h.fields
+}
+
+// Fields returns the names of every field, in no particular order.
+func (h *HashMap) Fields() []string {
+	h.DeleteExpired()
+	res := make([]string, 0, len(h.fields))
+	for field := range h.fields {
+		res = append(res, field)
+	}
+	return res
+}
+
+// SetExpiry sets the expiry of an existing field, in Unix milliseconds.
+func (h *HashMap) SetExpiry(field string, expireAtMs uint64) {
+	h.expires[field] = expireAtMs
+}
+
+// Expiry returns the expiry of a field, in Unix milliseconds, and whether it has one.
+func (h *HashMap) Expiry(field string) (uint64, bool) {
+	exp, ok := h.expires[field]
+	return exp, ok
+}
+
+// Persist removes the expiry of a field and reports whether it had one.
+func (h *HashMap) Persist(field string) bool {
+	if _, ok := h.expires[field]; !ok {
+		return false
+	}
+	delete(h.expires, field)
+	return true
+}
+
+// HasExpiringFields reports whether any field has an expiry.
+func (h *HashMap) HasExpiringFields() bool {
+	return len(h.expires) > 0
+}
+
+// DeleteExpired deletes every expired field and returns how many were deleted.
+func (h *HashMap) DeleteExpired() int {
+	if len(h.expires) == 0 {
+		return 0
+	}
+	now := nowMs()
+	deleted := 0
+	for field, exp := range h.expires {
+		if exp <= now {
+			h.remove(field)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// Scan returns the fields of the next HSCAN batch from cursor and the cursor to continue from, 0 when
+// the iteration is over. Expired fields may be returned, Get deletes them. See ScanIndex.Scan.
+func (h *HashMap) Scan(cursor uint64, count int) ([]string, uint64) {
+	var batch []string
+	next := h.index.Scan(cursor, count, func(field string) {
+		batch = append(batch, field)
+	})
+	return batch, next
+}
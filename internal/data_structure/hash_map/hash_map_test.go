@@ -0,0 +1,59 @@
+package hash_map
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashMap(t *testing.T) {
+	h := NewHashMap()
+	assert.True(t, h.Set("a", "1"))
+	assert.True(t, h.Set("b", "2"))
+	assert.False(t, h.Set("a", "3"))
+
+	v, ok := h.Get("a")
+	assert.True(t, ok)
+	assert.EqualValues(t, "3", v)
+	_, ok = h.Get("missing")
+	assert.False(t, ok)
+
+	fields := h.Fields()
+	sort.Strings(fields)
+	assert.EqualValues(t, []string{"a", "b"}, fields)
+
+	assert.True(t, h.Del("a"))
+	assert.False(t, h.Del("a"))
+	assert.EqualValues(t, 1, h.Len())
+}
+
+func TestHashMapFieldExpiry(t *testing.T) {
+	h := NewHashMap()
+	h.Set("past", "1")
+	h.Set("future", "2")
+	h.Set("kept", "3")
+	h.SetExpiry("past", nowMs()-1)
+	h.SetExpiry("future", nowMs()+100000)
+	h.SetExpiry("kept", nowMs()+100000)
+
+	_, ok := h.Get("past")
+	assert.False(t, ok)
+	assert.True(t, h.Persist("kept"))
+	assert.False(t, h.Persist("kept"))
+
+	// Overwriting a field drops its expiry
+	h.Set("future", "4")
+	_, ok = h.Expiry("future")
+	assert.False(t, ok)
+	assert.False(t, h.HasExpiringFields())
+
+	// Only the accessed field expires, the others wait for DeleteExpired
+	h.SetExpiry("kept", nowMs()-1)
+	h.SetExpiry("future", nowMs()-1)
+	_, ok = h.Get("kept")
+	assert.False(t, ok)
+	assert.False(t, h.Empty())
+	assert.EqualValues(t, 1, h.DeleteExpired())
+	assert.True(t, h.Empty())
+}
@@ -5,9 +5,42 @@ import (
 	"time"
 
 	"github.com/spaghetti-lover/multithread-redis/internal/config"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/scan_index"
+)
+
+// ObjType is the data type of the value held by an Obj. Every type shares the same keyspace,
+// so a key holds exactly one type at a time.
+type ObjType uint8
+
+const (
+	ObjTypeString ObjType = iota
+	ObjTypeZSet
+	ObjTypeSet
+	ObjTypeCMS
+	ObjTypeList
+	ObjTypeHash
+	ObjTypeBloom
+	ObjTypeCuckoo
+	ObjTypeTopK
+	ObjTypeTDigest
+)
+
+// ObjEncoding is the internal representation of the value, reported by OBJECT ENCODING.
+type ObjEncoding uint8
+
+const (
+	ObjEncodingRaw ObjEncoding = iota
+	ObjEncodingInt
+	ObjEncodingHT
+	ObjEncodingBTree
+	ObjEncodingSkiplist
+	ObjEncodingQuicklist
+	ObjEncodingIntset
 )
 
 type Obj struct {
+	Type           ObjType
+	Encoding       ObjEncoding
 	Value          interface{}
 	LastAccessTime uint32
 }
@@ -15,6 +48,7 @@ type Obj struct {
 type Dict struct {
 	dictStore        map[string]*Obj
 	expiredDictStore map[string]uint64
+	keys             scan_index.ScanIndex // Every key of dictStore, for SCAN and RANDOMKEY
 }
 
 func CreateDict() *Dict {
@@ -65,6 +99,18 @@ func (d *Dict) SetExpiry(key string, ttlMs int64) {
 	d.expiredDictStore[key] = uint64(time.Now().UnixMilli()) + uint64(ttlMs)
 }
 
+// SetExpiryAt sets the absolute expiry time of a key, in Unix milliseconds.
+func (d *Dict) SetExpiryAt(key string, expireAtMs uint64) {
+	d.expiredDictStore[key] = expireAtMs
+}
+
+// Flush removes every key.
+func (d *Dict) Flush() {
+	d.dictStore = make(map[string]*Obj)
+	d.expiredDictStore = make(map[string]uint64)
+	d.keys.Reset()
+}
+
 func (d *Dict) HasExpired(key string) bool {
 	exp, exist := d.expiredDictStore[key]
 	if !exist {
@@ -89,6 +135,9 @@ func (d *Dict) Set(k string, obj *Obj) {
 	if len(d.dictStore) >= config.MaxKeyNumber {
 		d.evict()
 	}
+	if _, exist := d.dictStore[k]; !exist {
+		d.keys.Add(k)
+	}
 	d.dictStore[k] = obj
 }
 
@@ -120,11 +169,46 @@ func (d *Dict) Del(k string) bool {
 	if _, exist := d.dictStore[k]; exist {
 		delete(d.dictStore, k)
 		delete(d.expiredDictStore, k)
+		d.keys.Remove(k)
 		return true
 	}
 	return false
 }
 
+// Len returns the number of keys, including the expired keys not deleted yet.
+func (d *Dict) Len() int {
+	return len(d.dictStore)
+}
+
+// Scan returns the keys of the next SCAN batch from cursor and the cursor to continue from, 0 when
+// the iteration is over. Expired keys are skipped and deleted. See ScanIndex.Scan for the guarantees.
+func (d *Dict) Scan(cursor uint64, count int) ([]string, uint64) {
+	var batch []string
+	next := d.keys.Scan(cursor, count, func(key string) {
+		batch = append(batch, key)
+	})
+	res := batch[:0]
+	for _, key := range batch {
+		if d.HasExpired(key) {
+			d.Del(key)
+			continue
+		}
+		res = append(res, key)
+	}
+	return res, next
+}
+
+// RandomKey returns a random key, deleting the expired keys it picks, and false if there is none left.
+func (d *Dict) RandomKey() (string, bool) {
+	for {
+		key, ok := d.keys.Random()
+		if !ok || !d.HasExpired(key) {
+			return key, ok
+		}
+		d.Del(key)
+	}
+}
+
 // ExpiringKeysCount returns number of keys that currently have a TTL and are not expired.
 // It does not clean up stale TTLs and expired keys.
 func (d *Dict) ExpiringKeysCount() int {
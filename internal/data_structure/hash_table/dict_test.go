@@ -73,3 +73,28 @@ func TestDictGetExpiry(t *testing.T) {
 		t.Errorf("expected expiry in the future, got %v", exp)
 	}
 }
+
+func TestDictScan(t *testing.T) {
+	d := CreateDict()
+	d.Set("live", d.NewObj("live", "v", 0))
+	d.Set("gone", d.NewObj("gone", "v", 1))
+	d.Set("live", d.NewObj("live", "v2", 0))
+	time.Sleep(5 * time.Millisecond)
+
+	// The expired key still counts until a scan or a random pick deletes it
+	if d.Len() != 2 {
+		t.Errorf("expected 2 keys, got %d", d.Len())
+	}
+	keys, cursor := d.Scan(0, 10)
+	if len(keys) != 1 || keys[0] != "live" || cursor != 0 {
+		t.Errorf("expected [live] and cursor 0, got %v and %d", keys, cursor)
+	}
+	if key, ok := d.RandomKey(); !ok || key != "live" || d.Len() != 1 {
+		t.Errorf("expected live to be the only key left, got %q", key)
+	}
+
+	d.Del("live")
+	if _, ok := d.RandomKey(); ok {
+		t.Errorf("expected no random key in an empty dict")
+	}
+}
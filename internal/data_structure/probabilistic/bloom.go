@@ -1,6 +1,7 @@
 package probabilistic
 
 import (
+	"errors"
 	"math"
 
 	"github.com/spaolacci/murmur3"
@@ -20,8 +21,19 @@ type Bloom struct {
 	bf          []uint64
 	bits        uint64 // size of bf in bits
 	words       uint64 // number of 64-bit words in bf
+	items       uint64 // number of added entries that were not already in the filter
 }
 
+// MaxBloomBits bounds the size of a Bloom filter, or of a sub-filter of a scalable one, to 512 MB
+const MaxBloomBits uint64 = 1 << 32
+
+var (
+	// ErrFilterFull is returned when adding a new item to a filter that reached its capacity and does not scale
+	ErrFilterFull = errors.New("non scaling filter is full")
+	// ErrFilterTooLarge is returned when a scalable filter would need a sub-filter larger than MaxBloomBits
+	ErrFilterTooLarge = errors.New("filter is too large")
+)
+
 type HashValue struct {
 	a uint64
 	b uint64
@@ -32,7 +44,17 @@ func calcBpe(err float64) float64 {
 	return math.Abs(-(num / Ln2Square))
 }
 
+// BloomFilterFits reports whether a filter of entries items at errorRate takes at most MaxBloomBits.
+// The constructors do not check it, callers must.
+func BloomFilterFits(entries uint64, errorRate float64) bool {
+	return float64(entries)*calcBpe(errorRate) <= float64(MaxBloomBits)
+}
+
 func NewBloomFilter(entries uint64, errorRate float64) MembershipTester {
+	return newBloom(entries, errorRate)
+}
+
+func newBloom(entries uint64, errorRate float64) *Bloom {
 	bloom := &Bloom{
 		Entries: entries,
 		Error:   errorRate,
@@ -61,15 +83,21 @@ func (b *Bloom) CalcHash(entry string) HashValue {
 	return HashValue{a: x, b: y}
 }
 
-func (b *Bloom) Add(entry string) {
+// Add reports whether entry is new, it may have been added before when no bit had to be set.
+// Once the filter holds Entries items, new entries are refused with ErrFilterFull.
+func (b *Bloom) Add(entry string) (bool, error) {
 	initHash := b.CalcHash(entry)
-
-	for i := 0; i < b.Hashes; i++ {
-		hash := (initHash.a + initHash.b*uint64(i)) % b.bits
-		word := hash >> 6  // chia 64
-		bit := hash & 0x3F // mod 64 (mask 6 bit)
-		b.bf[word] |= 1 << bit
+	if b.ExistHash(initHash) {
+		return false, nil
 	}
+	if b.full() {
+		return false, ErrFilterFull
+	}
+	return b.AddHash(initHash), nil
+}
+
+func (b *Bloom) full() bool {
+	return b.items >= b.Entries
 }
 
 func (b *Bloom) Exist(entry string) bool {
@@ -85,13 +113,21 @@ func (b *Bloom) Exist(entry string) bool {
 	return true
 }
 
-func (b *Bloom) AddHash(initHash HashValue) {
+func (b *Bloom) AddHash(initHash HashValue) bool {
+	added := false
 	for i := 0; i < b.Hashes; i++ {
 		hash := (initHash.a + initHash.b*uint64(i)) % b.bits
-		word := hash >> 6
-		bit := hash & 0x3F
-		b.bf[word] |= 1 << bit
+		word := hash >> 6  // chia 64
+		bit := hash & 0x3F // mod 64 (mask 6 bit)
+		if b.bf[word]&(1<<bit) == 0 {
+			b.bf[word] |= 1 << bit
+			added = true
+		}
+	}
+	if added {
+		b.items++
 	}
+	return added
 }
 
 func (b *Bloom) ExistHash(initHash HashValue) bool {
@@ -105,3 +141,13 @@ func (b *Bloom) ExistHash(initHash HashValue) bool {
 	}
 	return true
 }
+
+func (b *Bloom) Info() FilterInfo {
+	return FilterInfo{
+		Capacity: b.Entries,
+		Size:     b.words * 8,
+		Filters:  1,
+		Items:    b.items,
+		Hashes:   b.Hashes,
+	}
+}
@@ -2,6 +2,7 @@ package probabilistic
 
 import (
 	"math/rand"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -73,3 +74,43 @@ func TestBloomFalsePositiveRate(t *testing.T) {
 		t.Errorf("false positive rate too high: got %.4f, want ≤ %.2f", fpRate, errorRate)
 	}
 }
+
+func TestBloomAddReportsNewItems(t *testing.T) {
+	bf := NewBloomFilter(100, 0.01)
+
+	if added, err := bf.Add("golang"); !added || err != nil {
+		t.Errorf("expected first Add of 'golang' to report a new item, got %v %v", added, err)
+	}
+	if added, err := bf.Add("golang"); added || err != nil {
+		t.Errorf("expected second Add of 'golang' to report an existing item, got %v %v", added, err)
+	}
+	bf.Add("rust")
+
+	info := bf.Info()
+	if info.Items != 2 {
+		t.Errorf("expected 2 items, got %d", info.Items)
+	}
+	if info.Capacity != 100 || info.Filters != 1 || info.Hashes != 7 || info.Expansion != 0 {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if info.Size == 0 || info.Size%8 != 0 {
+		t.Errorf("expected size to be a whole number of 64-bit words, got %d", info.Size)
+	}
+}
+
+func TestBloomFull(t *testing.T) {
+	bf := NewBloomFilter(10, 0.01)
+	for i := 0; i < 10; i++ {
+		if _, err := bf.Add(strconv.Itoa(i)); err != nil {
+			t.Fatalf("unexpected error before the filter is full: %v", err)
+		}
+	}
+
+	if _, err := bf.Add("new"); err != ErrFilterFull {
+		t.Errorf("expected ErrFilterFull, got %v", err)
+	}
+	// Items already in the filter can still be added
+	if added, err := bf.Add("3"); added || err != nil {
+		t.Errorf("expected existing item to be accepted, got %v %v", added, err)
+	}
+}
@@ -1,14 +1,19 @@
 package probabilistic
 
 import (
+	"errors"
 	"math"
 
 	"github.com/spaolacci/murmur3"
 )
 
+// ErrCMSDimMismatch is returned when merging sketches of different dimensions
+var ErrCMSDimMismatch = errors.New("width/depth is not equal")
+
 type CMS struct {
 	width   uint64
 	depth   uint64
+	count   uint64   // total of all increments
 	counter []uint64 // 1D slides: index = i*width + j for better cache locality than 2D array
 }
 
@@ -42,8 +47,26 @@ func (c *CMS) calcHash(item string, seed uint32) uint64 {
 	return hasher.Sum64()
 }
 
+// addSaturated returns a + b, or math.MaxUint64 if it overflows.
+func addSaturated(a, b uint64) uint64 {
+	if math.MaxUint64-a < b {
+		return math.MaxUint64
+	}
+	return a + b
+}
+
+// mulSaturated returns a * b, or math.MaxUint64 if it overflows.
+func mulSaturated(a, b uint64) uint64 {
+	if a != 0 && b > math.MaxUint64/a {
+		return math.MaxUint64
+	}
+	return a * b
+}
+
 // IncrBy increments an item by value and returns the estimated count.
 func (c *CMS) IncrBy(item string, value uint64) uint64 {
+	c.count = addSaturated(c.count, value)
+
 	var minCount uint64 = math.MaxUint64
 	for i := uint64(0); i < c.depth; i++ {
 		hash := c.calcHash(item, uint32(i))
@@ -78,3 +101,32 @@ func (c *CMS) Count(item string) uint64 {
 	}
 	return minCount
 }
+
+// Merge replaces the sketch with the sum of sketches, each multiplied by its weight.
+// The sketch itself can be one of sketches.
+func (c *CMS) Merge(sketches []FrequencyEstimator, weights []uint64) error {
+	sources := make([]*CMS, len(sketches))
+	for i, sketch := range sketches {
+		src, ok := sketch.(*CMS)
+		if !ok || src.width != c.width || src.depth != c.depth {
+			return ErrCMSDimMismatch
+		}
+		sources[i] = src
+	}
+
+	counter := make([]uint64, len(c.counter))
+	var count uint64
+	for i, src := range sources {
+		for idx, v := range src.counter {
+			counter[idx] = addSaturated(counter[idx], mulSaturated(v, weights[i]))
+		}
+		count = addSaturated(count, mulSaturated(src.count, weights[i]))
+	}
+	c.counter = counter
+	c.count = count
+	return nil
+}
+
+func (c *CMS) Info() CMSInfo {
+	return CMSInfo{Width: c.width, Depth: c.depth, Count: c.count}
+}
@@ -75,3 +75,51 @@ func TestDifferentItemsIndependence(t *testing.T) {
 		t.Errorf("Expected x >= y, got x=%d, y=%d", countX, countY)
 	}
 }
+
+func TestMerge(t *testing.T) {
+	a := NewCMS(100, 5)
+	b := NewCMS(100, 5)
+	a.IncrBy("apple", 3)
+	b.IncrBy("apple", 2)
+	b.IncrBy("banana", 4)
+
+	dest := NewCMS(100, 5)
+	if err := dest.Merge([]FrequencyEstimator{a, b}, []uint64{1, 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := dest.Count("apple"); got < 23 {
+		t.Errorf("Expected count >= 23 for 'apple', got %d", got)
+	}
+	if got := dest.Count("banana"); got < 40 {
+		t.Errorf("Expected count >= 40 for 'banana', got %d", got)
+	}
+	if info := dest.Info(); info.Width != 100 || info.Depth != 5 || info.Count != 63 {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	// A sketch can be merged into itself
+	if err := a.Merge([]FrequencyEstimator{a, b}, []uint64{2, 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info := a.Info(); info.Count != 12 {
+		t.Errorf("Expected total count 12, got %d", info.Count)
+	}
+
+	if err := dest.Merge([]FrequencyEstimator{NewCMS(50, 5)}, []uint64{1}); err != ErrCMSDimMismatch {
+		t.Errorf("Expected ErrCMSDimMismatch, got %v", err)
+	}
+}
+
+func TestMergeOverflow(t *testing.T) {
+	a := NewCMS(10, 2)
+	a.IncrBy("apple", math.MaxUint64/2)
+
+	dest := NewCMS(10, 2)
+	dest.Merge([]FrequencyEstimator{a}, []uint64{3})
+	if got := dest.Count("apple"); got != math.MaxUint64 {
+		t.Errorf("Expected count to saturate at MaxUint64, got %d", got)
+	}
+	if info := dest.Info(); info.Count != math.MaxUint64 {
+		t.Errorf("Expected total count to saturate at MaxUint64, got %d", info.Count)
+	}
+}
@@ -0,0 +1,254 @@
+package probabilistic
+
+import (
+	"errors"
+	"math/bits"
+	"math/rand"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// MaxCuckooSlots bounds the number of fingerprints of a sub-filter, so to 512 MB
+const MaxCuckooSlots uint64 = 1 << 29
+
+// ErrCuckooFull is returned when an item finds no room and the filter does not expand, or cannot expand any more
+var ErrCuckooFull = errors.New("Filter is full")
+
+// fingerprint is the part of an item hash stored in a bucket, 0 marks an empty slot
+type fingerprint uint8
+
+// cuckooTable is one sub-filter: numBuckets buckets of bucketSize fingerprints, stored flat.
+// numBuckets is a power of two so that altIndex can go back and forth between the two buckets of an item.
+type cuckooTable struct {
+	slots      []fingerprint
+	numBuckets uint64
+	bucketSize int
+}
+
+func newCuckooTable(numBuckets uint64, bucketSize int) *cuckooTable {
+	return &cuckooTable{
+		slots:      make([]fingerprint, numBuckets*uint64(bucketSize)),
+		numBuckets: numBuckets,
+		bucketSize: bucketSize,
+	}
+}
+
+func (t *cuckooTable) bucket(i uint64) []fingerprint {
+	start := i * uint64(t.bucketSize)
+	return t.slots[start : start+uint64(t.bucketSize)]
+}
+
+// indexes returns the two buckets an item with hash h and fingerprint fp can be stored in
+func (t *cuckooTable) indexes(h uint64, fp fingerprint) (uint64, uint64) {
+	i1 := h & (t.numBuckets - 1)
+	return i1, t.altIndex(i1, fp)
+}
+
+func (t *cuckooTable) altIndex(i uint64, fp fingerprint) uint64 {
+	return (i ^ (uint64(fp) * 0x5bd1e995)) & (t.numBuckets - 1)
+}
+
+// insert stores fp in an empty slot of bucket i
+func (t *cuckooTable) insert(i uint64, fp fingerprint) bool {
+	b := t.bucket(i)
+	for j := range b {
+		if b[j] == 0 {
+			b[j] = fp
+			return true
+		}
+	}
+	return false
+}
+
+// delete removes one copy of fp from bucket i
+func (t *cuckooTable) delete(i uint64, fp fingerprint) bool {
+	b := t.bucket(i)
+	for j := range b {
+		if b[j] == fp {
+			b[j] = 0
+			return true
+		}
+	}
+	return false
+}
+
+func (t *cuckooTable) count(i uint64, fp fingerprint) uint64 {
+	var n uint64
+	for _, f := range t.bucket(i) {
+		if f == fp {
+			n++
+		}
+	}
+	return n
+}
+
+// kick makes room for fp by relocating fingerprints between their two buckets, at most maxIterations times.
+// When no room is found every move is undone, so no fingerprint already in the table is lost.
+func (t *cuckooTable) kick(i uint64, fp fingerprint, maxIterations int) bool {
+	type move struct {
+		slot uint64
+		prev fingerprint
+	}
+	moves := make([]move, 0, maxIterations)
+
+	for n := 0; n < maxIterations; n++ {
+		slot := i*uint64(t.bucketSize) + uint64(rand.Intn(t.bucketSize))
+		moves = append(moves, move{slot: slot, prev: t.slots[slot]})
+		fp, t.slots[slot] = t.slots[slot], fp
+
+		i = t.altIndex(i, fp)
+		if t.insert(i, fp) {
+			return true
+		}
+	}
+
+	for n := len(moves) - 1; n >= 0; n-- {
+		t.slots[moves[n].slot] = moves[n].prev
+	}
+	return false
+}
+
+// Cuckoo is a Cuckoo filter: like a Bloom filter it answers whether an item may have been added,
+// but it stores a fingerprint of every item so that items can also be deleted and counted.
+// When an item finds no room a new sub-filter, expansion times larger, is added unless expansion is 0.
+type Cuckoo struct {
+	tables        []*cuckooTable
+	bucketSize    int
+	maxIterations int
+	expansion     int
+	items         uint64
+	deleted       uint64
+}
+
+// cuckooBuckets returns the number of buckets of bucketSize fingerprints that hold capacity items
+func cuckooBuckets(capacity uint64, bucketSize int) uint64 {
+	return nextPowerOfTwo((capacity + uint64(bucketSize) - 1) / uint64(bucketSize))
+}
+
+// CuckooFilterFits reports whether a filter of capacity items takes at most MaxCuckooSlots.
+// NewCuckooFilter does not check it, callers must.
+func CuckooFilterFits(capacity uint64, bucketSize int) bool {
+	return capacity <= MaxCuckooSlots && cuckooBuckets(capacity, bucketSize)*uint64(bucketSize) <= MaxCuckooSlots
+}
+
+func NewCuckooFilter(capacity uint64, bucketSize, maxIterations, expansion int) DeletableMembershipTester {
+	return &Cuckoo{
+		tables:        []*cuckooTable{newCuckooTable(cuckooBuckets(capacity, bucketSize), bucketSize)},
+		bucketSize:    bucketSize,
+		maxIterations: maxIterations,
+		expansion:     expansion,
+	}
+}
+
+func nextPowerOfTwo(n uint64) uint64 {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len64(n-1)
+}
+
+func cuckooHash(item string) (uint64, fingerprint) {
+	h := murmur3.Sum64WithSeed([]byte(item), ABigSeed)
+	fp := fingerprint(h >> 56)
+	if fp == 0 {
+		fp = 1
+	}
+	return h, fp
+}
+
+func (c *Cuckoo) Add(item string) error {
+	h, fp := cuckooHash(item)
+
+	// An empty slot in any sub-filter will do, the newest ones are the emptiest
+	for n := len(c.tables) - 1; n >= 0; n-- {
+		t := c.tables[n]
+		i1, i2 := t.indexes(h, fp)
+		if t.insert(i1, fp) || t.insert(i2, fp) {
+			c.items++
+			return nil
+		}
+	}
+
+	last := c.tables[len(c.tables)-1]
+	i1, _ := last.indexes(h, fp)
+	if last.kick(i1, fp, c.maxIterations) {
+		c.items++
+		return nil
+	}
+	// Checked by division first so that the size of the next sub-filter cannot overflow
+	if c.expansion == 0 || last.numBuckets > MaxCuckooSlots/uint64(c.expansion) {
+		return ErrCuckooFull
+	}
+	numBuckets := nextPowerOfTwo(last.numBuckets * uint64(c.expansion))
+	if numBuckets*uint64(c.bucketSize) > MaxCuckooSlots {
+		return ErrCuckooFull
+	}
+
+	last = newCuckooTable(numBuckets, c.bucketSize)
+	c.tables = append(c.tables, last)
+	i1, _ = last.indexes(h, fp)
+	last.insert(i1, fp)
+	c.items++
+	return nil
+}
+
+func (c *Cuckoo) AddNX(item string) (bool, error) {
+	if c.Exist(item) {
+		return false, nil
+	}
+	return true, c.Add(item)
+}
+
+func (c *Cuckoo) Exist(item string) bool {
+	h, fp := cuckooHash(item)
+	for _, t := range c.tables {
+		i1, i2 := t.indexes(h, fp)
+		if t.count(i1, fp) > 0 || t.count(i2, fp) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cuckoo) Count(item string) uint64 {
+	h, fp := cuckooHash(item)
+	var n uint64
+	for _, t := range c.tables {
+		i1, i2 := t.indexes(h, fp)
+		n += t.count(i1, fp)
+		if i2 != i1 {
+			n += t.count(i2, fp)
+		}
+	}
+	return n
+}
+
+func (c *Cuckoo) Del(item string) bool {
+	h, fp := cuckooHash(item)
+	for n := len(c.tables) - 1; n >= 0; n-- {
+		t := c.tables[n]
+		i1, i2 := t.indexes(h, fp)
+		if t.delete(i1, fp) || t.delete(i2, fp) {
+			c.items--
+			c.deleted++
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cuckoo) Info() CuckooInfo {
+	info := CuckooInfo{
+		Filters:       len(c.tables),
+		Items:         c.items,
+		Deleted:       c.deleted,
+		BucketSize:    c.bucketSize,
+		Expansion:     c.expansion,
+		MaxIterations: c.maxIterations,
+	}
+	for _, t := range c.tables {
+		info.Buckets += t.numBuckets
+		info.Size += uint64(len(t.slots))
+	}
+	return info
+}
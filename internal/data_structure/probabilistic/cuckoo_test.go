@@ -0,0 +1,134 @@
+package probabilistic
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestCuckooBasic(t *testing.T) {
+	cf := NewCuckooFilter(1000, 2, 20, 1)
+
+	if err := cf.Add("golang"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cf.Exist("golang") {
+		t.Errorf("expected 'golang' to exist in Cuckoo filter")
+	}
+	if cf.Exist("python") {
+		t.Errorf("did not expect 'python' to exist in Cuckoo filter")
+	}
+
+	cf.Add("golang")
+	if n := cf.Count("golang"); n != 2 {
+		t.Errorf("expected count 2, got %d", n)
+	}
+	if added, _ := cf.AddNX("golang"); added {
+		t.Errorf("expected AddNX of an existing item to do nothing")
+	}
+
+	if !cf.Del("golang") || cf.Count("golang") != 1 {
+		t.Errorf("expected one copy of 'golang' to be left")
+	}
+	cf.Del("golang")
+	if cf.Exist("golang") || cf.Del("golang") {
+		t.Errorf("expected 'golang' to be deleted")
+	}
+
+	info := cf.Info()
+	if info.Items != 0 || info.Deleted != 2 || info.Buckets != 512 || info.Size != 1024 {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func TestCuckooManyItems(t *testing.T) {
+	n := 10000
+	cf := NewCuckooFilter(uint64(n), 4, 500, 0)
+
+	// Buckets of 4 fill up to about 95%, 90% must fit without expanding
+	added := 0
+	for i := 0; i < n*9/10; i++ {
+		if err := cf.Add("in" + strconv.Itoa(i)); err != nil {
+			t.Fatalf("unexpected error after %d items: %v", added, err)
+		}
+		added++
+	}
+	for i := 0; i < added; i++ {
+		if !cf.Exist("in" + strconv.Itoa(i)) {
+			t.Fatalf("expected in%d to exist", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if cf.Exist("out" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	// 8-bit fingerprints in 2 buckets of 4: about 8/255
+	if rate := float64(falsePositives) / float64(n); rate > 0.04 {
+		t.Errorf("false positive rate too high: %.4f", rate)
+	}
+
+	for i := 0; i < added; i++ {
+		if !cf.Del("in" + strconv.Itoa(i)) {
+			t.Fatalf("expected in%d to be deleted", i)
+		}
+	}
+	if info := cf.Info(); info.Items != 0 {
+		t.Errorf("expected an empty filter, got %+v", info)
+	}
+}
+
+func TestCuckooFullAndExpansion(t *testing.T) {
+	fixed := NewCuckooFilter(8, 2, 10, 0)
+	var err error
+	added := 0
+	for i := 0; i < 100 && err == nil; i++ {
+		if err = fixed.Add("item" + strconv.Itoa(i)); err == nil {
+			added++
+		}
+	}
+	if err != ErrCuckooFull {
+		t.Fatalf("expected ErrCuckooFull, got %v", err)
+	}
+	// A refused item does not push out the others
+	for i := 0; i < added; i++ {
+		if !fixed.Exist("item" + strconv.Itoa(i)) {
+			t.Fatalf("expected item%d to exist", i)
+		}
+	}
+
+	growing := NewCuckooFilter(8, 2, 10, 2)
+	for i := 0; i < 100; i++ {
+		if err := growing.Add("item" + strconv.Itoa(i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if !growing.Exist("item" + strconv.Itoa(i)) {
+			t.Fatalf("expected item%d to exist", i)
+		}
+	}
+	if info := growing.Info(); info.Filters < 3 || info.Items != 100 {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func TestCuckooMaxSize(t *testing.T) {
+	if !CuckooFilterFits(MaxCuckooSlots, 2) || CuckooFilterFits(MaxCuckooSlots+1, 1) || CuckooFilterFits(1<<63, 2) {
+		t.Errorf("unexpected CuckooFilterFits around MaxCuckooSlots")
+	}
+
+	// The next sub-filter would take more than MaxCuckooSlots, or its size would overflow
+	for _, expansion := range []int{1 << 29, 1 << 62} {
+		c := NewCuckooFilter(2, 2, 1, 1).(*Cuckoo)
+		c.expansion = expansion
+		var err error
+		for i := 0; err == nil; i++ {
+			err = c.Add(strconv.Itoa(i))
+		}
+		if err != ErrCuckooFull || len(c.tables) != 1 {
+			t.Errorf("expansion %d: expected ErrCuckooFull and no new sub-filter, got %v and %d", expansion, err, len(c.tables))
+		}
+	}
+}
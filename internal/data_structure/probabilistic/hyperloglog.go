@@ -0,0 +1,360 @@
+package probabilistic
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/config"
+	"github.com/spaolacci/murmur3"
+)
+
+// A HyperLogLog is stored as a string laid out like the one of Redis:
+//
+//	+------+---+-----+----------+----------------------------+
+//	| HYLL | E | N/U | Cardin.  | registers                  |
+//	+------+---+-----+----------+----------------------------+
+//
+// E is the encoding of the registers, N/U 3 unused bytes, and Cardin. the little endian cached
+// cardinality, invalid when the most significant bit of its last byte is set.
+//
+// The dense encoding packs the 16384 6-bit registers, the first one in the lowest bits of the first byte.
+// The sparse encoding run-length encodes them with three opcodes:
+//
+//	ZERO  00xxxxxx          1 to 64 registers set to 0
+//	XZERO 01xxxxxx yyyyyyyy 1 to 16384 registers set to 0
+//	VAL   1vvvvvxx          1 to 4 registers set to a value from 1 to 32
+const (
+	HLLP         = 14 // bits of the hash that select a register
+	HLLQ         = 64 - HLLP
+	HLLRegisters = 1 << HLLP
+	HLLBits      = 6
+	HLLHeaderLen = 16
+	HLLDenseLen  = HLLHeaderLen + (HLLRegisters*HLLBits+7)/8
+
+	hllDense  = 0
+	hllSparse = 1
+
+	hllZeroMaxLen  = 64
+	hllXZeroMaxLen = 16384
+	hllValMaxValue = 32
+	hllValMaxLen   = 4
+
+	hllAlphaInf = 0.721347520444481703680 // 1 / (2 ln 2)
+	hllHashSeed = 0xadc83b19
+)
+
+var (
+	// ErrNotHLL is returned when parsing a string that is not a HyperLogLog
+	ErrNotHLL = errors.New("Key is not a valid HyperLogLog string value.")
+	// ErrCorruptHLL is returned when parsing a HyperLogLog string whose registers cannot be decoded
+	ErrCorruptHLL = errors.New("Corrupted HLL object detected")
+)
+
+// HyperLogLog estimates the number of distinct items added to it in 12 KB at most, with a standard error
+// of 0.81%. Registers are kept decoded in memory and encoded again by Bytes: a HyperLogLog starts sparse
+// and becomes dense for good once the sparse encoding takes more than maxSparseBytes.
+type HyperLogLog struct {
+	registers      [HLLRegisters]uint8
+	sparse         bool
+	card           uint64
+	cardValid      bool
+	maxSparseBytes int
+}
+
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{
+		sparse:         true,
+		cardValid:      true,
+		maxSparseBytes: config.HllSparseMaxBytes,
+	}
+}
+
+// ParseHyperLogLog decodes a HyperLogLog string
+func ParseHyperLogLog(s string) (*HyperLogLog, error) {
+	if len(s) < HLLHeaderLen || s[:4] != "HYLL" {
+		return nil, ErrNotHLL
+	}
+	h := &HyperLogLog{maxSparseBytes: config.HllSparseMaxBytes}
+
+	switch s[4] {
+	case hllDense:
+		if len(s) != HLLDenseLen {
+			return nil, ErrNotHLL
+		}
+		if err := h.decodeDense(s[HLLHeaderLen:]); err != nil {
+			return nil, err
+		}
+	case hllSparse:
+		h.sparse = true
+		if err := h.decodeSparse(s[HLLHeaderLen:]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrNotHLL
+	}
+
+	card := []byte(s[8:HLLHeaderLen])
+	if card[7]&0x80 == 0 {
+		h.card, h.cardValid = binary.LittleEndian.Uint64(card), true
+	}
+	return h, nil
+}
+
+func (h *HyperLogLog) decodeDense(p string) error {
+	for i := range h.registers {
+		bit := i * HLLBits
+		b, shift := bit/8, bit%8
+		v := uint(p[b]) >> shift
+		if b+1 < len(p) {
+			v |= uint(p[b+1]) << (8 - shift)
+		}
+		v &= 1<<HLLBits - 1
+		// Add never sets a register past HLLQ + 1
+		if v > HLLQ+1 {
+			return ErrCorruptHLL
+		}
+		h.registers[i] = uint8(v)
+	}
+	return nil
+}
+
+func (h *HyperLogLog) decodeSparse(p string) error {
+	i := 0
+	for j := 0; j < len(p); j++ {
+		op := p[j]
+		var runLen int
+		var value uint8
+		switch {
+		case op&0xc0 == 0x00: // ZERO
+			runLen = int(op&0x3f) + 1
+		case op&0xc0 == 0x40: // XZERO
+			if j+1 >= len(p) {
+				return ErrCorruptHLL
+			}
+			runLen = (int(op&0x3f)<<8 | int(p[j+1])) + 1
+			j++
+		default: // VAL, at most hllValMaxValue so never past HLLQ + 1
+			value = (op>>2)&0x1f + 1
+			runLen = int(op&0x03) + 1
+		}
+		if i+runLen > HLLRegisters {
+			return ErrCorruptHLL
+		}
+		for end := i + runLen; i < end; i++ {
+			h.registers[i] = value
+		}
+	}
+	if i != HLLRegisters {
+		return ErrCorruptHLL
+	}
+	return nil
+}
+
+// Bytes encodes the HyperLogLog, switching to the dense encoding if the sparse one is too large
+func (h *HyperLogLog) Bytes() []byte {
+	var regs []byte
+	if h.sparse {
+		regs = h.encodeSparse()
+		if regs == nil {
+			h.sparse = false
+		}
+	}
+
+	encoding := byte(hllSparse)
+	if !h.sparse {
+		encoding = hllDense
+		regs = h.encodeDense()
+	}
+
+	out := make([]byte, HLLHeaderLen, HLLHeaderLen+len(regs))
+	copy(out, "HYLL")
+	out[4] = encoding
+	if h.cardValid {
+		binary.LittleEndian.PutUint64(out[8:], h.card)
+	} else {
+		out[15] = 0x80
+	}
+	return append(out, regs...)
+}
+
+func (h *HyperLogLog) encodeDense() []byte {
+	p := make([]byte, HLLDenseLen-HLLHeaderLen)
+	for i, v := range h.registers {
+		bit := i * HLLBits
+		b, shift := bit/8, bit%8
+		p[b] |= v << shift
+		if shift > 8-HLLBits {
+			p[b+1] |= v >> (8 - shift)
+		}
+	}
+	return p
+}
+
+// encodeSparse returns nil when a register is too large for a VAL opcode or the encoding takes more than maxSparseBytes
+func (h *HyperLogLog) encodeSparse() []byte {
+	var p []byte
+	for i := 0; i < HLLRegisters; {
+		v := h.registers[i]
+		runLen := 1
+		for i+runLen < HLLRegisters && h.registers[i+runLen] == v {
+			runLen++
+		}
+		i += runLen
+
+		switch {
+		case v > hllValMaxValue:
+			return nil
+		case v == 0:
+			for runLen > 0 {
+				if runLen > hllZeroMaxLen {
+					n := min(runLen, hllXZeroMaxLen)
+					p = append(p, 0x40|byte((n-1)>>8), byte(n-1))
+					runLen -= n
+				} else {
+					p = append(p, byte(runLen-1))
+					runLen = 0
+				}
+			}
+		default:
+			for runLen > 0 {
+				n := min(runLen, hllValMaxLen)
+				p = append(p, 0x80|(v-1)<<2|byte(n-1))
+				runLen -= n
+			}
+		}
+		if HLLHeaderLen+len(p) > h.maxSparseBytes {
+			return nil
+		}
+	}
+	return p
+}
+
+// IsSparse reports whether the HyperLogLog uses the sparse encoding
+func (h *HyperLogLog) IsSparse() bool {
+	return h.sparse
+}
+
+// hllPosition returns the register item selects and the value it sets it to at least
+func hllPosition(item string) (int, uint8) {
+	hash := murmur3.Sum64WithSeed([]byte(item), hllHashSeed)
+	index := int(hash & (HLLRegisters - 1))
+	// The position of the first 1 bit in the other bits, bounded by HLLQ + 1
+	count := uint8(bits.TrailingZeros64(hash>>HLLP|1<<HLLQ)) + 1
+	return index, count
+}
+
+// Add reports whether a register changed, which is when item changes the estimated cardinality
+func (h *HyperLogLog) Add(item string) bool {
+	index, count := hllPosition(item)
+	if count > h.registers[index] {
+		h.registers[index] = count
+		h.cardValid = false
+		return true
+	}
+	return false
+}
+
+// IsDenseHLL reports whether p has the header and length of a dense HyperLogLog string. Its registers
+// are not checked, AddDense never sets one past HLLQ + 1 and ParseHyperLogLog finds corrupt ones.
+func IsDenseHLL(p []byte) bool {
+	return len(p) == HLLDenseLen && string(p[:4]) == "HYLL" && p[4] == hllDense
+}
+
+// AddDense adds item to the dense HyperLogLog string p in place, without decoding the other registers,
+// and reports whether a register changed. The cached cardinality is invalidated when it did.
+func AddDense(p []byte, item string) bool {
+	index, count := hllPosition(item)
+	regs := p[HLLHeaderLen:]
+	bit := index * HLLBits
+	b, shift := bit/8, bit%8
+	v := uint(regs[b]) >> shift
+	if b+1 < len(regs) {
+		v |= uint(regs[b+1]) << (8 - shift)
+	}
+	mask := byte(1<<HLLBits - 1)
+	if count <= uint8(v)&mask {
+		return false
+	}
+
+	regs[b] = regs[b]&^(mask<<shift) | count<<shift
+	if shift > 8-HLLBits {
+		regs[b+1] = regs[b+1]&^(mask>>(8-shift)) | count>>(8-shift)
+	}
+	p[15] |= 0x80
+	return true
+}
+
+// Merge sets every register to the maximum of its value in h and in other
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	for i, v := range other.registers {
+		if v > h.registers[i] {
+			h.registers[i] = v
+			h.cardValid = false
+		}
+	}
+	h.sparse = h.sparse && other.sparse
+}
+
+// Count returns the estimated cardinality. It is cached until a register changes.
+func (h *HyperLogLog) Count() uint64 {
+	if !h.cardValid {
+		h.card, h.cardValid = h.estimate(), true
+	}
+	return h.card
+}
+
+// CountIsCached reports whether Count can answer without estimating the cardinality again
+func (h *HyperLogLog) CountIsCached() bool {
+	return h.cardValid
+}
+
+// estimate implements the estimator of Otmar Ertl, "New cardinality estimation algorithms for HyperLogLog sketches"
+func (h *HyperLogLog) estimate() uint64 {
+	var histogram [HLLQ + 2]int
+	for _, v := range h.registers {
+		histogram[v]++
+	}
+
+	m := float64(HLLRegisters)
+	z := m * hllTau((m-float64(histogram[HLLQ+1]))/m)
+	for k := HLLQ; k >= 1; k-- {
+		z += float64(histogram[k])
+		z *= 0.5
+	}
+	z += m * hllSigma(float64(histogram[0])/m)
+	return uint64(math.Round(hllAlphaInf * m * m / z))
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if prev == z {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+		if prev == z {
+			return z / 3
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package probabilistic
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestHyperLogLogCount(t *testing.T) {
+	for _, n := range []int{0, 1, 10, 1000, 100000} {
+		h := NewHyperLogLog()
+		for i := 0; i < n; i++ {
+			h.Add("item" + strconv.Itoa(i))
+			h.Add("item" + strconv.Itoa(i))
+		}
+
+		got := float64(h.Count())
+		// 4 standard errors of 0.81%
+		if math.Abs(got-float64(n)) > math.Max(1, 0.033*float64(n)) {
+			t.Errorf("expected about %d, got %.0f", n, got)
+		}
+	}
+}
+
+func TestHyperLogLogEncodings(t *testing.T) {
+	h := NewHyperLogLog()
+	if b := h.Bytes(); len(b) != HLLHeaderLen+2 || string(b[:4]) != "HYLL" {
+		t.Fatalf("expected an empty sparse HyperLogLog to be one XZERO opcode, got %v", b)
+	}
+
+	for i := 0; i < 100; i++ {
+		h.Add(strconv.Itoa(i))
+	}
+	sparse := h.Bytes()
+	if !h.IsSparse() || len(sparse) > 500 {
+		t.Errorf("expected 100 items to fit in a small sparse encoding, got %d bytes", len(sparse))
+	}
+
+	for i := 100; i < 10000; i++ {
+		h.Add(strconv.Itoa(i))
+	}
+	dense := h.Bytes()
+	if h.IsSparse() || len(dense) != HLLDenseLen {
+		t.Errorf("expected 10000 items to use the dense encoding, got %d bytes", len(dense))
+	}
+
+	// Both encodings decode to the same registers
+	for _, b := range [][]byte{sparse, dense} {
+		parsed, err := ParseHyperLogLog(string(b))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		again := parsed.Bytes()
+		if string(again) != string(b) {
+			t.Errorf("expected encoding to round trip")
+		}
+	}
+	parsed, _ := ParseHyperLogLog(string(dense))
+	if parsed.registers != h.registers {
+		t.Errorf("expected dense registers to round trip")
+	}
+}
+
+func TestHyperLogLogAddDense(t *testing.T) {
+	h := NewHyperLogLog()
+	for i := 0; i < 10000; i++ {
+		h.Add(strconv.Itoa(i))
+	}
+	h.Count()
+	p := h.Bytes()
+	if !IsDenseHLL(p) {
+		t.Fatalf("expected 10000 items to use the dense encoding")
+	}
+	if AddDense(p, "0") || p[15]&0x80 != 0 {
+		t.Errorf("expected an item already added to change no register nor the cached cardinality")
+	}
+
+	// Updating the string in place sets the same registers as Add
+	for i := 10000; i < 100000; i++ {
+		if AddDense(p, strconv.Itoa(i)) != h.Add(strconv.Itoa(i)) {
+			t.Fatalf("expected AddDense and Add to agree on item %d", i)
+		}
+	}
+	parsed, err := ParseHyperLogLog(string(p))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed.registers != h.registers || parsed.CountIsCached() {
+		t.Errorf("expected the registers of Add and an invalidated cardinality")
+	}
+	if IsDenseHLL(NewHyperLogLog().Bytes()) {
+		t.Errorf("expected a sparse HyperLogLog not to be dense")
+	}
+}
+
+func TestHyperLogLogCardinalityCache(t *testing.T) {
+	h := NewHyperLogLog()
+	h.Add("a")
+	if h.CountIsCached() {
+		t.Errorf("expected Add to invalidate the cached cardinality")
+	}
+	parsed, _ := ParseHyperLogLog(string(h.Bytes()))
+	if parsed.CountIsCached() {
+		t.Errorf("expected the invalid cache to be encoded")
+	}
+
+	if h.Count() != 1 || !h.CountIsCached() {
+		t.Errorf("expected a cached cardinality of 1")
+	}
+	parsed, _ = ParseHyperLogLog(string(h.Bytes()))
+	if !parsed.CountIsCached() || parsed.Count() != 1 {
+		t.Errorf("expected the cached cardinality to be encoded")
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a, b := NewHyperLogLog(), NewHyperLogLog()
+	for i := 0; i < 3000; i++ {
+		a.Add("a" + strconv.Itoa(i))
+		b.Add("b" + strconv.Itoa(i))
+		b.Add("a" + strconv.Itoa(i))
+	}
+	a.Merge(b)
+	if got := float64(a.Count()); math.Abs(got-6000) > 200 {
+		t.Errorf("expected about 6000, got %.0f", got)
+	}
+}
+
+func TestParseHyperLogLogErrors(t *testing.T) {
+	for _, s := range []string{"", "hello", "HYLL", "HYLL\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"} {
+		if _, err := ParseHyperLogLog(s); err != ErrNotHLL {
+			t.Errorf("expected ErrNotHLL for %q, got %v", s, err)
+		}
+	}
+
+	header := "HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"
+	// ZERO opcodes covering 64 registers instead of 16384
+	if _, err := ParseHyperLogLog(header + "\x3f"); err != ErrCorruptHLL {
+		t.Errorf("expected ErrCorruptHLL, got %v", err)
+	}
+	// Truncated XZERO opcode
+	if _, err := ParseHyperLogLog(header + "\x7f"); err != ErrCorruptHLL {
+		t.Errorf("expected ErrCorruptHLL, got %v", err)
+	}
+}
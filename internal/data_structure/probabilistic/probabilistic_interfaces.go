@@ -11,9 +11,156 @@ type FrequencyEstimator interface {
 	// Return the min-counts of each of the provided items in the sketch.
 	// Error if: invalid arguments, missing key, or wrong key type.
 	Count(item string) uint64
+
+	// Merge replaces the sketch with the sum of sketches, each count multiplied by the weight of its sketch.
+	// Error if a sketch does not have the same width and depth.
+	Merge(sketches []FrequencyEstimator, weights []uint64) error
+
+	// Info returns the dimensions of the sketch and the total of all increments, as reported by CMS.INFO.
+	Info() CMSInfo
 }
 
+// CMSInfo describes a Count-Min Sketch
+type CMSInfo struct {
+	Width uint64
+	Depth uint64
+	Count uint64 // total of all increments
+}
+
+// MembershipTester defines the interface for Bloom filters
 type MembershipTester interface {
-	Add(item string)
+	// Add adds item to the filter.
+	// Return true if item is new, false if it may have been added before.
+	// Error if the filter is full and does not scale.
+	Add(item string) (bool, error)
+
+	// Exist reports whether entry may have been added, false positives happen at the error rate.
 	Exist(entry string) bool
+
+	// Info returns the dimensions and usage of the filter, as reported by BF.INFO.
+	Info() FilterInfo
+}
+
+// FilterInfo describes a membership filter
+type FilterInfo struct {
+	Capacity  uint64 // items the filter holds within its error rate
+	Size      uint64 // memory used by the filter, in bytes
+	Filters   int    // number of sub-filters
+	Items     uint64 // items added, not counting the ones that were already in the filter
+	Hashes    int    // hash functions per item
+	Expansion int    // capacity growth of the next sub-filter, 0 if the filter does not scale
+}
+
+// DeletableMembershipTester defines the interface for Cuckoo filters
+type DeletableMembershipTester interface {
+	// Add adds item to the filter, even if it was already added.
+	// Error if there is no room for item and the filter does not expand.
+	Add(item string) error
+
+	// AddNX adds item only if it does not exist in the filter.
+	// Return true if item was added, false if it may have been added before.
+	AddNX(item string) (bool, error)
+
+	// Exist reports whether item may have been added, false positives happen at the error rate.
+	Exist(item string) bool
+
+	// Del removes one copy of item.
+	// Return false if item was not found.
+	Del(item string) bool
+
+	// Count returns the number of copies of item, it can be higher than the number of times item was added.
+	Count(item string) uint64
+
+	// Info returns the dimensions and usage of the filter, as reported by CF.INFO.
+	Info() CuckooInfo
+}
+
+// CuckooInfo describes a Cuckoo filter
+type CuckooInfo struct {
+	Size          uint64 // memory used by the fingerprints, in bytes
+	Buckets       uint64 // buckets of all the sub-filters
+	Filters       int    // number of sub-filters
+	Items         uint64 // items added and not deleted
+	Deleted       uint64 // items deleted
+	BucketSize    int    // fingerprints per bucket
+	Expansion     int    // size of a new sub-filter relative to the last one, 0 if the filter does not expand
+	MaxIterations int    // relocations tried before a new item is refused or a sub-filter is added
+}
+
+// HeavyHitterTracker defines the interface for Top-K
+type HeavyHitterTracker interface {
+	// IncrBy increases the count of item by increment.
+	// Return the item expelled from the top-k list to make room for item, false if none was.
+	IncrBy(item string, increment uint64) (string, bool)
+
+	// Query reports whether item is in the top-k list.
+	Query(item string) bool
+
+	// Count returns the estimated count of item.
+	Count(item string) uint64
+
+	// List returns the top-k items, highest count first.
+	List() []TopKItem
+
+	// Info returns the parameters of the Top-K, as reported by TOPK.INFO.
+	Info() TopKInfo
+}
+
+// TopKInfo describes a Top-K
+type TopKInfo struct {
+	K     int
+	Width uint64
+	Depth uint64
+	Decay float64
+}
+
+// QuantileEstimator defines the interface for t-digest
+type QuantileEstimator interface {
+	// Add adds an observation.
+	Add(value float64)
+
+	// Merge adds the observations of digests, which can include the digest itself.
+	Merge(digests []QuantileEstimator)
+
+	// Reset removes every observation.
+	Reset()
+
+	// Quantile returns the estimated value below which a fraction q of the observations fall.
+	// Return NaN if there is no observation.
+	Quantile(q float64) float64
+
+	// CDF returns the estimated fraction of the observations below value, plus half of the ones equal to it.
+	// Return NaN if there is no observation.
+	CDF(value float64) float64
+
+	// Rank returns the estimated number of observations below value, or above it when reverse is set.
+	// Return -1 if there is none, the number of observations if all are, and -2 if there is no observation.
+	Rank(value float64, reverse bool) int
+
+	// Min and Max return the smallest and largest observation, NaN if there is no observation.
+	Min() float64
+	Max() float64
+
+	// TrimmedMean returns the mean of the observations between the low and high quantiles.
+	// Return NaN if there is no observation.
+	TrimmedMean(low, high float64) float64
+
+	// Compression returns the compression the digest was created with.
+	Compression() float64
+
+	// Info returns the size and usage of the digest, as reported by TDIGEST.INFO.
+	Info() TDigestInfo
+}
+
+// TDigestInfo describes a t-digest
+type TDigestInfo struct {
+	Compression    float64
+	Capacity       int // observations buffered before they are merged into the centroids
+	MergedNodes    int
+	UnmergedNodes  int
+	MergedWeight   float64
+	UnmergedWeight float64
+	Observations   float64
+	Compressions   int
+	MemoryUsage    int // bytes
 }
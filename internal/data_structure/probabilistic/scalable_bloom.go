@@ -0,0 +1,70 @@
+package probabilistic
+
+import "math"
+
+// ErrorTighteningRatio is the error rate of a new sub-filter relative to the previous one
+const ErrorTighteningRatio = 0.5
+
+// ScalableBloom is a chain of Bloom filters. Items go to the last one, and once it holds its
+// capacity a new sub-filter is added with expansion times the capacity and a tighter error rate,
+// so the overall false positive rate stays bounded however many items are added.
+type ScalableBloom struct {
+	filters   []*Bloom
+	expansion int
+}
+
+func NewScalableBloomFilter(entries uint64, errorRate float64, expansion int) MembershipTester {
+	return &ScalableBloom{
+		filters:   []*Bloom{newBloom(entries, errorRate)},
+		expansion: expansion,
+	}
+}
+
+func (sb *ScalableBloom) Add(entry string) (bool, error) {
+	// Every sub-filter uses the same hash function, the item is hashed once
+	initHash := sb.filters[0].CalcHash(entry)
+	if sb.existHash(initHash) {
+		return false, nil
+	}
+
+	last := sb.filters[len(sb.filters)-1]
+	if last.full() {
+		expansion := uint64(sb.expansion)
+		entries, errorRate := last.Entries*expansion, last.Error*ErrorTighteningRatio
+		if last.Entries > math.MaxUint64/expansion || !BloomFilterFits(entries, errorRate) {
+			return false, ErrFilterTooLarge
+		}
+		last = newBloom(entries, errorRate)
+		sb.filters = append(sb.filters, last)
+	}
+	return last.AddHash(initHash), nil
+}
+
+func (sb *ScalableBloom) Exist(entry string) bool {
+	return sb.existHash(sb.filters[0].CalcHash(entry))
+}
+
+func (sb *ScalableBloom) existHash(initHash HashValue) bool {
+	// The newest sub-filters hold the most items, check them first
+	for i := len(sb.filters) - 1; i >= 0; i-- {
+		if sb.filters[i].ExistHash(initHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// Info sums the sub-filters, Hashes is the one of the sub-filter new items go to
+func (sb *ScalableBloom) Info() FilterInfo {
+	info := FilterInfo{
+		Filters:   len(sb.filters),
+		Hashes:    sb.filters[len(sb.filters)-1].Hashes,
+		Expansion: sb.expansion,
+	}
+	for _, b := range sb.filters {
+		info.Capacity += b.Entries
+		info.Size += b.words * 8
+		info.Items += b.items
+	}
+	return info
+}
@@ -0,0 +1,90 @@
+package probabilistic
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestScalableBloomGrows(t *testing.T) {
+	sb := NewScalableBloomFilter(100, 0.01, 2)
+
+	for i := 0; i < 1000; i++ {
+		if _, err := sb.Add("item" + strconv.Itoa(i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		if !sb.Exist("item" + strconv.Itoa(i)) {
+			t.Fatalf("expected item%d to exist", i)
+		}
+	}
+
+	// 100 + 200 + 400 + 800 holds 1000 items
+	info := sb.Info()
+	if info.Filters != 4 || info.Capacity != 1500 || info.Expansion != 2 {
+		t.Errorf("unexpected info %+v", info)
+	}
+	// Items that are false positives when added are not counted
+	if info.Items > 1000 || info.Items < 970 {
+		t.Errorf("expected about 1000 items, got %d", info.Items)
+	}
+}
+
+func TestScalableBloomFalsePositiveRate(t *testing.T) {
+	n := 10000
+	errorRate := 0.01
+	sb := NewScalableBloomFilter(100, errorRate, 2)
+	for i := 0; i < n; i++ {
+		sb.Add("in" + strconv.Itoa(i))
+	}
+
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if sb.Exist("out" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+
+	// A fixed filter of capacity 100 would answer yes to almost everything. The error rates of
+	// the sub-filters add up to at most twice the requested one.
+	rate := float64(falsePositives) / float64(n)
+	if rate > 2*errorRate {
+		t.Errorf("false positive rate too high: got %.4f, expected <= %.4f", rate, 2*errorRate)
+	}
+}
+
+func TestScalableBloomExistingItem(t *testing.T) {
+	sb := NewScalableBloomFilter(1, 0.01, 1)
+	if added, _ := sb.Add("a"); !added {
+		t.Errorf("expected 'a' to be new")
+	}
+	if added, _ := sb.Add("a"); added {
+		t.Errorf("expected 'a' to exist")
+	}
+	if sb.Info().Filters != 1 {
+		t.Errorf("adding an existing item must not grow the filter")
+	}
+	sb.Add("b")
+	if info := sb.Info(); info.Filters != 2 || info.Capacity != 2 {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func TestScalableBloomMaxSize(t *testing.T) {
+	// Fake a full last sub-filter rather than allocating one at the limit
+	full := newBloom(10, 0.01)
+	full.Entries, full.items = MaxBloomBits, MaxBloomBits
+	sb := &ScalableBloom{filters: []*Bloom{full}, expansion: 2}
+	if _, err := sb.Add("item"); err != ErrFilterTooLarge {
+		t.Errorf("expected ErrFilterTooLarge, got %v", err)
+	}
+
+	// The capacity of the next sub-filter would overflow
+	full.Entries, full.items = 1<<63, 1<<63
+	if _, err := sb.Add("item"); err != ErrFilterTooLarge {
+		t.Errorf("expected ErrFilterTooLarge, got %v", err)
+	}
+	if len(sb.filters) != 1 {
+		t.Errorf("expected no new sub-filter, got %d filters", len(sb.filters))
+	}
+}
@@ -0,0 +1,320 @@
+package probabilistic
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"unsafe"
+)
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// TDigest estimates quantiles of a stream of values with the merging t-digest of Ted Dunning. Values are
+// summarized by centroids: close to the median a centroid merges many values, towards the tails only a
+// few, so extreme quantiles stay accurate. The larger the compression, the more centroids and the more
+// accurate the estimates. New values are buffered and merged into the centroids once the buffer is full.
+type TDigest struct {
+	compression  float64
+	centroids    []centroid // sorted by mean
+	buffer       []centroid // not merged yet
+	bufferSize   int
+	merged       float64 // weight of the centroids
+	unmerged     float64 // weight of the buffer
+	min, max     float64
+	compressions int
+}
+
+func NewTDigest(compression float64) QuantileEstimator {
+	t := &TDigest{
+		compression: compression,
+		bufferSize:  int(math.Ceil(5 * compression)),
+	}
+	t.Reset()
+	return t
+}
+
+func (t *TDigest) Reset() {
+	t.centroids = nil
+	t.buffer = make([]centroid, 0, t.bufferSize)
+	t.merged, t.unmerged = 0, 0
+	t.min, t.max = math.Inf(1), math.Inf(-1)
+	t.compressions = 0
+}
+
+func (t *TDigest) Add(value float64) {
+	t.addCentroid(centroid{mean: value, weight: 1})
+}
+
+func (t *TDigest) addCentroid(c centroid) {
+	t.buffer = append(t.buffer, c)
+	t.unmerged += c.weight
+	t.min = math.Min(t.min, c.mean)
+	t.max = math.Max(t.max, c.mean)
+	if len(t.buffer) >= t.bufferSize {
+		t.compress()
+	}
+}
+
+// Merge adds the values summarized by digests, which can include t itself
+func (t *TDigest) Merge(digests []QuantileEstimator) {
+	var all []centroid
+	for _, d := range digests {
+		other := d.(*TDigest)
+		other.compress()
+		all = append(all, other.centroids...)
+		// The min and max of a digest are not necessarily centroid means
+		if other.count() > 0 {
+			t.min = math.Min(t.min, other.min)
+			t.max = math.Max(t.max, other.max)
+		}
+	}
+	for _, c := range all {
+		t.addCentroid(c)
+	}
+	t.compress()
+}
+
+// scale maps the quantile q to the arcsine scale of the t-digest. A centroid spans at most 1 on this scale,
+// so centroids get smaller towards the tails and there are about compression/2 of them.
+func (t *TDigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+func (t *TDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.buffer, t.centroids...)
+	slices.SortFunc(all, func(a, b centroid) int { return cmp.Compare(a.mean, b.mean) })
+
+	total := t.merged + t.unmerged
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	cur := all[0]
+	weightSoFar := 0.0
+	for _, next := range all[1:] {
+		proposed := cur.weight + next.weight
+		q0 := weightSoFar / total
+		q2 := (weightSoFar + proposed) / total
+		if t.scale(q2)-t.scale(q0) <= 1 {
+			cur.mean += (next.mean - cur.mean) * next.weight / proposed
+			cur.weight = proposed
+			continue
+		}
+		weightSoFar += cur.weight
+		merged = append(merged, cur)
+		cur = next
+	}
+	merged = append(merged, cur)
+
+	t.centroids = merged
+	t.buffer = t.buffer[:0]
+	t.merged, t.unmerged = total, 0
+	t.compressions++
+}
+
+func (t *TDigest) count() float64 {
+	return t.merged + t.unmerged
+}
+
+func (t *TDigest) Min() float64 {
+	if t.count() == 0 {
+		return math.NaN()
+	}
+	return t.min
+}
+
+func (t *TDigest) Max() float64 {
+	if t.count() == 0 {
+		return math.NaN()
+	}
+	return t.max
+}
+
+// Quantile returns the estimated value below which a fraction q of the values fall.
+// Each centroid is taken to spread its weight evenly around its mean, except for centroids of a single
+// value which are exact. The min and max bound the tails.
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+	c := t.centroids
+	n := len(c)
+	switch {
+	case n == 0:
+		return math.NaN()
+	case n == 1:
+		return c[0].mean
+	}
+
+	total := t.merged
+	index := q * total
+	if index < 1 {
+		return t.min
+	}
+	if c[0].weight > 1 && index < c[0].weight/2 {
+		return t.min + (index-1)/(c[0].weight/2-1)*(c[0].mean-t.min)
+	}
+	if index > total-1 {
+		return t.max
+	}
+	if c[n-1].weight > 1 && total-index <= c[n-1].weight/2 {
+		return t.max - (total-index-1)/(c[n-1].weight/2-1)*(t.max-c[n-1].mean)
+	}
+
+	weightSoFar := c[0].weight / 2
+	for i := 0; i < n-1; i++ {
+		dw := (c[i].weight + c[i+1].weight) / 2
+		if weightSoFar+dw > index {
+			// c[i] and c[i+1] bracket index
+			leftUnit := 0.0
+			if c[i].weight == 1 {
+				if index-weightSoFar < 0.5 {
+					return c[i].mean
+				}
+				leftUnit = 0.5
+			}
+			rightUnit := 0.0
+			if c[i+1].weight == 1 {
+				if weightSoFar+dw-index <= 0.5 {
+					return c[i+1].mean
+				}
+				rightUnit = 0.5
+			}
+			z1 := index - weightSoFar - leftUnit
+			z2 := weightSoFar + dw - index - rightUnit
+			return (c[i].mean*z2 + c[i+1].mean*z1) / (z1 + z2)
+		}
+		weightSoFar += dw
+	}
+
+	// Between the mean of the last centroid and the max
+	z1 := index - weightSoFar
+	z2 := c[n-1].weight/2 - z1
+	return (c[n-1].mean*z2 + t.max*z1) / (z1 + z2)
+}
+
+// CDF returns the estimated fraction of the values below value, plus half of the ones equal to it
+func (t *TDigest) CDF(value float64) float64 {
+	t.compress()
+	c := t.centroids
+	n := len(c)
+	switch {
+	case n == 0:
+		return math.NaN()
+	case value < t.min:
+		return 0
+	case value > t.max:
+		return 1
+	case n == 1:
+		if t.max == t.min {
+			return 0.5
+		}
+		return (value - t.min) / (t.max - t.min)
+	}
+
+	total := t.merged
+	// Tails, between the min (max) and the first (last) centroid
+	if value < c[0].mean {
+		if value == t.min {
+			return 0.5 / total
+		}
+		return (1 + (value-t.min)/(c[0].mean-t.min)*(c[0].weight/2-1)) / total
+	}
+	if value > c[n-1].mean {
+		if value == t.max {
+			return 1 - 0.5/total
+		}
+		return 1 - (1+(t.max-value)/(t.max-c[n-1].mean)*(c[n-1].weight/2-1))/total
+	}
+
+	weightSoFar := 0.0
+	for i := 0; i < n-1; i++ {
+		if c[i].mean == value {
+			dw := 0.0
+			for ; i < n && c[i].mean == value; i++ {
+				dw += c[i].weight
+			}
+			return (weightSoFar + dw/2) / total
+		}
+		if value < c[i+1].mean {
+			// c[i] and c[i+1] bracket value, a centroid of a single value does not spread
+			leftExcluded, rightExcluded := 0.0, 0.0
+			if c[i].weight == 1 {
+				if c[i+1].weight == 1 {
+					return (weightSoFar + 1) / total
+				}
+				leftExcluded = 0.5
+			} else if c[i+1].weight == 1 {
+				rightExcluded = 0.5
+			}
+			dw := (c[i].weight+c[i+1].weight)/2 - leftExcluded - rightExcluded
+			base := weightSoFar + c[i].weight/2 + leftExcluded
+			return (base + dw*(value-c[i].mean)/(c[i+1].mean-c[i].mean)) / total
+		}
+		weightSoFar += c[i].weight
+	}
+	// value is the mean of the last centroid
+	return (weightSoFar + c[n-1].weight/2) / total
+}
+
+// Rank returns the estimated number of values below value, or above it when reverse is set.
+// It is -1 when no value is below (above) it, the number of values when all are, and -2 if the digest is empty.
+func (t *TDigest) Rank(value float64, reverse bool) int {
+	n := t.count()
+	switch {
+	case n == 0:
+		return -2
+	case value < t.min && !reverse, value > t.max && reverse:
+		return -1
+	case value > t.max && !reverse, value < t.min && reverse:
+		return int(n)
+	}
+	cdf := t.CDF(value)
+	if reverse {
+		cdf = 1 - cdf
+	}
+	return int(math.Floor(cdf * n))
+}
+
+// TrimmedMean returns the mean of the values between the low and high quantiles
+func (t *TDigest) TrimmedMean(low, high float64) float64 {
+	t.compress()
+	if len(t.centroids) == 0 {
+		return math.NaN()
+	}
+
+	from, to := low*t.merged, high*t.merged
+	var sum, weight, weightSoFar float64
+	for _, c := range t.centroids {
+		// The part of the weight of c between the quantiles
+		overlap := math.Min(weightSoFar+c.weight, to) - math.Max(weightSoFar, from)
+		if overlap > 0 {
+			sum += c.mean * overlap
+			weight += overlap
+		}
+		weightSoFar += c.weight
+	}
+	if weight == 0 {
+		return t.Quantile(low)
+	}
+	return sum / weight
+}
+
+func (t *TDigest) Compression() float64 {
+	return t.compression
+}
+
+func (t *TDigest) Info() TDigestInfo {
+	return TDigestInfo{
+		Compression:    t.compression,
+		Capacity:       t.bufferSize,
+		MergedNodes:    len(t.centroids),
+		UnmergedNodes:  len(t.buffer),
+		MergedWeight:   t.merged,
+		UnmergedWeight: t.unmerged,
+		Observations:   t.count(),
+		Compressions:   t.compressions,
+		MemoryUsage:    int(unsafe.Sizeof(*t)) + (cap(t.centroids)+cap(t.buffer))*int(unsafe.Sizeof(centroid{})),
+	}
+}
@@ -0,0 +1,105 @@
+package probabilistic
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestTDigestExactOnSmallInputs(t *testing.T) {
+	td := NewTDigest(100)
+	for _, v := range []float64{1, 2, 2, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 5} {
+		td.Add(v)
+	}
+
+	expected := []float64{1, 2, 3, 3, 4, 4, 4, 5, 5, 5, 5}
+	for i, want := range expected {
+		if got := td.Quantile(float64(i) / 10); got != want {
+			t.Errorf("quantile %.1f: expected %v, got %v", float64(i)/10, want, got)
+		}
+	}
+	if td.Min() != 1 || td.Max() != 5 {
+		t.Errorf("expected min 1 and max 5, got %v %v", td.Min(), td.Max())
+	}
+}
+
+func TestTDigestRank(t *testing.T) {
+	td := NewTDigest(100)
+	if td.Rank(1, false) != -2 || !math.IsNaN(td.CDF(1)) || !math.IsNaN(td.Quantile(0.5)) || !math.IsNaN(td.Min()) {
+		t.Errorf("expected an empty digest to have no rank, cdf, quantile or min")
+	}
+
+	for _, v := range []float64{10, 20, 30, 40, 50, 60} {
+		td.Add(v)
+	}
+	values := []float64{0, 10, 15, 20, 30, 40, 50, 60, 70}
+	ranks := []int{-1, 0, 1, 1, 2, 3, 4, 5, 6}
+	revRanks := []int{6, 5, 5, 4, 3, 2, 1, 0, -1}
+	for i, v := range values {
+		if got := td.Rank(v, false); got != ranks[i] {
+			t.Errorf("rank of %v: expected %d, got %d", v, ranks[i], got)
+		}
+		if got := td.Rank(v, true); got != revRanks[i] {
+			t.Errorf("reverse rank of %v: expected %d, got %d", v, revRanks[i], got)
+		}
+	}
+	if got := td.CDF(35); got != 0.5 {
+		t.Errorf("expected cdf 0.5 between 30 and 40, got %v", got)
+	}
+}
+
+func TestTDigestAccuracy(t *testing.T) {
+	td := NewTDigest(100)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		td.Add(r.Float64() * 1000)
+	}
+
+	for _, q := range []float64{0.001, 0.01, 0.25, 0.5, 0.75, 0.99, 0.999} {
+		if got := td.Quantile(q); math.Abs(got-q*1000) > 10 {
+			t.Errorf("quantile %v: expected about %v, got %v", q, q*1000, got)
+		}
+		if got := td.CDF(q * 1000); math.Abs(got-q) > 0.01 {
+			t.Errorf("cdf of %v: expected about %v, got %v", q*1000, q, got)
+		}
+	}
+	if got := td.TrimmedMean(0.1, 0.9); math.Abs(got-500) > 10 {
+		t.Errorf("expected a trimmed mean of about 500, got %v", got)
+	}
+	if got := td.TrimmedMean(0, 0.5); math.Abs(got-250) > 10 {
+		t.Errorf("expected a trimmed mean of about 250, got %v", got)
+	}
+
+	info := td.Info()
+	if info.Observations != 100000 || info.MergedNodes > 200 || info.Compressions == 0 {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func TestTDigestMerge(t *testing.T) {
+	low, high := NewTDigest(100), NewTDigest(100)
+	for i := 0; i < 1000; i++ {
+		low.Add(float64(i))
+		high.Add(float64(1000 + i))
+	}
+
+	all := NewTDigest(100)
+	all.Merge([]QuantileEstimator{low, high})
+	if all.Min() != 0 || all.Max() != 1999 || all.Info().Observations != 2000 {
+		t.Errorf("unexpected min %v, max %v or info %+v", all.Min(), all.Max(), all.Info())
+	}
+	if got := all.Quantile(0.5); math.Abs(got-1000) > 20 {
+		t.Errorf("expected a median of about 1000, got %v", got)
+	}
+
+	// Merging a digest into itself counts its observations twice
+	low.Merge([]QuantileEstimator{low})
+	if low.Info().Observations != 2000 || low.Max() != 999 {
+		t.Errorf("unexpected info %+v", low.Info())
+	}
+
+	all.Reset()
+	if all.Info().Observations != 0 || !math.IsNaN(all.Max()) {
+		t.Errorf("expected an empty digest after Reset")
+	}
+}
@@ -0,0 +1,152 @@
+package probabilistic
+
+import (
+	"cmp"
+	"container/heap"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// TopKItem is an item of the top-k list with its estimated count
+type TopKItem struct {
+	Item  string
+	Count uint64
+}
+
+type topKBucket struct {
+	fp    uint32
+	count uint64
+}
+
+// topKHeap is a min-heap of the top-k items, the one with the lowest count is expelled first
+type topKHeap []*TopKItem
+
+func (h topKHeap) Len() int           { return len(h) }
+func (h topKHeap) Less(i, j int) bool { return h[i].Count < h[j].Count }
+func (h topKHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *topKHeap) Push(x any)        { *h = append(*h, x.(*TopKItem)) }
+func (h *topKHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// TopK keeps the k items with the highest counts using HeavyKeeper: a depth x width array of buckets
+// holds a fingerprint and a count. An item increments its buckets, or decays the count of a bucket owned
+// by another item with probability decay^count and takes it over once the count drops to 0. Small counts
+// decay fast so the buckets end up owned by the heavy hitters, whose counts are kept in a min-heap.
+type TopK struct {
+	k       int
+	width   uint64
+	depth   uint64
+	decay   float64
+	buckets []topKBucket // 1D slides: index = i*width + j, like the CMS counters
+	heap    topKHeap
+}
+
+func NewTopK(k int, width, depth uint64, decay float64) HeavyHitterTracker {
+	return &TopK{
+		k:       k,
+		width:   width,
+		depth:   depth,
+		decay:   decay,
+		buckets: make([]topKBucket, width*depth),
+		heap:    make(topKHeap, 0, k),
+	}
+}
+
+func (t *TopK) fingerprint(item string) uint32 {
+	return murmur3.Sum32WithSeed([]byte(item), ABigSeed)
+}
+
+func (t *TopK) bucket(item string, row uint64) *topKBucket {
+	j := uint64(murmur3.Sum32WithSeed([]byte(item), uint32(row))) % t.width
+	return &t.buckets[row*t.width+j]
+}
+
+func (t *TopK) find(item string) int {
+	return slices.IndexFunc(t.heap, func(e *TopKItem) bool { return e.Item == item })
+}
+
+func (t *TopK) IncrBy(item string, increment uint64) (string, bool) {
+	fp := t.fingerprint(item)
+	var maxCount uint64
+
+	for i := uint64(0); i < t.depth; i++ {
+		b := t.bucket(item, i)
+		switch {
+		case b.count == 0:
+			b.fp, b.count = fp, increment
+		case b.fp == fp:
+			b.count = addSaturated(b.count, increment)
+		default:
+			for incr := increment; incr > 0; incr-- {
+				if rand.Float64() < math.Pow(t.decay, float64(b.count)) {
+					b.count--
+					if b.count == 0 {
+						b.fp, b.count = fp, incr
+						break
+					}
+				}
+			}
+		}
+		if b.fp == fp && b.count > maxCount {
+			maxCount = b.count
+		}
+	}
+
+	if i := t.find(item); i >= 0 {
+		t.heap[i].Count = maxCount
+		heap.Fix(&t.heap, i)
+		return "", false
+	}
+	if len(t.heap) < t.k {
+		heap.Push(&t.heap, &TopKItem{Item: item, Count: maxCount})
+		return "", false
+	}
+	if maxCount > t.heap[0].Count {
+		expelled := t.heap[0].Item
+		t.heap[0] = &TopKItem{Item: item, Count: maxCount}
+		heap.Fix(&t.heap, 0)
+		return expelled, true
+	}
+	return "", false
+}
+
+func (t *TopK) Query(item string) bool {
+	return t.find(item) >= 0
+}
+
+func (t *TopK) Count(item string) uint64 {
+	fp := t.fingerprint(item)
+	var count uint64
+	for i := uint64(0); i < t.depth; i++ {
+		if b := t.bucket(item, i); b.fp == fp && b.count > count {
+			count = b.count
+		}
+	}
+	return count
+}
+
+func (t *TopK) List() []TopKItem {
+	items := make([]TopKItem, 0, len(t.heap))
+	for _, e := range t.heap {
+		items = append(items, *e)
+	}
+	// Highest count first
+	slices.SortFunc(items, func(a, b TopKItem) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Item, b.Item)
+	})
+	return items
+}
+
+func (t *TopK) Info() TopKInfo {
+	return TopKInfo{K: t.k, Width: t.width, Depth: t.depth, Decay: t.decay}
+}
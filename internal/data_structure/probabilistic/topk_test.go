@@ -0,0 +1,66 @@
+package probabilistic
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestTopKFindsHeavyHitters(t *testing.T) {
+	tk := NewTopK(3, 50, 5, 0.9)
+
+	// hot0 is added 300 times, hot1 200 times and hot2 100 times, among 1000 items seen a few times each
+	for round := 0; round < 100; round++ {
+		for i := 0; i < 3; i++ {
+			for n := 0; n < 3-i; n++ {
+				tk.IncrBy("hot"+strconv.Itoa(i), 1)
+			}
+		}
+		for i := 0; i < 10; i++ {
+			tk.IncrBy("cold"+strconv.Itoa(round*10+i), 1)
+		}
+	}
+
+	list := tk.List()
+	if len(list) != 3 {
+		t.Fatalf("expected 3 items, got %v", list)
+	}
+	for i, want := range []string{"hot0", "hot1", "hot2"} {
+		if list[i].Item != want {
+			t.Errorf("expected %s at position %d, got %v", want, i, list)
+		}
+		if !tk.Query(want) {
+			t.Errorf("expected %s to be in the top-k", want)
+		}
+	}
+	// HeavyKeeper never overestimates, and the heavy hitters lose few increments
+	if c := tk.Count("hot0"); c > 300 || c < 250 {
+		t.Errorf("expected a count close to 300 for hot0, got %d", c)
+	}
+	if tk.Query("cold5") {
+		t.Errorf("did not expect cold5 to be in the top-k")
+	}
+}
+
+func TestTopKExpelled(t *testing.T) {
+	tk := NewTopK(2, 8, 7, 0.9)
+
+	if _, expelled := tk.IncrBy("a", 1); expelled {
+		t.Errorf("expected no item to be expelled while the list is not full")
+	}
+	tk.IncrBy("b", 2)
+	if _, expelled := tk.IncrBy("b", 1); expelled {
+		t.Errorf("expected no item to be expelled when an item of the list is incremented")
+	}
+
+	item, expelled := tk.IncrBy("c", 10)
+	if !expelled || item != "a" {
+		t.Errorf("expected a to be expelled, got %q %v", item, expelled)
+	}
+	list := tk.List()
+	if len(list) != 2 || list[0] != (TopKItem{"c", 10}) || list[1] != (TopKItem{"b", 3}) {
+		t.Errorf("unexpected list %v", list)
+	}
+	if info := tk.Info(); info != (TopKInfo{K: 2, Width: 8, Depth: 7, Decay: 0.9}) {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
@@ -0,0 +1,320 @@
+package quicklist
+
+import "iter"
+
+// QuickList is a deque of strings stored as a doubly linked list of small arrays, like Redis'
+// quicklist. Pushing and popping at both ends is O(1), while the chunking keeps the per-element
+// overhead low and makes index lookups walk nodes rather than elements.
+type QuickList struct {
+	head, tail *node
+	length     int
+	nodeSize   int // Max number of entries per node
+}
+
+type node struct {
+	entries    []string
+	prev, next *node
+}
+
+func NewQuickList(nodeSize int) *QuickList {
+	return &QuickList{nodeSize: max(nodeSize, 1)}
+}
+
+func (q *QuickList) Len() int {
+	return q.length
+}
+
+// LPUSH
+func (q *QuickList) PushHead(values ...string) {
+	for _, v := range values {
+		if q.head == nil || len(q.head.entries) >= q.nodeSize {
+			q.linkBefore(q.head, &node{})
+		}
+		q.head.entries = append(q.head.entries, "")
+		copy(q.head.entries[1:], q.head.entries)
+		q.head.entries[0] = v
+		q.length++
+	}
+}
+
+// RPUSH
+func (q *QuickList) PushTail(values ...string) {
+	for _, v := range values {
+		if q.tail == nil || len(q.tail.entries) >= q.nodeSize {
+			q.linkAfter(q.tail, &node{})
+		}
+		q.tail.entries = append(q.tail.entries, v)
+		q.length++
+	}
+}
+
+// LPOP
+func (q *QuickList) PopHead() (string, bool) {
+	if q.length == 0 {
+		return "", false
+	}
+	v := q.head.entries[0]
+	q.removeAt(q.head, 0)
+	return v, true
+}
+
+// RPOP
+func (q *QuickList) PopTail() (string, bool) {
+	if q.length == 0 {
+		return "", false
+	}
+	v := q.tail.entries[len(q.tail.entries)-1]
+	q.removeAt(q.tail, len(q.tail.entries)-1)
+	return v, true
+}
+
+// LINDEX, index must be in [0, Len())
+func (q *QuickList) Index(index int) (string, bool) {
+	n, offset := q.locate(index)
+	if n == nil {
+		return "", false
+	}
+	return n.entries[offset], true
+}
+
+// LSET, index must be in [0, Len())
+func (q *QuickList) Set(index int, value string) bool {
+	n, offset := q.locate(index)
+	if n == nil {
+		return false
+	}
+	n.entries[offset] = value
+	return true
+}
+
+// LRANGE, returns the elements from start to stop inclusive, both in [0, Len())
+func (q *QuickList) Range(start, stop int) []string {
+	if start > stop {
+		return []string{}
+	}
+	res := make([]string, 0, stop-start+1)
+	n, offset := q.locate(start)
+	for n != nil && len(res) < cap(res) {
+		take := min(len(n.entries)-offset, cap(res)-len(res))
+		res = append(res, n.entries[offset:offset+take]...)
+		n, offset = n.next, 0
+	}
+	return res
+}
+
+// Elements iterates over the elements and their index, from the head, or from the tail when reverse is set.
+// The list must not change during the iteration.
+func (q *QuickList) Elements(reverse bool) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		if !reverse {
+			i := 0
+			for n := q.head; n != nil; n = n.next {
+				for _, entry := range n.entries {
+					if !yield(i, entry) {
+						return
+					}
+					i++
+				}
+			}
+			return
+		}
+		i := q.length - 1
+		for n := q.tail; n != nil; n = n.prev {
+			for j := len(n.entries) - 1; j >= 0; j-- {
+				if !yield(i, n.entries[j]) {
+					return
+				}
+				i--
+			}
+		}
+	}
+}
+
+// LINSERT, inserts value before or after the first occurrence of pivot.
+// Returns the new length, or -1 if pivot was not found.
+func (q *QuickList) Insert(pivot, value string, after bool) int {
+	for n := q.head; n != nil; n = n.next {
+		for i, entry := range n.entries {
+			if entry == pivot {
+				if after {
+					i++
+				}
+				q.insertAt(n, i, value)
+				return q.length
+			}
+		}
+	}
+	return -1
+}
+
+// LREM, removes up to count occurrences of value, scanning from the head when count > 0 and
+// from the tail when count < 0. All occurrences are removed when count is 0.
+func (q *QuickList) Remove(count int, value string) int {
+	limit := count
+	if limit < 0 {
+		limit = -limit
+	}
+	removed := 0
+	if count >= 0 {
+		for n := q.head; n != nil && (limit == 0 || removed < limit); {
+			next := n.next
+			for i := 0; i < len(n.entries) && (limit == 0 || removed < limit); {
+				if n.entries[i] == value {
+					q.removeAt(n, i)
+					removed++
+					continue
+				}
+				i++
+			}
+			n = next
+		}
+		return removed
+	}
+	for n := q.tail; n != nil && removed < limit; {
+		prev := n.prev
+		for i := len(n.entries) - 1; i >= 0 && removed < limit; i-- {
+			if n.entries[i] == value {
+				q.removeAt(n, i)
+				removed++
+			}
+		}
+		n = prev
+	}
+	return removed
+}
+
+// LTRIM, keeps the elements from start to stop inclusive, both in [0, Len()).
+// The list is emptied when start > stop.
+func (q *QuickList) Trim(start, stop int) {
+	if start > stop {
+		q.head, q.tail, q.length = nil, nil, 0
+		return
+	}
+	keep := stop - start + 1
+	q.trimHead(start)
+	q.trimTail(q.length - keep)
+}
+
+// trimHead removes the first n elements.
+func (q *QuickList) trimHead(n int) {
+	for n > 0 && q.head != nil {
+		if n >= len(q.head.entries) {
+			n -= len(q.head.entries)
+			q.length -= len(q.head.entries)
+			q.unlink(q.head)
+			continue
+		}
+		q.head.entries = append(q.head.entries[:0:0], q.head.entries[n:]...)
+		q.length -= n
+		n = 0
+	}
+}
+
+// trimTail removes the last n elements.
+func (q *QuickList) trimTail(n int) {
+	for n > 0 && q.tail != nil {
+		if n >= len(q.tail.entries) {
+			n -= len(q.tail.entries)
+			q.length -= len(q.tail.entries)
+			q.unlink(q.tail)
+			continue
+		}
+		q.tail.entries = q.tail.entries[:len(q.tail.entries)-n]
+		q.length -= n
+		n = 0
+	}
+}
+
+// locate returns the node holding the element at index and the offset inside it,
+// walking from whichever end is closer.
+func (q *QuickList) locate(index int) (*node, int) {
+	if index < 0 || index >= q.length {
+		return nil, 0
+	}
+	if index < q.length/2 {
+		for n := q.head; n != nil; n = n.next {
+			if index < len(n.entries) {
+				return n, index
+			}
+			index -= len(n.entries)
+		}
+		return nil, 0
+	}
+	index = q.length - 1 - index
+	for n := q.tail; n != nil; n = n.prev {
+		if index < len(n.entries) {
+			return n, len(n.entries) - 1 - index
+		}
+		index -= len(n.entries)
+	}
+	return nil, 0
+}
+
+// insertAt inserts value at offset i of node n, splitting n in two halves when it is full.
+func (q *QuickList) insertAt(n *node, i int, value string) {
+	if len(n.entries) >= q.nodeSize {
+		half := len(n.entries) / 2
+		right := &node{entries: append([]string(nil), n.entries[half:]...)}
+		n.entries = n.entries[:half:half]
+		q.linkAfter(n, right)
+		if i > half {
+			n, i = right, i-half
+		}
+	}
+	n.entries = append(n.entries, "")
+	copy(n.entries[i+1:], n.entries[i:])
+	n.entries[i] = value
+	q.length++
+}
+
+func (q *QuickList) removeAt(n *node, i int) {
+	n.entries = append(n.entries[:i], n.entries[i+1:]...)
+	q.length--
+	if len(n.entries) == 0 {
+		q.unlink(n)
+	}
+}
+
+// linkBefore links n before at, or as the only node when the list is empty.
+func (q *QuickList) linkBefore(at, n *node) {
+	if at == nil {
+		q.head, q.tail = n, n
+		return
+	}
+	n.next, n.prev = at, at.prev
+	if at.prev != nil {
+		at.prev.next = n
+	} else {
+		q.head = n
+	}
+	at.prev = n
+}
+
+// linkAfter links n after at, or as the only node when the list is empty.
+func (q *QuickList) linkAfter(at, n *node) {
+	if at == nil {
+		q.head, q.tail = n, n
+		return
+	}
+	n.prev, n.next = at, at.next
+	if at.next != nil {
+		at.next.prev = n
+	} else {
+		q.tail = n
+	}
+	at.next = n
+}
+
+func (q *QuickList) unlink(n *node) {
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else {
+		q.head = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else {
+		q.tail = n.prev
+	}
+	n.prev, n.next = nil, nil
+}
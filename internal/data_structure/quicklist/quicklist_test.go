@@ -0,0 +1,160 @@
+package quicklist
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// checkList compares the list with the expected elements and checks the node invariants.
+func checkList(t *testing.T, q *QuickList, expected []string) {
+	t.Helper()
+	if q.Len() != len(expected) {
+		t.Fatalf("expected length %d, got %d", len(expected), q.Len())
+	}
+	all := q.Range(0, q.Len()-1)
+	if len(expected) == 0 {
+		all = []string{}
+	}
+	if !reflect.DeepEqual(all, expected) {
+		t.Fatalf("expected %v, got %v", expected, all)
+	}
+	count := 0
+	var prev *node
+	for n := q.head; n != nil; n = n.next {
+		if len(n.entries) == 0 || len(n.entries) > q.nodeSize {
+			t.Fatalf("node has %d entries, max is %d", len(n.entries), q.nodeSize)
+		}
+		if n.prev != prev {
+			t.Fatalf("broken prev link")
+		}
+		prev = n
+		count += len(n.entries)
+	}
+	if prev != q.tail || count != q.Len() {
+		t.Fatalf("broken tail or length")
+	}
+}
+
+func seq(from, to int) []string {
+	var res []string
+	for i := from; i <= to; i++ {
+		res = append(res, strconv.Itoa(i))
+	}
+	return res
+}
+
+func TestPushPop(t *testing.T) {
+	q := NewQuickList(4)
+	q.PushTail(seq(5, 9)...)
+	q.PushHead("4", "3", "2", "1", "0")
+	checkList(t, q, seq(0, 9))
+
+	if v, ok := q.PopHead(); !ok || v != "0" {
+		t.Errorf("expected 0, got %s", v)
+	}
+	if v, ok := q.PopTail(); !ok || v != "9" {
+		t.Errorf("expected 9, got %s", v)
+	}
+	checkList(t, q, seq(1, 8))
+
+	for q.Len() > 0 {
+		q.PopTail()
+	}
+	if _, ok := q.PopHead(); ok {
+		t.Errorf("expected empty list")
+	}
+	checkList(t, q, []string{})
+}
+
+func TestIndexSetRange(t *testing.T) {
+	q := NewQuickList(3)
+	q.PushTail(seq(0, 9)...)
+	for i := 0; i < 10; i++ {
+		if v, ok := q.Index(i); !ok || v != strconv.Itoa(i) {
+			t.Errorf("index %d: got %s", i, v)
+		}
+	}
+	if _, ok := q.Index(10); ok {
+		t.Errorf("expected index 10 to be out of range")
+	}
+	q.Set(7, "x")
+	if !reflect.DeepEqual(q.Range(6, 8), []string{"6", "x", "8"}) {
+		t.Errorf("unexpected range %v", q.Range(6, 8))
+	}
+}
+
+func TestElements(t *testing.T) {
+	q := NewQuickList(3)
+	q.PushTail(seq(0, 9)...)
+	for _, reverse := range []bool{false, true} {
+		var indexes, values []string
+		for i, v := range q.Elements(reverse) {
+			indexes = append(indexes, strconv.Itoa(i))
+			values = append(values, v)
+		}
+		expected := seq(0, 9)
+		if reverse {
+			slices.Reverse(expected)
+		}
+		if !reflect.DeepEqual(indexes, expected) || !reflect.DeepEqual(values, expected) {
+			t.Errorf("reverse %v: expected %v, got indexes %v and values %v", reverse, expected, indexes, values)
+		}
+	}
+
+	// The iteration stops early
+	var values []string
+	for _, v := range q.Elements(true) {
+		values = append(values, v)
+		if len(values) == 2 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(values, []string{"9", "8"}) {
+		t.Errorf("expected [9 8], got %v", values)
+	}
+}
+
+func TestInsertSplitsFullNodes(t *testing.T) {
+	q := NewQuickList(4)
+	q.PushTail("a", "b", "c", "d")
+	if n := q.Insert("c", "x", false); n != 5 {
+		t.Errorf("expected length 5, got %d", n)
+	}
+	q.Insert("d", "y", true)
+	q.Insert("a", "z", false)
+	checkList(t, q, []string{"z", "a", "b", "x", "c", "d", "y"})
+	if n := q.Insert("missing", "v", true); n != -1 {
+		t.Errorf("expected -1, got %d", n)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	q := NewQuickList(2)
+	q.PushTail("a", "b", "a", "c", "a", "a")
+	if n := q.Remove(2, "a"); n != 2 {
+		t.Errorf("expected 2 removed, got %d", n)
+	}
+	checkList(t, q, []string{"b", "c", "a", "a"})
+	if n := q.Remove(-1, "a"); n != 1 {
+		t.Errorf("expected 1 removed, got %d", n)
+	}
+	checkList(t, q, []string{"b", "c", "a"})
+	q.PushTail("a", "a")
+	if n := q.Remove(0, "a"); n != 3 {
+		t.Errorf("expected 3 removed, got %d", n)
+	}
+	checkList(t, q, []string{"b", "c"})
+}
+
+func TestTrim(t *testing.T) {
+	q := NewQuickList(3)
+	q.PushTail(seq(0, 9)...)
+	q.Trim(2, 7)
+	checkList(t, q, seq(2, 7))
+	q.Trim(1, 1)
+	checkList(t, q, []string{"3"})
+	q.Trim(1, 0)
+	checkList(t, q, []string{})
+}
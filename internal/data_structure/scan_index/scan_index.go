@@ -0,0 +1,122 @@
+package scan_index
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"math/rand"
+	"slices"
+)
+
+const minBuckets = 4
+
+var seed = maphash.MakeSeed()
+
+// ScanIndex keeps the names of a collection (keys, set members, hash fields...) in a power-of-two table
+// of buckets selected by the lowest bits of their hash, like the table of a Redis dict. Scan walks the
+// buckets in reverse binary order of their index: the buckets not visited yet stay ahead of the cursor
+// when the table grows or shrinks between calls, so every name present for the whole iteration is
+// returned at least once. The zero value is an empty index ready to use.
+type ScanIndex struct {
+	buckets [][]string
+	size    int
+}
+
+func bucketOf(name string, mask uint64) uint64 {
+	return maphash.String(seed, name) & mask
+}
+
+// Add indexes name, which must not be indexed already
+func (x *ScanIndex) Add(name string) {
+	if x.buckets == nil {
+		x.buckets = make([][]string, minBuckets)
+	}
+	i := bucketOf(name, uint64(len(x.buckets)-1))
+	x.buckets[i] = append(x.buckets[i], name)
+	x.size++
+	if x.size > len(x.buckets) {
+		x.resize(len(x.buckets) * 2)
+	}
+}
+
+// Remove unindexes name, if it is indexed
+func (x *ScanIndex) Remove(name string) {
+	if x.size == 0 {
+		return
+	}
+	i := bucketOf(name, uint64(len(x.buckets)-1))
+	j := slices.Index(x.buckets[i], name)
+	if j < 0 {
+		return
+	}
+	x.buckets[i] = slices.Delete(x.buckets[i], j, j+1)
+	x.size--
+	if len(x.buckets) > minBuckets && x.size*8 < len(x.buckets) {
+		x.resize(len(x.buckets) / 2)
+	}
+}
+
+// resize moves every name to a table of n buckets
+func (x *ScanIndex) resize(n int) {
+	buckets := make([][]string, n)
+	mask := uint64(n - 1)
+	for _, bucket := range x.buckets {
+		for _, name := range bucket {
+			i := bucketOf(name, mask)
+			buckets[i] = append(buckets[i], name)
+		}
+	}
+	x.buckets = buckets
+}
+
+// Len returns the number of names
+func (x *ScanIndex) Len() int {
+	return x.size
+}
+
+// Reset removes every name
+func (x *ScanIndex) Reset() {
+	*x = ScanIndex{}
+}
+
+// Scan calls fn on the names of the buckets from cursor on, until it got at least count names or went
+// through 10 * count empty buckets. It returns the cursor to continue from, 0 when the iteration is over.
+// fn must not change the index.
+func (x *ScanIndex) Scan(cursor uint64, count int, fn func(name string)) uint64 {
+	if x.size == 0 {
+		return 0
+	}
+	count = max(count, 1)
+	emptyVisits := count * 10
+	mask := uint64(len(x.buckets) - 1)
+	for {
+		bucket := x.buckets[cursor&mask]
+		for _, name := range bucket {
+			fn(name)
+		}
+		count -= len(bucket)
+		if len(bucket) == 0 {
+			emptyVisits--
+		}
+
+		// Increment the bits of the mask in reverse order, the higher bits are dropped
+		cursor |= ^mask
+		cursor = bits.Reverse64(bits.Reverse64(cursor) + 1)
+		if cursor == 0 || count <= 0 || emptyVisits <= 0 {
+			return cursor
+		}
+	}
+}
+
+// Random returns a random name, picked from a random non-empty bucket, and false if there is none
+func (x *ScanIndex) Random() (string, bool) {
+	if x.size == 0 {
+		return "", false
+	}
+	// The table is at least 1/8 full, so a few picks find a non-empty bucket
+	for {
+		bucket := x.buckets[rand.Intn(len(x.buckets))]
+		if len(bucket) > 0 {
+			return bucket[rand.Intn(len(bucket))], true
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package scan_index
+
+import (
+	"strconv"
+	"testing"
+)
+
+// scanAll iterates over x with count, calling between after each call, and returns how often each name came up
+func scanAll(x *ScanIndex, count int, between func()) map[string]int {
+	seen := make(map[string]int)
+	cursor := uint64(0)
+	for {
+		cursor = x.Scan(cursor, count, func(name string) { seen[name]++ })
+		if cursor == 0 {
+			return seen
+		}
+		between()
+	}
+}
+
+func TestScanIndex(t *testing.T) {
+	var x ScanIndex
+	if cursor := x.Scan(0, 10, func(string) { t.Errorf("expected no name") }); cursor != 0 {
+		t.Errorf("expected an empty index to end the iteration, got %d", cursor)
+	}
+	if _, ok := x.Random(); ok {
+		t.Errorf("expected no random name in an empty index")
+	}
+
+	for i := 0; i < 1000; i++ {
+		x.Add(strconv.Itoa(i))
+	}
+	x.Remove("7")
+	x.Remove("missing")
+	if x.Len() != 999 {
+		t.Errorf("expected 999 names, got %d", x.Len())
+	}
+
+	seen := scanAll(&x, 10, func() {})
+	if len(seen) != 999 || seen["7"] != 0 {
+		t.Errorf("expected every name but 7, got %d names", len(seen))
+	}
+	for name, n := range seen {
+		if n != 1 {
+			t.Errorf("expected %s once without resizing, got %d times", name, n)
+		}
+	}
+
+	name, ok := x.Random()
+	if !ok || seen[name] != 1 {
+		t.Errorf("expected a random name of the index, got %q", name)
+	}
+
+	x.Reset()
+	if x.Len() != 0 || x.Scan(0, 10, func(string) {}) != 0 {
+		t.Errorf("expected Reset to empty the index")
+	}
+}
+
+func TestScanIndexResizeDuringScan(t *testing.T) {
+	// The names present for the whole iteration come up even when the table grows then shrinks
+	var x ScanIndex
+	for i := 0; i < 100; i++ {
+		x.Add("kept" + strconv.Itoa(i))
+	}
+	calls := 0
+	seen := scanAll(&x, 5, func() {
+		calls++
+		switch {
+		case calls <= 5:
+			for i := 0; i < 200; i++ {
+				x.Add("grow" + strconv.Itoa(calls) + "-" + strconv.Itoa(i))
+			}
+		case calls <= 10:
+			for i := 0; i < 200; i++ {
+				x.Remove("grow" + strconv.Itoa(calls-5) + "-" + strconv.Itoa(i))
+			}
+		}
+	})
+	for i := 0; i < 100; i++ {
+		if seen["kept"+strconv.Itoa(i)] == 0 {
+			t.Errorf("expected kept%d to come up", i)
+		}
+	}
+}
@@ -1,15 +1,56 @@
 package simple_set
 
+import (
+	"iter"
+	"math/rand"
+	"slices"
+	"strconv"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/config"
+	"github.com/spaghetti-lover/multithread-redis/internal/data_structure/scan_index"
+)
+
+// SimpleSet starts with the intset encoding: a sorted array of integers, much smaller than a map.
+// It converts to a hash table for good once a member is not an integer or the set grows past
+// maxIntsetEntries.
 type SimpleSet struct {
-	key  string
-	dict map[string]struct{}
+	key              string
+	dict             map[string]struct{}  // nil while the set is an intset
+	index            scan_index.ScanIndex // The members of dict, for SSCAN
+	intset           []int64
+	maxIntsetEntries int
 }
 
 func NewSimpleSet(key string) *SimpleSet {
 	return &SimpleSet{
-		key:  key,
-		dict: make(map[string]struct{}),
+		key:              key,
+		maxIntsetEntries: config.SetMaxIntsetEntries,
+	}
+}
+
+// IsIntset reports whether the set uses the intset encoding
+func (s *SimpleSet) IsIntset() bool {
+	return s.dict == nil
+}
+
+// toInt64 parses member if it is the canonical form of an integer, so that it formats back the same
+func toInt64(member string) (int64, bool) {
+	v, err := strconv.ParseInt(member, 10, 64)
+	if err != nil || strconv.FormatInt(v, 10) != member {
+		return 0, false
+	}
+	return v, true
+}
+
+// convertToDict moves the members of the intset to a hash table
+func (s *SimpleSet) convertToDict() {
+	s.dict = make(map[string]struct{}, len(s.intset))
+	for _, v := range s.intset {
+		member := strconv.FormatInt(v, 10)
+		s.dict[member] = struct{}{}
+		s.index.Add(member)
 	}
+	s.intset = nil
 }
 
 // SADD
@@ -17,8 +58,23 @@ func (s *SimpleSet) Add(members ...string) int {
 	added := 0
 
 	for _, m := range members {
+		if s.IsIntset() {
+			if v, ok := toInt64(m); ok {
+				i, exist := slices.BinarySearch(s.intset, v)
+				if exist {
+					continue
+				}
+				if len(s.intset) < s.maxIntsetEntries {
+					s.intset = slices.Insert(s.intset, i, v)
+					added += 1
+					continue
+				}
+			}
+			s.convertToDict()
+		}
 		if _, exist := s.dict[m]; !exist {
 			s.dict[m] = struct{}{}
+			s.index.Add(m)
 			added += 1
 		}
 	}
@@ -30,8 +86,20 @@ func (s *SimpleSet) Add(members ...string) int {
 func (s *SimpleSet) Rem(members ...string) int {
 	removed := 0
 	for _, m := range members {
+		if s.IsIntset() {
+			v, ok := toInt64(m)
+			if !ok {
+				continue
+			}
+			if i, exist := slices.BinarySearch(s.intset, v); exist {
+				s.intset = slices.Delete(s.intset, i, i+1)
+				removed += 1
+			}
+			continue
+		}
 		if _, exist := s.dict[m]; exist {
 			delete(s.dict, m)
+			s.index.Remove(m)
 			removed += 1
 		}
 	}
@@ -40,7 +108,14 @@ func (s *SimpleSet) Rem(members ...string) int {
 
 // SISMEMBER
 func (s *SimpleSet) IsMember(member string) int {
-	_, exist := s.dict[member]
+	var exist bool
+	if s.IsIntset() {
+		if v, ok := toInt64(member); ok {
+			_, exist = slices.BinarySearch(s.intset, v)
+		}
+	} else {
+		_, exist = s.dict[member]
+	}
 
 	if exist {
 		return 1
@@ -50,11 +125,70 @@ func (s *SimpleSet) IsMember(member string) int {
 
 // SMEMBERS
 func (s *SimpleSet) Members() []string {
-	m := make([]string, 0, len(s.dict))
+	m := make([]string, 0, s.Len())
 
+	if s.IsIntset() {
+		for _, v := range s.intset {
+			m = append(m, strconv.FormatInt(v, 10))
+		}
+		return m
+	}
 	for k := range s.dict {
 		m = append(m, k)
 	}
 
 	return m
 }
+
+// All iterates over the members without copying them, in no particular order. The set must not
+// change during the iteration.
+func (s *SimpleSet) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		if s.IsIntset() {
+			for _, v := range s.intset {
+				if !yield(strconv.FormatInt(v, 10)) {
+					return
+				}
+			}
+			return
+		}
+		for member := range s.dict {
+			if !yield(member) {
+				return
+			}
+		}
+	}
+}
+
+// RandomMember returns a member of a non-empty set without copying the others, for SPOP and SRANDMEMBER.
+// A hash table member is the first one its randomized iteration order visits.
+func (s *SimpleSet) RandomMember() string {
+	if s.IsIntset() {
+		return strconv.FormatInt(s.intset[rand.Intn(len(s.intset))], 10)
+	}
+	for k := range s.dict {
+		return k
+	}
+	return ""
+}
+
+// SCARD
+func (s *SimpleSet) Len() int {
+	if s.IsIntset() {
+		return len(s.intset)
+	}
+	return len(s.dict)
+}
+
+// Scan returns the members of the next SSCAN batch from cursor and the cursor to continue from, 0 when
+// the iteration is over. Like Redis does for its compact encodings, an intset is returned whole at once.
+func (s *SimpleSet) Scan(cursor uint64, count int) ([]string, uint64) {
+	if s.IsIntset() {
+		return s.Members(), 0
+	}
+	var batch []string
+	next := s.index.Scan(cursor, count, func(member string) {
+		batch = append(batch, member)
+	})
+	return batch, next
+}
@@ -39,4 +39,99 @@ func TestSimpleSet(t *testing.T) {
 	if set.IsMember("a") != 0 {
 		t.Errorf("expected a to be removed")
 	}
+
+	// --- Test SCARD ---
+	if set.Len() != 2 {
+		t.Errorf("expected 2 members, got %d", set.Len())
+	}
+}
+
+func TestSimpleSetIntset(t *testing.T) {
+	set := NewSimpleSet("ids")
+	set.maxIntsetEntries = 4
+
+	// --- Integers stay in the intset, sorted ---
+	if added := set.Add("3", "-1", "10", "3"); added != 3 {
+		t.Errorf("expected 3 added, got %d", added)
+	}
+	if !set.IsIntset() {
+		t.Errorf("expected the intset encoding")
+	}
+	if members := set.Members(); !reflect.DeepEqual(members, []string{"-1", "3", "10"}) {
+		t.Errorf("expected sorted members, got %v", members)
+	}
+	// "03" is not the canonical form of 3, it is a different member
+	if set.IsMember("3") != 1 || set.IsMember("03") != 0 || set.IsMember("x") != 0 {
+		t.Errorf("unexpected membership")
+	}
+	if removed := set.Rem("-1", "x", "7"); removed != 1 {
+		t.Errorf("expected 1 removed, got %d", removed)
+	}
+
+	// --- A non-integer member converts the set ---
+	set.Add("03")
+	if set.IsIntset() {
+		t.Errorf("expected the hash table encoding")
+	}
+	members := set.Members()
+	sort.Strings(members)
+	if !reflect.DeepEqual(members, []string{"03", "10", "3"}) {
+		t.Errorf("expected %v, got %v", []string{"03", "10", "3"}, members)
+	}
+
+	// --- So does growing past maxIntsetEntries ---
+	big := NewSimpleSet("big")
+	big.maxIntsetEntries = 4
+	big.Add("1", "2", "3", "4")
+	if !big.IsIntset() {
+		t.Errorf("expected the intset encoding")
+	}
+	if added := big.Add("5"); added != 1 || big.IsIntset() || big.Len() != 5 {
+		t.Errorf("expected the hash table encoding with 5 members")
+	}
+}
+
+func TestSimpleSetRandomMember(t *testing.T) {
+	for _, members := range [][]string{{"1", "2", "3"}, {"a", "b", "c"}} {
+		set := NewSimpleSet("s")
+		set.Add(members...)
+
+		// Every member comes up, and nothing else
+		seen := make(map[string]bool)
+		for i := 0; i < 1000; i++ {
+			member := set.RandomMember()
+			if set.IsMember(member) != 1 {
+				t.Fatalf("expected a member, got %q", member)
+			}
+			seen[member] = true
+		}
+		if len(seen) != len(members) {
+			t.Errorf("expected every member of %v to come up, got %v", members, seen)
+		}
+	}
+}
+
+func TestSimpleSetAll(t *testing.T) {
+	for _, members := range [][]string{{"1", "2", "3"}, {"a", "b", "c"}} {
+		set := NewSimpleSet("s")
+		set.Add(members...)
+		var all []string
+		for member := range set.All() {
+			all = append(all, member)
+		}
+		sort.Strings(all)
+		if !reflect.DeepEqual(all, members) {
+			t.Errorf("expected %v, got %v", members, all)
+		}
+
+		// The iteration stops early
+		n := 0
+		for range set.All() {
+			n++
+			break
+		}
+		if n != 1 {
+			t.Errorf("expected to stop after 1 member, got %d", n)
+		}
+	}
 }
@@ -1,5 +1,7 @@
 package sorted_set
 
+import "math"
+
 // BTreeNode represents a node in the B+ Tree
 type BTreeNode struct {
 	Items    []*Item
@@ -31,14 +33,7 @@ func (t *BTreeIndex) Add(score float64, member string) int {
 	}
 
 	// Find the correct leaf to insert into
-	node := t.Root
-	for !node.IsLeaf {
-		i := 0
-		for i < len(node.Items) && score >= node.Items[i].Score {
-			i++
-		}
-		node = node.Children[i]
-	}
+	node := t.findLeaf(item)
 
 	// Check if the member already exists in the leaf node
 	for i, existingItem := range node.Items {
@@ -62,18 +57,25 @@ func (t *BTreeIndex) Add(score float64, member string) int {
 	return 1 // Added new item
 }
 
-func (t *BTreeIndex) RemoveByScore(score float64, member string) int {
-	// Optimized removal with known score
-
-	// Find the correct leaf node using score (O(log n))
+// findLeaf returns the leaf that holds item, or where it belongs. Members with the same score
+// can span several leaves, so the separators are compared by score and then by member.
+func (t *BTreeIndex) findLeaf(item *Item) *BTreeNode {
 	node := t.Root
 	for !node.IsLeaf {
 		i := 0
-		for i < len(node.Items) && score >= node.Items[i].Score {
+		for i < len(node.Items) && item.CompareTo(node.Items[i]) >= 0 {
 			i++
 		}
 		node = node.Children[i]
 	}
+	return node
+}
+
+func (t *BTreeIndex) RemoveByScore(score float64, member string) int {
+	// Optimized removal with known score
+
+	// Find the correct leaf node using score (O(log n))
+	node := t.findLeaf(&Item{Score: score, Member: member})
 
 	// Find and remove the item in the leaf node
 	for i, item := range node.Items {
@@ -418,6 +420,74 @@ func (t *BTreeIndex) Count() int {
 	return count
 }
 
+// Len implements OrderedIndex.Len
+func (t *BTreeIndex) Len() int {
+	return t.Count()
+}
+
+// RangeByRank implements OrderedIndex.RangeByRank
+func (t *BTreeIndex) RangeByRank(start, stop int, reverse bool) []*Item {
+	if !reverse {
+		return t.GetRangeByRank(start, stop)
+	}
+	last := t.Count() - 1
+	result := t.GetRangeByRank(last-stop, last-start)
+	reverseItems(result)
+	return result
+}
+
+// collect walks the leaves from the one holding from, and returns the items accepted by inRange
+// until past returns true
+func (t *BTreeIndex) collect(from *Item, inRange, past func(item *Item) bool) []*Item {
+	var result []*Item
+	for node := t.findLeaf(from); node != nil; node = node.Next {
+		for _, item := range node.Items {
+			if past(item) {
+				return result
+			}
+			if inRange(item) {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+func (t *BTreeIndex) itemsByScore(r ScoreRange) []*Item {
+	return t.collect(&Item{Score: r.Min}, func(item *Item) bool {
+		return r.Contains(item.Score)
+	}, func(item *Item) bool {
+		return !r.belowMax(item.Score)
+	})
+}
+
+// RangeByScore implements OrderedIndex.RangeByScore
+func (t *BTreeIndex) RangeByScore(r ScoreRange, offset, count int, reverse bool) []*Item {
+	result := t.itemsByScore(r)
+	if reverse {
+		reverseItems(result)
+	}
+	return limitItems(result, offset, count)
+}
+
+// RangeByLex implements OrderedIndex.RangeByLex
+func (t *BTreeIndex) RangeByLex(r LexRange, offset, count int, reverse bool) []*Item {
+	result := t.collect(&Item{Score: math.Inf(-1)}, func(item *Item) bool {
+		return r.Contains(item.Member)
+	}, func(item *Item) bool {
+		return !r.belowMax(item.Member)
+	})
+	if reverse {
+		reverseItems(result)
+	}
+	return limitItems(result, offset, count)
+}
+
+// CountByScore implements OrderedIndex.CountByScore
+func (t *BTreeIndex) CountByScore(r ScoreRange) int {
+	return len(t.itemsByScore(r))
+}
+
 func (t *BTreeIndex) Clear() {
 	t.Root = &BTreeNode{IsLeaf: true}
 }
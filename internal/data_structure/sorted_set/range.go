@@ -0,0 +1,77 @@
+package sorted_set
+
+// ScoreRange is an interval of scores, a bound is excluded when its Ex flag is set
+type ScoreRange struct {
+	Min, Max     float64
+	MinEx, MaxEx bool
+}
+
+func (r *ScoreRange) aboveMin(score float64) bool {
+	if r.MinEx {
+		return score > r.Min
+	}
+	return score >= r.Min
+}
+
+func (r *ScoreRange) belowMax(score float64) bool {
+	if r.MaxEx {
+		return score < r.Max
+	}
+	return score <= r.Max
+}
+
+// Contains reports whether score is inside the range
+func (r *ScoreRange) Contains(score float64) bool {
+	return r.aboveMin(score) && r.belowMax(score)
+}
+
+// LexRange is an interval of members, meant for sorted sets whose members all have the same score.
+// MinInf stands for "-" (lower than any member) and MaxInf for "+" (greater than any member).
+type LexRange struct {
+	Min, Max       string
+	MinEx, MaxEx   bool
+	MinInf, MaxInf bool
+}
+
+func (r *LexRange) aboveMin(member string) bool {
+	switch {
+	case r.MinInf:
+		return true
+	case r.MinEx:
+		return member > r.Min
+	}
+	return member >= r.Min
+}
+
+func (r *LexRange) belowMax(member string) bool {
+	switch {
+	case r.MaxInf:
+		return true
+	case r.MaxEx:
+		return member < r.Max
+	}
+	return member <= r.Max
+}
+
+// Contains reports whether member is inside the range
+func (r *LexRange) Contains(member string) bool {
+	return r.aboveMin(member) && r.belowMax(member)
+}
+
+// limitItems applies a LIMIT offset count to items, a negative count keeps everything after offset
+func limitItems(items []*Item, offset, count int) []*Item {
+	if offset < 0 || offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if count >= 0 && count < len(items) {
+		items = items[:count]
+	}
+	return items
+}
+
+func reverseItems(items []*Item) {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+}
@@ -0,0 +1,102 @@
+package sorted_set
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var indexConfigs = []IndexConfig{
+	{Type: IndexTypeBTree, Degree: 4},
+	{Type: IndexTypeSkipList, MaxLevel: 16},
+}
+
+func members(items []*Item) []string {
+	res := make([]string, len(items))
+	for i, item := range items {
+		res[i] = item.Member
+	}
+	return res
+}
+
+func TestSortedSet_Ranges(t *testing.T) {
+	for _, config := range indexConfigs {
+		t.Run(string(config.Type), func(t *testing.T) {
+			ss, err := NewSortedSet(config)
+			assert.NoError(t, err)
+			for i, member := range []string{"a", "b", "c", "d", "e"} {
+				ss.Add(float64(i+1), member)
+			}
+
+			assert.Equal(t, []string{"b", "c", "d"}, members(ss.RangeByRank(1, 3, false)))
+			assert.Equal(t, []string{"e", "d"}, members(ss.RangeByRank(0, 1, true)))
+			assert.Empty(t, ss.RangeByRank(3, 1, false))
+
+			all := ScoreRange{Min: math.Inf(-1), Max: math.Inf(1)}
+			assert.Equal(t, []string{"a", "b", "c", "d", "e"}, members(ss.RangeByScore(all, 0, -1, false)))
+			assert.Equal(t, []string{"b", "c"}, members(ss.RangeByScore(all, 1, 2, false)))
+			assert.Equal(t, []string{"d", "c"}, members(ss.RangeByScore(all, 1, 2, true)))
+			assert.Empty(t, ss.RangeByScore(all, 5, -1, false))
+
+			r := ScoreRange{Min: 2, Max: 4, MinEx: true}
+			assert.Equal(t, []string{"c", "d"}, members(ss.RangeByScore(r, 0, -1, false)))
+			assert.Equal(t, []string{"d", "c"}, members(ss.RangeByScore(r, 0, -1, true)))
+			assert.Equal(t, 2, ss.Count(r))
+			assert.Equal(t, 0, ss.Count(ScoreRange{Min: 6, Max: 10}))
+			assert.Equal(t, 0, ss.Count(ScoreRange{Min: 3, Max: 3, MaxEx: true}))
+
+			assert.Equal(t, 2, ss.RemoveRangeByScore(ScoreRange{Min: 4, Max: math.Inf(1)}))
+			assert.Equal(t, 1, ss.RemoveRangeByRank(0, 0))
+			assert.Equal(t, []string{"b", "c"}, members(ss.RangeByRank(0, 1, false)))
+		})
+	}
+}
+
+func TestSortedSet_RangeByLex(t *testing.T) {
+	for _, config := range indexConfigs {
+		t.Run(string(config.Type), func(t *testing.T) {
+			ss, err := NewSortedSet(config)
+			assert.NoError(t, err)
+			for _, member := range []string{"e", "c", "a", "d", "b", "f", "g"} {
+				ss.Add(0, member)
+			}
+
+			all := LexRange{MinInf: true, MaxInf: true}
+			assert.Equal(t, []string{"a", "b", "c", "d", "e", "f", "g"}, members(ss.RangeByLex(all, 0, -1, false)))
+			assert.Equal(t, []string{"f", "e"}, members(ss.RangeByLex(all, 1, 2, true)))
+
+			r := LexRange{Min: "b", Max: "e", MaxEx: true}
+			assert.Equal(t, []string{"b", "c", "d"}, members(ss.RangeByLex(r, 0, -1, false)))
+			assert.Equal(t, []string{"d", "c", "b"}, members(ss.RangeByLex(r, 0, -1, true)))
+			assert.Empty(t, ss.RangeByLex(LexRange{Min: "x", MaxInf: true}, 0, -1, false))
+		})
+	}
+}
+
+// Many members with the same score span several B+ Tree leaves, they must stay ordered by member
+// and removable after random updates
+func TestSortedSet_SameScoreAcrossNodes(t *testing.T) {
+	for _, config := range indexConfigs {
+		t.Run(string(config.Type), func(t *testing.T) {
+			ss, err := NewSortedSet(config)
+			assert.NoError(t, err)
+			rnd := rand.New(rand.NewSource(1))
+			for _, i := range rnd.Perm(200) {
+				ss.Add(float64(i%3), fmt.Sprintf("m%03d", i))
+			}
+			for _, i := range rnd.Perm(200)[:100] {
+				assert.Equal(t, 1, ss.Remove(fmt.Sprintf("m%03d", i)))
+			}
+
+			items := ss.RangeByRank(0, ss.Len()-1, false)
+			assert.Len(t, items, 100)
+			for i := 1; i < len(items); i++ {
+				assert.Equal(t, -1, items[i-1].CompareTo(items[i]))
+			}
+			assert.Equal(t, len(ss.RangeByScore(ScoreRange{Min: 1, Max: 1}, 0, -1, false)), ss.Count(ScoreRange{Min: 1, Max: 1}))
+		})
+	}
+}
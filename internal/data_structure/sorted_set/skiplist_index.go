@@ -85,6 +85,56 @@ func (sl *SkipListIndex) GetRank(member string) int {
 	return -1
 }
 
+// GetByRank implements OrderedIndex.GetByRank with O(log N) complexity, following the spans
+func (sl *SkipListIndex) GetByRank(rank int) *Item {
+	x := sl.nodeByRank(rank)
+	if x == nil {
+		return nil
+	}
+	return &Item{Score: x.score, Member: x.ele}
+}
+
+// Len implements OrderedIndex.Len
+func (sl *SkipListIndex) Len() int {
+	return int(sl.length)
+}
+
+// RangeByRank implements OrderedIndex.RangeByRank
+func (sl *SkipListIndex) RangeByRank(start, stop int, reverse bool) []*Item {
+	if start < 0 || stop < start {
+		return nil
+	}
+	first := start
+	if reverse {
+		first = int(sl.length) - 1 - start
+	}
+	return sl.walk(sl.nodeByRank(first), 0, stop-start+1, reverse, func(*SkiplistNode) bool { return true })
+}
+
+// RangeByScore implements OrderedIndex.RangeByScore
+func (sl *SkipListIndex) RangeByScore(r ScoreRange, offset, count int, reverse bool) []*Item {
+	aboveMin, belowMax := scoreBounds(r)
+	return sl.rangeBy(aboveMin, belowMax, offset, count, reverse)
+}
+
+// RangeByLex implements OrderedIndex.RangeByLex
+func (sl *SkipListIndex) RangeByLex(r LexRange, offset, count int, reverse bool) []*Item {
+	aboveMin, belowMax := lexBounds(r)
+	return sl.rangeBy(aboveMin, belowMax, offset, count, reverse)
+}
+
+// CountByScore implements OrderedIndex.CountByScore with O(log N) complexity, from the ranks of
+// the first and the last node in range
+func (sl *SkipListIndex) CountByScore(r ScoreRange) int {
+	aboveMin, belowMax := scoreBounds(r)
+	first := sl.firstInRange(aboveMin, belowMax)
+	if first == nil {
+		return 0
+	}
+	last := sl.lastInRange(aboveMin, belowMax)
+	return int(sl.getRankByScoreAndMember(last.score, last.ele)-sl.getRankByScoreAndMember(first.score, first.ele)) + 1
+}
+
 // Private helper methods
 
 func (sl *SkipListIndex) findMember(member string) *SkiplistNode {
@@ -240,3 +290,91 @@ func (sl *SkipListIndex) RemoveByScore(score float64, member string) int {
 	}
 	return 0
 }
+
+func (sl *SkipListIndex) nodeByRank(rank int) *SkiplistNode {
+	if rank < 0 || rank >= int(sl.length) {
+		return nil
+	}
+	target := uint32(rank + 1) // spans count 1-based ranks
+	var traversed uint32
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && traversed+x.levels[i].span <= target {
+			traversed += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		if traversed == target {
+			return x
+		}
+	}
+	return nil
+}
+
+func scoreBounds(r ScoreRange) (aboveMin, belowMax func(x *SkiplistNode) bool) {
+	return func(x *SkiplistNode) bool { return r.aboveMin(x.score) },
+		func(x *SkiplistNode) bool { return r.belowMax(x.score) }
+}
+
+func lexBounds(r LexRange) (aboveMin, belowMax func(x *SkiplistNode) bool) {
+	return func(x *SkiplistNode) bool { return r.aboveMin(x.ele) },
+		func(x *SkiplistNode) bool { return r.belowMax(x.ele) }
+}
+
+// firstInRange returns the lowest node within both bounds, or nil if there is none
+func (sl *SkipListIndex) firstInRange(aboveMin, belowMax func(x *SkiplistNode) bool) *SkiplistNode {
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && !aboveMin(x.levels[i].forward) {
+			x = x.levels[i].forward
+		}
+	}
+	x = x.levels[0].forward
+	if x == nil || !belowMax(x) {
+		return nil
+	}
+	return x
+}
+
+// lastInRange returns the highest node within both bounds, or nil if there is none
+func (sl *SkipListIndex) lastInRange(aboveMin, belowMax func(x *SkiplistNode) bool) *SkiplistNode {
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && belowMax(x.levels[i].forward) {
+			x = x.levels[i].forward
+		}
+	}
+	if x == sl.head || !aboveMin(x) {
+		return nil
+	}
+	return x
+}
+
+func (sl *SkipListIndex) rangeBy(aboveMin, belowMax func(x *SkiplistNode) bool, offset, count int, reverse bool) []*Item {
+	if reverse {
+		return sl.walk(sl.lastInRange(aboveMin, belowMax), offset, count, true, aboveMin)
+	}
+	return sl.walk(sl.firstInRange(aboveMin, belowMax), offset, count, false, belowMax)
+}
+
+// walk collects the items from x, forward or backward, while inRange accepts them. The first
+// offset nodes are skipped and at most count items are returned, a negative count means no limit
+func (sl *SkipListIndex) walk(x *SkiplistNode, offset, count int, reverse bool, inRange func(x *SkiplistNode) bool) []*Item {
+	if offset < 0 {
+		return nil
+	}
+	next := func(x *SkiplistNode) *SkiplistNode {
+		if reverse {
+			return x.backward
+		}
+		return x.levels[0].forward
+	}
+	for ; x != nil && offset > 0; offset-- {
+		x = next(x)
+	}
+	var result []*Item
+	for ; x != nil && count != 0 && inRange(x); count-- {
+		result = append(result, &Item{Score: x.score, Member: x.ele})
+		x = next(x)
+	}
+	return result
+}
@@ -157,3 +157,38 @@ func TestSkipListIndex_Integration(t *testing.T) {
 		assert.EqualValues(t, expectedRank, rank)
 	}
 }
+
+func TestSkipListIndex_GetByRank(t *testing.T) {
+	skiplist := NewSkipListIndex(16)
+	for i, member := range []string{"e", "c", "a", "d", "b"} {
+		skiplist.Add(float64(10-i), member)
+	}
+	// scores: e=10, c=9, a=8, d=7, b=6
+	expected := []string{"b", "d", "a", "c", "e"}
+	for rank, member := range expected {
+		item := skiplist.GetByRank(rank)
+		assert.NotNil(t, item)
+		assert.EqualValues(t, member, item.Member)
+	}
+	assert.Nil(t, skiplist.GetByRank(5))
+	assert.Nil(t, skiplist.GetByRank(-1))
+}
+
+func TestSortedSet_PopMinMax(t *testing.T) {
+	for _, indexType := range []IndexType{IndexTypeSkipList, IndexTypeBTree} {
+		ss, err := NewSortedSet(IndexConfig{Type: indexType})
+		assert.NoError(t, err)
+		ss.Add(1, "a")
+		ss.Add(2, "b")
+		ss.Add(3, "c")
+
+		items := ss.PopMin(1)
+		assert.Len(t, items, 1)
+		assert.EqualValues(t, "a", items[0].Member)
+		items = ss.PopMax(5)
+		assert.Len(t, items, 2)
+		assert.EqualValues(t, "c", items[0].Member)
+		assert.EqualValues(t, "b", items[1].Member)
+		assert.EqualValues(t, 0, ss.Len())
+	}
+}
@@ -76,3 +76,74 @@ func (ss *SortedSet) Remove(member string) int {
 	}
 	return result
 }
+
+// Len returns the number of members
+func (ss *SortedSet) Len() int {
+	return len(ss.MemberScore)
+}
+
+// PopMin removes and returns up to count members with the lowest scores, lowest first
+func (ss *SortedSet) PopMin(count int) []*Item {
+	return ss.pop(count, func() int { return 0 })
+}
+
+// PopMax removes and returns up to count members with the highest scores, highest first
+func (ss *SortedSet) PopMax(count int) []*Item {
+	return ss.pop(count, func() int { return ss.Len() - 1 })
+}
+
+func (ss *SortedSet) pop(count int, rank func() int) []*Item {
+	var res []*Item
+	for len(res) < count && ss.Len() > 0 {
+		item := ss.Index.GetByRank(rank())
+		if item == nil {
+			break
+		}
+		res = append(res, &Item{Score: item.Score, Member: item.Member})
+		ss.Remove(item.Member)
+	}
+	return res
+}
+
+// RangeByRank returns the members from rank start to stop, both inclusive and within [0, Len())
+func (ss *SortedSet) RangeByRank(start, stop int, reverse bool) []*Item {
+	return ss.Index.RangeByRank(start, stop, reverse)
+}
+
+// RangeByScore returns the members with a score in r, see OrderedIndex.RangeByScore
+func (ss *SortedSet) RangeByScore(r ScoreRange, offset, count int, reverse bool) []*Item {
+	return ss.Index.RangeByScore(r, offset, count, reverse)
+}
+
+// RangeByLex returns the members in r, see OrderedIndex.RangeByLex
+func (ss *SortedSet) RangeByLex(r LexRange, offset, count int, reverse bool) []*Item {
+	return ss.Index.RangeByLex(r, offset, count, reverse)
+}
+
+// Count returns the number of members with a score in r
+func (ss *SortedSet) Count(r ScoreRange) int {
+	return ss.Index.CountByScore(r)
+}
+
+// RemoveRangeByRank removes the members from rank start to stop and returns how many were removed
+func (ss *SortedSet) RemoveRangeByRank(start, stop int) int {
+	return ss.removeItems(ss.RangeByRank(start, stop, false))
+}
+
+// RemoveRangeByScore removes the members with a score in r and returns how many were removed
+func (ss *SortedSet) RemoveRangeByScore(r ScoreRange) int {
+	return ss.removeItems(ss.RangeByScore(r, 0, -1, false))
+}
+
+func (ss *SortedSet) removeItems(items []*Item) int {
+	// The B+ Tree returns its own items, copy the members before the tree changes under them
+	members := make([]string, len(items))
+	for i, item := range items {
+		members[i] = item.Member
+	}
+	removed := 0
+	for _, member := range members {
+		removed += ss.Remove(member)
+	}
+	return removed
+}
@@ -10,9 +10,30 @@ type OrderedIndex interface {
 	// Return -1 if member not found
 	GetRank(member string) int
 
+	// GetByRank returns the item at the given rank (0-based index)
+	// Returns nil if rank is out of range
+	GetByRank(rank int) *Item
+
 	// RemoveByScore removes an item by its score and member with O(log N) complexity.
 	// Returns 1 if member was removed, 0 if not found
 	RemoveByScore(score float64, member string) int
+
+	// Len returns the number of items
+	Len() int
+
+	// RangeByRank returns the items from rank start to stop, both inclusive and within [0, Len()).
+	// When reverse is set, ranks are counted from the highest score
+	RangeByRank(start, stop int, reverse bool) []*Item
+
+	// RangeByScore returns the items with a score in r, lowest first or highest first when reverse is set.
+	// The first offset items are skipped and at most count are returned, a negative count means no limit
+	RangeByScore(r ScoreRange, offset, count int, reverse bool) []*Item
+
+	// RangeByLex is RangeByScore for a range of members, the items are expected to have the same score
+	RangeByLex(r LexRange, offset, count int, reverse bool) []*Item
+
+	// CountByScore returns the number of items with a score in r
+	CountByScore(r ScoreRange) int
 }
 
 // IndexType represents the type of index to create
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+)
+
+// Client IDs are unique across all I/O handlers, so a late reply never reaches a new connection reusing the fd
+var nextClientID atomic.Uint64
+
+// client holds the state of one connection served by an IOHandler.
+type client struct {
+	id     uint64
+	fd     int
+	conn   net.Conn
+	reader *core.RespReader // Buffers partial and pipelined commands across reads
+
+	// Pipelined commands may complete out of order when they run on different workers.
+	// Replies are parked in pending until every earlier reply has been appended to outBuf.
+	nextSeq  uint64            // Sequence number of the next dispatched command
+	flushSeq uint64            // Sequence number of the next reply to append to outBuf
+	pending  map[uint64][]byte // Completed replies waiting for an earlier one
+	outBuf   []byte            // Replies not written to the socket yet
+
+	writeMonitored bool // Whether the handler waits for the socket to become writable
+	closing        bool // Close once every reply is written, set after a protocol error
+	sentBlocking   bool // Whether the client sent a blocking command, the workers are told when it disconnects
+}
+
+func newClient(fd int, conn net.Conn) *client {
+	return &client{
+		id:      nextClientID.Add(1),
+		fd:      fd,
+		conn:    conn,
+		reader:  core.NewRespReader(),
+		pending: make(map[uint64][]byte),
+	}
+}
+
+// addReply stores the reply of the command with the given sequence number
+// and moves every reply that is now in order to the output buffer.
+func (c *client) addReply(seq uint64, data []byte) {
+	c.pending[seq] = data
+	for {
+		data, ok := c.pending[c.flushSeq]
+		if !ok {
+			return
+		}
+		delete(c.pending, c.flushSeq)
+		c.outBuf = append(c.outBuf, data...)
+		c.flushSeq++
+	}
+}
+
+// write sends as much of the output buffer as the socket accepts without blocking.
+func (c *client) write() error {
+	for len(c.outBuf) > 0 {
+		n, err := syscall.Write(c.fd, c.outBuf)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EAGAIN {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c.outBuf = c.outBuf[n:]
+	}
+	c.outBuf = nil
+	return nil
+}
+
+// done reports whether a closing client has no reply left to send.
+func (c *client) done() bool {
+	return c.closing && c.flushSeq == c.nextSeq && len(c.outBuf) == 0
+}
@@ -1,28 +1,27 @@
 package server
 
 import (
+	"errors"
 	"io"
 	"log"
 	"net"
 	"sync"
 	"syscall"
 
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
 	"github.com/spaghetti-lover/multithread-redis/internal/core"
 	"github.com/spaghetti-lover/multithread-redis/internal/core/iomux"
 )
 
-// import (
-// 	"sync"
-
-// 	"github.com/spaghetti-lover/multithread-redis/internal/core/iomux"
-// )
-
 type IOHandler struct {
 	id            int
 	ioMultiplexer iomux.IOMultiplexer
-	mu            sync.Mutex
+	mu            sync.Mutex // Guards the clients and the multiplexer, shared by Run and replyLoop
 	server        *Server
-	conns         map[int]net.Conn
+	conns         map[int]*client
+	clients       map[uint64]*client // Same clients indexed by id, to route replies
+	readBuf       []byte             // Only used by Run since events are served one at a time
+	replyCh       chan *core.Reply   // Replies sent back by the workers
 }
 
 func NewIOHandler(id int, server *Server) (*IOHandler, error) {
@@ -35,7 +34,10 @@ func NewIOHandler(id int, server *Server) (*IOHandler, error) {
 		id:            id,
 		ioMultiplexer: multiplexer,
 		server:        server,
-		conns:         make(map[int]net.Conn), // map from fd to corresponding connection
+		conns:         make(map[int]*client), // map from fd to corresponding connection
+		clients:       make(map[uint64]*client),
+		readBuf:       make([]byte, constant.IOBufferSize),
+		replyCh:       make(chan *core.Reply, constant.ReplyChannelSize),
 	}, nil
 }
 
@@ -55,7 +57,9 @@ func (h *IOHandler) AddConn(conn net.Conn) error {
 		connFd = int(fd)
 		log.Printf("I/O Handler %d is monitoring fd %d", h.id, connFd)
 		// Store the connection object so it's not garbage collected
-		h.conns[connFd] = conn
+		c := newClient(connFd, conn)
+		h.conns[connFd] = c
+		h.clients[c.id] = c
 		// Add to epoll
 		h.ioMultiplexer.Monitor(iomux.Event{
 			Fd: connFd,
@@ -69,15 +73,116 @@ func (h *IOHandler) AddConn(conn net.Conn) error {
 func (h *IOHandler) closeConn(fd int) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.closeConnLocked(fd)
+}
 
-	if conn, ok := h.conns[fd]; ok {
-		conn.Close()
+func (h *IOHandler) closeConnLocked(fd int) {
+	if c, ok := h.conns[fd]; ok {
+		h.ioMultiplexer.Unmonitor(iomux.Event{Fd: fd, Op: iomux.OpRead})
+		if c.writeMonitored {
+			h.ioMultiplexer.Unmonitor(iomux.Event{Fd: fd, Op: iomux.OpWrite})
+		}
+		c.conn.Close()
 		delete(h.conns, fd)
+		delete(h.clients, c.id)
+		if c.sentBlocking {
+			// Not waiting for the worker queues while holding the lock
+			go h.server.clientGone(c.id)
+		}
+	}
+}
+
+// flush writes the client's pending output. If the socket can't take all of it, the handler
+// waits for it to become writable instead of blocking, and stops waiting once the buffer is empty.
+// Must be called with h.mu held.
+func (h *IOHandler) flush(c *client) {
+	if err := c.write(); err != nil {
+		log.Printf("Write error on fd %d: %v", c.fd, err)
+		h.closeConnLocked(c.fd)
+		return
+	}
+	if c.done() {
+		h.closeConnLocked(c.fd)
+		return
+	}
+
+	pending := len(c.outBuf) > 0
+	if pending != c.writeMonitored {
+		event := iomux.Event{Fd: c.fd, Op: iomux.OpWrite}
+		var err error
+		if pending {
+			err = h.ioMultiplexer.Monitor(event)
+		} else {
+			err = h.ioMultiplexer.Unmonitor(event)
+		}
+		if err != nil {
+			log.Printf("Can not update write monitoring on fd %d: %v", c.fd, err)
+			return
+		}
+		c.writeMonitored = pending
+	}
+}
+
+// replyLoop delivers the replies sent by the workers to their clients, so the event loop
+// never waits for a worker.
+func (h *IOHandler) replyLoop() {
+	for reply := range h.replyCh {
+		h.mu.Lock()
+		// the client might have disconnected while its command was executing
+		if c, ok := h.clients[reply.Task.ClientID]; ok {
+			c.addReply(reply.Task.Seq, reply.Data)
+			h.flush(c)
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *IOHandler) handleRead(c *client) {
+	cmds, err := readCommandsConn(c, h.readBuf)
+	var protoErr *core.ProtocolError
+	if err != nil && !errors.As(err, &protoErr) {
+		if err == io.EOF || err == syscall.ECONNRESET {
+			//log.Printf("Client disconnected (fd: %d)", c.fd)
+		} else {
+			log.Printf("Read error on fd %d: %v", c.fd, err)
+		}
+		h.closeConn(c.fd)
+		return
+	}
+
+	for _, cmd := range cmds {
+		h.mu.Lock()
+		task := &core.Task{
+			Command:  cmd,
+			ClientID: c.id,
+			Seq:      c.nextSeq,
+			ReplyCh:  h.replyCh,
+		}
+		c.nextSeq++
+		if spec := core.LookupCommand(cmd.Cmd); spec != nil && spec.HasFlag(core.FlagBlocking) {
+			c.sentBlocking = true
+		}
+		h.mu.Unlock()
+		// dispatch the command to the corresponding Worker, the reply comes back through replyLoop
+		h.server.dispatch(task)
+	}
+
+	if protoErr != nil {
+		// Reply with the error after every pending reply, then close the connection
+		h.mu.Lock()
+		seq := c.nextSeq
+		c.nextSeq++
+		c.closing = true
+		h.ioMultiplexer.Unmonitor(iomux.Event{Fd: c.fd, Op: iomux.OpRead})
+		c.addReply(seq, core.Encode(protoErr, false))
+		h.flush(c)
+		h.mu.Unlock()
 	}
 }
 
 func (h *IOHandler) Run() {
 	log.Printf("I/O Handler %d started", h.id)
+	go h.replyLoop()
 	for {
 		// wait for data from any of the fd in the monitoring list
 		events, err := h.ioMultiplexer.Wait()
@@ -89,7 +194,7 @@ func (h *IOHandler) Run() {
 			connFd := event.Fd
 
 			h.mu.Lock()
-			conn, ok := h.conns[connFd]
+			c, ok := h.conns[connFd]
 			h.mu.Unlock()
 
 			if !ok {
@@ -97,26 +202,13 @@ func (h *IOHandler) Run() {
 				continue
 			}
 
-			cmd, err := readCommandConn(conn)
-			if err != nil {
-				if err == io.EOF || err == syscall.ECONNRESET {
-					//log.Printf("Client disconnected (fd: %d)", connFd)
-				} else {
-					log.Printf("Read error on fd %d: %v", connFd, err)
-				}
-				h.closeConn(connFd) // <-- Use our new closing function
+			if event.Op == iomux.OpWrite {
+				h.mu.Lock()
+				h.flush(c)
+				h.mu.Unlock()
 				continue
 			}
-
-			replyCh := make(chan []byte, 1)
-			task := &core.Task{
-				Command: cmd,
-				ReplyCh: replyCh,
-			}
-			// dispatch the command to the corresponding Worker
-			h.server.dispatch(task)
-			res := <-replyCh
-			syscall.Write(connFd, res)
+			h.handleRead(c)
 		}
 	}
 }
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+	"strconv"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/constant"
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+)
+
+// fanOut queues subCmds[i] on worker workerIDs[i] and replies to task with merge(replies), where
+// replies[i] is the reply of subCmds[i]. The sub-tasks are queued before returning so they keep their
+// order relative to the next commands of the client; the replies are awaited in a separate goroutine.
+func (s *Server) fanOut(task *core.Task, workerIDs []int, subCmds []*core.Command, merge func(replies [][]byte) []byte) {
+	replyCh := make(chan *core.Reply, len(subCmds))
+	for i, cmd := range subCmds {
+		s.workers[workerIDs[i]].TaskCh <- &core.Task{
+			Command:  cmd,
+			ClientID: task.ClientID,
+			Seq:      uint64(i),
+			ReplyCh:  replyCh,
+		}
+	}
+	go func() {
+		replies := make([][]byte, len(subCmds))
+		for range subCmds {
+			reply := <-replyCh
+			replies[reply.Task.Seq] = reply.Data
+		}
+		task.Reply(merge(replies))
+	}()
+}
+
+// dispatchAllShards runs the command on every worker and merges the replies by its response policy.
+func (s *Server) dispatchAllShards(task *core.Task, spec *core.CommandSpec) {
+	workerIDs := make([]int, s.numWorkers)
+	subCmds := make([]*core.Command, s.numWorkers)
+	for i := range workerIDs {
+		workerIDs[i] = i
+		subCmds[i] = task.Command
+	}
+	s.fanOut(task, workerIDs, subCmds, func(replies [][]byte) []byte {
+		switch spec.Name {
+		case "RANDOMKEY":
+			return mergeRandomKey(replies)
+		case "INFO":
+			return mergeInfo(replies)
+		}
+		return mergeReplies(spec, replies, nil)
+	})
+}
+
+// dispatchMultiShard splits the keys of the command by shard, runs one sub-command per shard
+// and merges the replies by the command's response policy.
+func (s *Server) dispatchMultiShard(task *core.Task, spec *core.CommandSpec, keys []string) {
+	args := task.Command.Args
+	step := max(spec.Step, 1)
+	if (len(args)-spec.FirstKey+1)%step != 0 {
+		// The last key misses some of its arguments, any worker can reply with the error
+		s.workers[rand.Intn(s.numWorkers)].TaskCh <- task
+		return
+	}
+
+	var workerIDs []int
+	var subCmds []*core.Command
+	var keyIndexes [][]int // Position in keys of every key sent to each sub-command
+	shardIndex := make(map[int]int)
+	for i, key := range keys {
+		workerID := s.getPartitionID(key)
+		idx, ok := shardIndex[workerID]
+		if !ok {
+			idx = len(subCmds)
+			shardIndex[workerID] = idx
+			workerIDs = append(workerIDs, workerID)
+			subCmds = append(subCmds, &core.Command{Cmd: task.Command.Cmd})
+			keyIndexes = append(keyIndexes, nil)
+		}
+		// a key argument is followed by step-1 arguments belonging to it, e.g. the value in MSET
+		argPos := spec.FirstKey - 1 + i*step
+		subCmds[idx].Args = append(subCmds[idx].Args, args[argPos:argPos+step]...)
+		keyIndexes[idx] = append(keyIndexes[idx], i)
+	}
+
+	s.fanOut(task, workerIDs, subCmds, func(replies [][]byte) []byte {
+		return mergeReplies(spec, replies, func(elements [][][]byte) []byte {
+			// Put every element back at the position of its key
+			res := make([][]byte, len(keys))
+			for i, shardElements := range elements {
+				for j, element := range shardElements {
+					res[keyIndexes[i][j]] = element
+				}
+			}
+			return core.EncodeArrayElements(res)
+		})
+	})
+}
+
+// dispatchAtomic runs a command whose keys live on several workers atomically: every involved worker
+// is paused, the command runs on their storages from a separate goroutine, then they are released.
+func (s *Server) dispatchAtomic(task *core.Task, keys []string, workerIDs []int) {
+	release := make(chan struct{})
+	storageChs := make([]<-chan *core.Storage, len(workerIDs))
+	// Pause requests are queued under a lock so that two atomic commands sharing workers
+	// are queued in the same order on each of them, which rules out deadlocks.
+	s.pauseMu.Lock()
+	for i, workerID := range workerIDs {
+		storageChs[i] = s.workers[workerID].Pause(release)
+	}
+	s.pauseMu.Unlock()
+
+	go func() {
+		storages := make(map[int]*core.Storage, len(workerIDs))
+		for i, workerID := range workerIDs {
+			storages[workerID] = <-storageChs[i]
+		}
+		owners := make(map[string]*core.Storage, len(keys))
+		for _, key := range keys {
+			owners[key] = storages[s.getPartitionID(key)]
+		}
+		res := core.ExecuteOnShards(task.Command, owners)
+		close(release)
+		task.Reply(res)
+	}()
+}
+
+// dispatchScan serves SCAN over all shards. The cursor returned to the client encodes both the shard
+// being iterated and the cursor inside that shard: cursor = shardCursor*numWorkers + shard.
+func (s *Server) dispatchScan(task *core.Task) {
+	args := task.Command.Args
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		// let a worker reply with the invalid cursor error
+		s.workers[rand.Intn(s.numWorkers)].TaskCh <- task
+		return
+	}
+	numWorkers := uint64(s.numWorkers)
+	shard := cursor % numWorkers
+	subArgs := append([]string{strconv.FormatUint(cursor/numWorkers, 10)}, args[1:]...)
+	subCmd := &core.Command{Cmd: task.Command.Cmd, Args: subArgs}
+
+	s.fanOut(task, []int{int(shard)}, []*core.Command{subCmd}, func(replies [][]byte) []byte {
+		elements, err := core.DecodeArrayElements(replies[0])
+		if err != nil || len(elements) != 2 {
+			return replies[0]
+		}
+		value, _ := core.Decode(elements[0])
+		shardCursor, _ := strconv.ParseUint(value.(string), 10, 64)
+		var next uint64
+		if shardCursor != 0 {
+			next = shardCursor*numWorkers + shard
+		} else if shard+1 < numWorkers {
+			next = shard + 1
+		}
+		return core.EncodeArrayElements([][]byte{
+			core.Encode(strconv.FormatUint(next, 10), false),
+			elements[1],
+		})
+	})
+}
+
+// mergeReplies combines the replies of sub-commands by the command's response policy. Without a
+// response policy, array replies are concatenated, or passed to mergeArrays when it is not nil.
+func mergeReplies(spec *core.CommandSpec, replies [][]byte, mergeArrays func(elements [][][]byte) []byte) []byte {
+	for _, reply := range replies {
+		if len(reply) > 0 && reply[0] == '-' {
+			return reply
+		}
+	}
+
+	switch {
+	case spec.HasTip(core.TipResponseAggSum):
+		var sum int64
+		for _, reply := range replies {
+			value, err := core.Decode(reply)
+			if n, ok := value.(int64); err == nil && ok {
+				sum += n
+			}
+		}
+		return core.Encode(sum, false)
+	case spec.HasTip(core.TipResponseAllSucceeded):
+		return constant.RespOk
+	}
+
+	elements := make([][][]byte, len(replies))
+	for i, reply := range replies {
+		var err error
+		if elements[i], err = core.DecodeArrayElements(reply); err != nil {
+			return reply
+		}
+	}
+	if mergeArrays != nil {
+		return mergeArrays(elements)
+	}
+	return core.EncodeArrayElements(slices.Concat(elements...))
+}
+
+// mergeRandomKey picks the reply of a random shard holding at least one key.
+func mergeRandomKey(replies [][]byte) []byte {
+	var keys [][]byte
+	for _, reply := range replies {
+		if len(reply) > 0 && reply[0] == '-' {
+			return reply
+		}
+		if !bytes.Equal(reply, constant.RespNil) {
+			keys = append(keys, reply)
+		}
+	}
+	if len(keys) == 0 {
+		return constant.RespNil
+	}
+	return keys[rand.Intn(len(keys))]
+}
+
+// mergeInfo sums the keyspace sections of every shard. The other replies are the same on every shard.
+func mergeInfo(replies [][]byte) []byte {
+	var keys, expires int
+	var ttlSum uint64
+	for _, reply := range replies {
+		value, err := core.Decode(reply)
+		info, isString := value.(string)
+		if err != nil || !isString {
+			return reply
+		}
+		shardKeys, shardExpires, avgTTL, ok := core.ParseKeyspaceInfo(info)
+		if !ok {
+			return reply
+		}
+		keys += shardKeys
+		expires += shardExpires
+		ttlSum += avgTTL * uint64(shardExpires)
+	}
+	var avgTTL uint64
+	if expires > 0 {
+		avgTTL = ttlSum / uint64(expires)
+	}
+	return core.Encode(core.KeyspaceInfo(keys, expires, avgTTL), false)
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/spaghetti-lover/multithread-redis/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, numWorkers int) *Server {
+	s := &Server{
+		workers:    make([]*core.Worker, numWorkers),
+		numWorkers: numWorkers,
+	}
+	for i := range s.workers {
+		s.workers[i] = core.NewWorker(i, 1024)
+		s.workers[i].Start(context.Background())
+	}
+	t.Cleanup(func() {
+		for _, w := range s.workers {
+			w.Stop()
+		}
+	})
+	return s
+}
+
+func (s *Server) exec(cmd string, args ...string) string {
+	replyCh := make(chan *core.Reply, 1)
+	s.dispatch(&core.Task{Command: &core.Command{Cmd: cmd, Args: args}, ReplyCh: replyCh})
+	return string((<-replyCh).Data)
+}
+
+// keysOnDifferentShards returns n keys owned by n different workers.
+func keysOnDifferentShards(s *Server, n int) []string {
+	var keys []string
+	seen := make(map[int]bool)
+	for i := 0; len(keys) < n; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		if id := s.getPartitionID(key); !seen[id] {
+			seen[id] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestMultiShardKeyspaceCommands(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 4)
+	for _, key := range keys {
+		assert.EqualValues(t, "+OK\r\n", s.exec("SET", key, "v"))
+	}
+	s.exec("SADD", "myset", "a")
+
+	assert.EqualValues(t, ":5\r\n", s.exec("DBSIZE"))
+	assert.EqualValues(t, ":3\r\n", s.exec("EXISTS", keys[0], keys[1], keys[1], "missing"))
+	assert.EqualValues(t, ":2\r\n", s.exec("DEL", keys[0], keys[1], "missing"))
+	assert.EqualValues(t, ":3\r\n", s.exec("DBSIZE"))
+	assert.EqualValues(t, "*1\r\n$5\r\nmyset\r\n", s.exec("KEYS", "my*"))
+
+	assert.EqualValues(t, "+OK\r\n", s.exec("FLUSHALL"))
+	assert.EqualValues(t, ":0\r\n", s.exec("DBSIZE"))
+	assert.EqualValues(t, "$-1\r\n", s.exec("RANDOMKEY"))
+}
+
+func TestMultiShardInfoKeyspace(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 4)
+	for _, key := range keys {
+		s.exec("SET", key, "v")
+	}
+	s.exec("EXPIRE", keys[0], "100")
+	s.exec("EXPIRE", keys[3], "300")
+
+	// Every call sums the sections of all the shards
+	for i := 0; i < 5; i++ {
+		value, err := core.Decode([]byte(s.exec("INFO", "keyspace")))
+		assert.NoError(t, err)
+		keyCount, expires, avgTTL, ok := core.ParseKeyspaceInfo(value.(string))
+		assert.True(t, ok)
+		assert.EqualValues(t, 4, keyCount)
+		assert.EqualValues(t, 2, expires)
+		assert.InDelta(t, 200000, avgTTL, 1000)
+	}
+	assert.EqualValues(t, "-(error) ERR unknown INFO section\r\n", s.exec("INFO", "x"))
+}
+
+func TestMultiShardRename(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 2)
+	s.exec("SET", keys[0], "hello")
+	assert.EqualValues(t, "+OK\r\n", s.exec("RENAME", keys[0], keys[1]))
+	assert.EqualValues(t, "$-1\r\n", s.exec("GET", keys[0]))
+	assert.EqualValues(t, "$5\r\nhello\r\n", s.exec("GET", keys[1]))
+	assert.EqualValues(t, "-ERR no such key\r\n", s.exec("RENAME", keys[0], keys[1]))
+}
+
+func TestMultiShardScan(t *testing.T) {
+	s := newTestServer(t, 4)
+	expected := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		s.exec("SET", key, "v")
+		expected[key] = true
+	}
+
+	seen := make(map[string]bool)
+	cursor := "0"
+	for {
+		elements, err := core.DecodeArrayElements([]byte(s.exec("SCAN", cursor, "COUNT", "7")))
+		assert.NoError(t, err)
+		next, _ := core.Decode(elements[0])
+		batch, _ := core.Decode(elements[1])
+		for _, key := range batch.([]interface{}) {
+			seen[key.(string)] = true
+		}
+		cursor = next.(string)
+		if cursor == "0" {
+			break
+		}
+	}
+	assert.EqualValues(t, expected, seen)
+}
+
+func TestMultiShardMultiKeyStrings(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 4)
+	assert.EqualValues(t, "+OK\r\n", s.exec("MSET", keys[0], "0", keys[1], "1", keys[2], "2"))
+	assert.EqualValues(t, "$1\r\n1\r\n", s.exec("GET", keys[1]))
+	assert.EqualValues(t, "*4\r\n$1\r\n2\r\n$-1\r\n$1\r\n0\r\n$1\r\n1\r\n", s.exec("MGET", keys[2], keys[3], keys[0], keys[1]))
+	assert.EqualValues(t, "-ERR wrong number of arguments for 'mset' command\r\n", s.exec("MSET", keys[0], "0", keys[1]))
+
+	// MSETNX sets nothing when any key exists, whatever shard it is on.
+	assert.EqualValues(t, ":0\r\n", s.exec("MSETNX", keys[3], "3", keys[0], "x"))
+	assert.EqualValues(t, ":0\r\n", s.exec("EXISTS", keys[3]))
+	assert.EqualValues(t, "$1\r\n0\r\n", s.exec("GET", keys[0]))
+	s.exec("DEL", keys[0])
+	assert.EqualValues(t, ":1\r\n", s.exec("MSETNX", keys[3], "3", keys[0], "x"))
+	assert.EqualValues(t, "*2\r\n$1\r\n3\r\n$1\r\nx\r\n", s.exec("MGET", keys[3], keys[0]))
+}
+
+func TestMultiShardListMove(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 2)
+	s.exec("RPUSH", keys[0], "a", "b")
+	assert.EqualValues(t, "$1\r\nb\r\n", s.exec("LMOVE", keys[0], keys[1], "RIGHT", "LEFT"))
+	assert.EqualValues(t, "$1\r\na\r\n", s.exec("RPOPLPUSH", keys[0], keys[1]))
+	assert.EqualValues(t, ":0\r\n", s.exec("EXISTS", keys[0]))
+	assert.EqualValues(t, "*2\r\n$1\r\na\r\n$1\r\nb\r\n", s.exec("LRANGE", keys[1], "0", "-1"))
+}
+
+func TestMultiShardSortedSetAlgebra(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 3)
+	s.exec("ZADD", keys[0], "1", "a", "2", "b")
+	s.exec("ZADD", keys[1], "10", "b", "20", "c")
+	assert.EqualValues(t, ":3\r\n", s.exec("ZUNIONSTORE", keys[2], "2", keys[0], keys[1], "WEIGHTS", "1", "2"))
+	assert.EqualValues(t, "*6\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$2\r\n22\r\n$1\r\nc\r\n$2\r\n40\r\n", s.exec("ZRANGE", keys[2], "0", "-1", "WITHSCORES"))
+	assert.EqualValues(t, "*2\r\n$1\r\nb\r\n$2\r\n10\r\n", s.exec("ZINTER", "2", keys[0], keys[1], "AGGREGATE", "MAX", "WITHSCORES"))
+	assert.EqualValues(t, ":1\r\n", s.exec("ZRANGESTORE", keys[0], keys[1], "0", "0"))
+	assert.EqualValues(t, "*1\r\n$1\r\nb\r\n", s.exec("ZRANGE", keys[0], "0", "-1"))
+	assert.EqualValues(t, "-ERR at least 1 input key is needed for 'zunionstore' command\r\n", s.exec("ZUNIONSTORE", keys[2], "0", keys[0]))
+}
+
+func TestMultiShardSetAlgebra(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 3)
+	s.exec("SADD", keys[0], "a", "b")
+	s.exec("SADD", keys[1], "b", "c")
+	assert.EqualValues(t, ":1\r\n", s.exec("SINTERSTORE", keys[2], keys[0], keys[1]))
+	assert.EqualValues(t, "*1\r\n$1\r\nb\r\n", s.exec("SMEMBERS", keys[2]))
+	assert.EqualValues(t, ":1\r\n", s.exec("SINTERCARD", "2", keys[0], keys[1]))
+	assert.EqualValues(t, ":1\r\n", s.exec("SMOVE", keys[0], keys[1], "a"))
+	assert.EqualValues(t, ":3\r\n", s.exec("SCARD", keys[1]))
+}
+
+func TestMultiShardHyperLogLog(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 3)
+	s.exec("PFADD", keys[0], "a", "b")
+	s.exec("PFADD", keys[1], "b", "c")
+	assert.EqualValues(t, ":3\r\n", s.exec("PFCOUNT", keys[0], keys[1]))
+	assert.EqualValues(t, "+OK\r\n", s.exec("PFMERGE", keys[2], keys[0], keys[1]))
+	assert.EqualValues(t, ":3\r\n", s.exec("PFCOUNT", keys[2]))
+}
+
+func TestMultiShardBitOp(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 3)
+	s.exec("SETBIT", keys[0], "1", "1")
+	s.exec("SETBIT", keys[1], "9", "1")
+	assert.EqualValues(t, ":2\r\n", s.exec("BITOP", "OR", keys[2], keys[0], keys[1]))
+	assert.EqualValues(t, ":2\r\n", s.exec("BITCOUNT", keys[2]))
+}
+
+func TestMultiShardTDigestMerge(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 3)
+	s.exec("TDIGEST.CREATE", keys[0])
+	s.exec("TDIGEST.CREATE", keys[1])
+	s.exec("TDIGEST.ADD", keys[0], "1", "2")
+	s.exec("TDIGEST.ADD", keys[1], "3", "4")
+	assert.EqualValues(t, "+OK\r\n", s.exec("TDIGEST.MERGE", keys[2], "2", keys[0], keys[1]))
+	assert.EqualValues(t, "*2\r\n$1\r\n1\r\n$1\r\n4\r\n", s.exec("TDIGEST.QUANTILE", keys[2], "0", "1"))
+}
+
+func TestBlockingCommandsStayOnOneShard(t *testing.T) {
+	s := newTestServer(t, 4)
+	keys := keysOnDifferentShards(s, 2)
+	assert.EqualValues(t, "-CROSSSLOT Keys in request don't hash to the same slot\r\n", s.exec("BLPOP", keys[0], keys[1], "0"))
+
+	replyCh := make(chan *core.Reply, 1)
+	s.dispatch(&core.Task{Command: &core.Command{Cmd: "BLPOP", Args: []string{keys[0], "0"}}, ReplyCh: replyCh})
+	s.exec("RPUSH", keys[0], "job")
+	assert.EqualValues(t, "*2\r\n$"+fmt.Sprint(len(keys[0]))+"\r\n"+keys[0]+"\r\n$3\r\njob\r\n", string((<-replyCh).Data))
+}
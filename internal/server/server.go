@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"hash/fnv"
 	"io"
 	"log"
@@ -9,6 +10,7 @@ import (
 	"net"
 	"os"
 	"runtime"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -31,6 +33,9 @@ type Server struct {
 
 	// For round-robin assigment of new connection to I/O handlers
 	nextIOHandler int
+
+	// Serializes queueing the pause requests of atomic multi-shard commands
+	pauseMu sync.Mutex
 }
 
 func (s *Server) getPartitionID(key string) int {
@@ -40,23 +45,64 @@ func (s *Server) getPartitionID(key string) int {
 }
 
 func (s *Server) dispatch(task *core.Task) {
+	cmd := task.Command
+	spec := core.LookupCommand(cmd.Cmd)
+	if spec == nil || !spec.CheckArity(cmd.Args) {
+		// Any worker can reply with the error
+		s.workers[rand.Intn(s.numWorkers)].TaskCh <- task
+		return
+	}
+
+	switch {
+	case spec.Name == "SCAN":
+		s.dispatchScan(task)
+		return
+	case spec.HasTip(core.TipRequestAllShards):
+		s.dispatchAllShards(task, spec)
+		return
+	}
+
 	// Commands like PING etc., don't have a key.
 	// We can send them to any worker.
-	var key string
-	var workerID int
-	if len(task.Command.Args) > 0 {
-		key = task.Command.Args[0]
-		workerID = s.getPartitionID(key)
-	} else {
-		workerID = rand.Intn(s.numWorkers)
+	keys := spec.GetKeys(cmd.Args)
+	if len(keys) == 0 {
+		s.workers[rand.Intn(s.numWorkers)].TaskCh <- task
+		return
+	}
+
+	var workerIDs []int
+	for _, key := range keys {
+		if workerID := s.getPartitionID(key); !slices.Contains(workerIDs, workerID) {
+			workerIDs = append(workerIDs, workerID)
+		}
+	}
+	switch {
+	case len(workerIDs) == 1:
+		s.workers[workerIDs[0]].TaskCh <- task
+	case spec.HasFlag(core.FlagBlocking):
+		// A client can only wait on the keys of one shard
+		task.Reply(core.Encode(errCrossSlot, false))
+	case spec.HasTip(core.TipRequestMultiShard):
+		s.dispatchMultiShard(task, spec, keys)
+	default:
+		slices.Sort(workerIDs)
+		s.dispatchAtomic(task, keys, workerIDs)
+	}
+}
+
+var errCrossSlot = errors.New("CROSSSLOT Keys in request don't hash to the same slot")
+
+// clientGone tells every worker that a client which sent blocking commands has disconnected.
+func (s *Server) clientGone(clientID uint64) {
+	for _, w := range s.workers {
+		w.ClientGone(clientID)
 	}
-	s.workers[workerID].TaskCh <- task
 }
 
 func NewServer() *Server {
-	numCores := runtime.NumCPU()  // 8
-	numIOHandlers := numCores / 2 // 4
-	numWorkers := numCores / 2    // 4
+	numCores := runtime.NumCPU()        // 8
+	numIOHandlers := max(1, numCores/2) // 4
+	numWorkers := max(1, numCores/2)    // 4
 	log.Printf("Initializing server with %d workers and %d io handler\n", numWorkers, numIOHandlers)
 
 	s := &Server{
@@ -109,8 +155,9 @@ func (s *Server) Stop() {
 
 var serverStatus int32 = constant.ServerStatusIdle
 
-func readCommand(fd int) (*core.Command, error) {
-	var buf = make([]byte, 512)
+// readCommands reads the data available on fd and returns every complete command buffered by reader.
+// A *core.ProtocolError is returned together with the commands that preceded the malformed frame.
+func readCommands(fd int, reader *core.RespReader, buf []byte) ([]*core.Command, error) {
 	n, err := syscall.Read(fd, buf)
 	if err != nil {
 		return nil, err
@@ -118,18 +165,18 @@ func readCommand(fd int) (*core.Command, error) {
 	if n == 0 {
 		return nil, io.EOF
 	}
-	return core.ParseCmd(buf)
+	reader.Feed(buf[:n])
+	return reader.ReadCommands()
 }
 
-func readCommandConn(conn net.Conn) (*core.Command, error) {
-	var buf = make([]byte, 512)
+func readCommandsConn(c *client, buf []byte) ([]*core.Command, error) {
 	// Use the Read method from the net.Conn interface
-	n, err := conn.Read(buf)
+	n, err := c.conn.Read(buf)
 	if err != nil {
 		return nil, err // This will properly handle io.EOF
 	}
-	return core.ParseCmd(buf[:n])
-
+	c.reader.Feed(buf[:n])
+	return c.reader.ReadCommands()
 }
 
 // func respond(data string, fd int) error {
@@ -178,6 +225,21 @@ func RunIoMultiplexingServer(wg *sync.WaitGroup) {
 
 	var events = make([]iomux.Event, config.MaxConnection)
 	var lastActiveExpireExecTime = time.Now()
+	var readBuf = make([]byte, constant.IOBufferSize)
+	// Each connection keeps its own reader so partial and pipelined commands survive across reads
+	var readers = make(map[int]*core.RespReader)
+
+	closeConn := func(fd int) {
+		err := ioMultiplexer.Unmonitor(iomux.Event{
+			Fd: fd,
+			Op: iomux.OpRead,
+		})
+		if err != nil {
+			log.Println("Can not unmonitor: ", err)
+		}
+		delete(readers, fd)
+		_ = syscall.Close(fd)
+	}
 
 	for atomic.LoadInt32(&serverStatus) != constant.ServerStatusShutdown {
 		// check last execution time and call if it is more than 100ms ago.
@@ -215,6 +277,7 @@ func RunIoMultiplexingServer(wg *sync.WaitGroup) {
 					continue
 				}
 				log.Printf("set up a new connection")
+				readers[connFd] = core.NewRespReader()
 				// ask epoll to monitor this connection
 				if err = ioMultiplexer.Monitor(iomux.Event{
 					Fd: connFd,
@@ -227,36 +290,27 @@ func RunIoMultiplexingServer(wg *sync.WaitGroup) {
 					return
 				}
 				// handle data from an existing connection
-				// read the command, execute it and write back the response.
-				cmd, err := readCommand(events[i].Fd)
-				if err != nil {
+				// read the commands, execute them and write back the responses.
+				connFd := events[i].Fd
+				cmds, err := readCommands(connFd, readers[connFd], readBuf)
+				var protoErr *core.ProtocolError
+				if err != nil && !errors.As(err, &protoErr) {
 					if err == io.EOF || err == syscall.ECONNRESET {
 						log.Println("client disconnected: ", err)
-
-						err = ioMultiplexer.Unmonitor(iomux.Event{
-							Fd: events[i].Fd,
-							Op: iomux.OpRead,
-						})
-						if err != nil {
-							log.Println("Can not unmonitor: ", err)
-						}
-						_ = syscall.Close(events[i].Fd)
+						closeConn(connFd)
 						continue
 					}
 					log.Println("read error:", err)
 					continue
 				}
-				if err = core.ExecuteAndResponse(cmd, events[i].Fd); err != nil {
+				if err = core.ExecuteAndResponse(cmds, connFd); err != nil {
 					log.Println("err write: ", err)
-
-					err = ioMultiplexer.Unmonitor(iomux.Event{
-						Fd: events[i].Fd,
-						Op: iomux.OpRead,
-					})
-					if err != nil {
-						log.Println("Can not unmonitor: ", err)
-					}
-					_ = syscall.Close(events[i].Fd)
+					closeConn(connFd)
+					continue
+				}
+				if protoErr != nil {
+					syscall.Write(connFd, core.Encode(protoErr, false))
+					closeConn(connFd)
 				}
 			}
 		}